@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -8,14 +9,27 @@ import (
 	"time"
 
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/announcements"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/auth"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/background"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/chat"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/events"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/feedback"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/follows"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/friends"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/middleware"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/moderation"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/organizations"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/recommendations"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/registration"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/rolemap"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/search"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/sentiment"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/users"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/webhooks"
 )
 
 func main() {
@@ -35,28 +49,90 @@ func main() {
 	updater.Start()
 	log.Println("⏰ Background Status Updater started")
 
+	dispatcher := background.NewEmailDispatcher(db, notifications.NewTransportFromEnv())
+	dispatcher.Start()
+	log.Println("📧 Background Email Dispatcher started")
+
 	// 2. Setup Repos & Services
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	notifyService := notifications.NewService()
+	notifyService := notifications.NewService(db)
+	stream := notifications.NewStream(eventRepo, dsn)
+	if err := stream.Start(); err != nil {
+		log.Fatal("Failed to start notification stream:", err)
+	}
+
+	reminderOffsets, err := background.ParseOffsets(os.Getenv("REMINDER_OFFSETS"))
+	if err != nil {
+		log.Fatal("Invalid REMINDER_OFFSETS:", err)
+	}
+	reminders := background.NewReminderScheduler(db, notifyService, reminderOffsets)
+	reminders.Start()
+	log.Println("🔔 Background Reminder Scheduler started")
+
+	digests := background.NewDigestBuilder(db, notifyService)
+	digests.Start()
+	log.Println("📰 Background Digest Builder started")
+
+	retention, err := background.ParseRetention(os.Getenv("NOTIFICATION_RETENTION"))
+	if err != nil {
+		log.Fatal("Invalid NOTIFICATION_RETENTION:", err)
+	}
+	notifRetention := background.NewNotificationRetention(db, retention)
+	notifRetention.Start()
+	log.Println("🧹 Background Notification Retention started")
+
+	webhookService := webhooks.NewService(db)
+	webhookDispatcher := background.NewWebhookDispatcher(db)
+	webhookDispatcher.Start()
+	log.Println("🪝 Background Webhook Dispatcher started")
+
 	aiService := ai.NewService()
+	aiService.Quota = ai.NewQuotaFromEnv(db)
+	sentimentScorer := background.NewSentimentScorer(db, &sentiment.Scorer{AI: aiService})
+	sentimentScorer.Start()
+	log.Println("💬 Background Sentiment Scorer started")
+
+	announcementService := announcements.NewService(db, notifyService)
+	announcementScheduler := background.NewAnnouncementScheduler(announcementService)
+	announcementScheduler.Start()
+	log.Println("📣 Background Announcement Scheduler started")
+
+	searchIndex := search.NewIndexFromEnv(db)
+	embeddingIndexer := background.NewEmbeddingIndexer(searchIndex)
+	embeddingIndexer.Start()
+	log.Println("🧭 Background Embedding Indexer started")
 
 	regService := &registration.Service{
 		DB:            db,
 		Notifications: notifyService,
+		Webhooks:      webhookService,
 	}
 
+	moderationService := moderation.NewServiceFromEnv(db, aiService)
+	orgRepo := store.NewOrganizationRepository(db)
 	eventHandler := &events.Handler{
 		Repo:          eventRepo,
 		UserRepo:      userRepo,
 		Notifications: notifyService,
 		AI:            aiService,
+		Webhooks:      webhookService,
+		Moderation:    moderationService,
+		Search:        &search.Service{Repo: eventRepo, Index: searchIndex},
+		Orgs:          orgRepo,
+	}
+	protectedSubjects := users.ProtectedSubjectsFromEnv()
+	if n, err := userRepo.ProtectSubjects(context.Background(), protectedSubjects); err != nil {
+		log.Printf("⚠️ Failed to protect PROTECTED_ADMIN_SUBJECTS accounts: %v", err)
+	} else if n > 0 {
+		log.Printf("🛡️ Protected %d account(s) from PROTECTED_ADMIN_SUBJECTS.", n)
 	}
-	userHandler := &users.Handler{Repo: userRepo}
+	userHandler := &users.Handler{Repo: userRepo, RoleMap: rolemap.NewServiceFromEnv(db), ProtectedSubjects: protectedSubjects}
 	regHandler := &registration.Handler{
 		Service:   regService,
 		UserRepo:  userRepo,
 		EventRepo: eventRepo,
+		Orgs:      orgRepo,
 	}
 
 	// 3. Auth Setup
@@ -69,6 +145,7 @@ func main() {
 		auth0Audience = "http://localhost:8080"
 	}
 	authMiddleware := auth.EnsureValidToken(auth0Domain, auth0Audience)
+	optionalAuth := auth.OptionalToken(auth0Domain, auth0Audience)
 	rateLimiter := middleware.NewRateLimiter(2 * time.Second)
 
 	// 4. Router Setup
@@ -84,7 +161,14 @@ func main() {
 	// Note: GET /api/events is public in your design
 	mux.HandleFunc("GET /api/events", eventHandler.HandleListEvents)
 	mux.HandleFunc("/api/events/checkin/self", eventHandler.HandleSelfCheckIn)
-	mux.HandleFunc("POST /api/ai/chat", eventHandler.HandleChat)
+
+	// CampusBot works anonymously; a token adds the caller's private events.
+	chatService := chat.NewService(db, aiService, regService)
+	chatHandler := &chat.Handler{Service: chatService, UserRepo: userRepo}
+	mux.Handle("POST /api/ai/chat", optionalAuth(http.HandlerFunc(chatHandler.HandleChat)))
+	mux.Handle("POST /api/ai/chat/confirm", optionalAuth(http.HandlerFunc(chatHandler.HandleConfirm)))
+	mux.Handle("GET /api/ai/chat/history", optionalAuth(http.HandlerFunc(chatHandler.HandleHistory)))
+
 	mux.HandleFunc("GET /api/events/comments", eventHandler.HandleGetComments)
 	mux.HandleFunc("GET /api/events/photos", eventHandler.HandleGetPhotos)
 
@@ -96,22 +180,41 @@ func main() {
 	apiMux.HandleFunc("GET /admin/users", userHandler.HandleListUsers)
 	apiMux.HandleFunc("PATCH /admin/users/role", userHandler.HandleUpdateRole)
 	apiMux.HandleFunc("PATCH /admin/users/active", userHandler.HandleToggleActive)
+	apiMux.HandleFunc("GET /admin/users/sync-log", userHandler.HandleSyncLog)
 	apiMux.HandleFunc("GET /leaderboard", userHandler.HandleGetLeaderboard)
 	apiMux.HandleFunc("GET /users/badges", userHandler.HandleGetMyBadges)
+	apiMux.HandleFunc("PATCH /users/settings", userHandler.HandleUpdateSettings)
 
 	// Events (Management)
 	apiMux.HandleFunc("POST /events", eventHandler.HandleCreateEvent)
+	apiMux.HandleFunc("POST /events/draft", eventHandler.HandleDraftEvent)
+	apiMux.HandleFunc("POST /events/duplicates/check", eventHandler.HandleCheckDuplicates)
+	apiMux.HandleFunc("GET /events/links", eventHandler.HandleListLinks)
+	apiMux.HandleFunc("POST /events/links", eventHandler.HandleLinkEvents)
+	apiMux.HandleFunc("POST /events/merge", eventHandler.HandleMergeEvents)
 	apiMux.HandleFunc("PUT /events", eventHandler.HandleUpdateEvent)
+	apiMux.HandleFunc("POST /events/cancel", eventHandler.HandleCancelEvent)
 	apiMux.HandleFunc("POST /events/invite", eventHandler.HandleInviteUser)
 	apiMux.HandleFunc("POST /events/invite/bulk", eventHandler.HandleBulkInvite)
 	apiMux.HandleFunc("GET /events/attendees", eventHandler.HandleListAttendees)
 	apiMux.HandleFunc("GET /events/export", eventHandler.HandleExportAttendees)
 	apiMux.HandleFunc("POST /events/feedback", eventHandler.HandleAddFeedback)
+	feedbackHandler := &feedback.Handler{Service: feedback.NewService(db, aiService), UserRepo: userRepo, EventRepo: eventRepo, Orgs: orgRepo}
+	apiMux.HandleFunc("GET /events/feedback/report", feedbackHandler.HandleReport)
 	apiMux.HandleFunc("GET /admin/analytics", eventHandler.HandleGetAnalytics)
 	apiMux.HandleFunc("GET /events/certificate", eventHandler.HandleDownloadCertificate)
 	apiMux.HandleFunc("POST /events/comments", eventHandler.HandleAddComment)
 	apiMux.HandleFunc("POST /events/photos", eventHandler.HandleAddPhoto)
 
+	// Moderation
+	modHandler := &moderation.Handler{Service: moderationService, UserRepo: userRepo}
+	apiMux.HandleFunc("POST /moderation/reports", modHandler.HandleReport)
+	apiMux.HandleFunc("GET /moderation/queue", modHandler.HandleQueue)
+	apiMux.HandleFunc("POST /moderation/actions", modHandler.HandleAction)
+	apiMux.HandleFunc("POST /moderation/bans", modHandler.HandleBan)
+	apiMux.HandleFunc("DELETE /moderation/bans", modHandler.HandleUnban)
+	apiMux.HandleFunc("GET /moderation/audit", modHandler.HandleAudit)
+
 	// Check-In Logic
 	// Organizer/Admin Scan QR:
 	apiMux.HandleFunc("POST /events/checkin", eventHandler.HandleCheckIn)
@@ -121,11 +224,78 @@ func main() {
 	apiMux.Handle("POST /registrations", rateLimiter.LimitMiddleware(http.HandlerFunc(regHandler.HandleRegister)))
 	apiMux.HandleFunc("DELETE /registrations", regHandler.HandleCancel)
 	apiMux.HandleFunc("GET /registrations/me", regHandler.HandleListMyRegistrations)
+	apiMux.HandleFunc("GET /events/applications", regHandler.HandleListApplications)
+	apiMux.HandleFunc("POST /events/applications/decide", regHandler.HandleDecideApplications)
 
 	// Notifications
-	noteHandler := &notifications.Handler{Repo: eventRepo, UserRepo: userRepo}
+	noteHandler := &notifications.Handler{Repo: eventRepo, UserRepo: userRepo, Service: notifyService, Stream: stream}
 	apiMux.HandleFunc("GET /notifications", noteHandler.HandleListNotifications)
+	apiMux.HandleFunc("PATCH /notifications", noteHandler.HandleUpdateNotification)
+	apiMux.HandleFunc("DELETE /notifications", noteHandler.HandleDeleteNotification)
+	apiMux.HandleFunc("GET /notifications/unread-count", noteHandler.HandleUnreadCount)
 	apiMux.HandleFunc("POST /notifications/read", noteHandler.HandleMarkRead)
+	apiMux.HandleFunc("GET /notifications/stream", noteHandler.HandleStream)
+	apiMux.HandleFunc("GET /notifications/preferences", noteHandler.HandleGetPreferences)
+	apiMux.HandleFunc("PUT /notifications/preferences", noteHandler.HandleSavePreferences)
+	// Unsubscribe links are opened from mail clients, so they are public;
+	// the signed token authenticates the request.
+	mux.HandleFunc("GET /api/unsubscribe", noteHandler.HandleUnsubscribe)
+	mux.HandleFunc("POST /api/unsubscribe", noteHandler.HandleUnsubscribe)
+	apiMux.HandleFunc("GET /admin/outbox", noteHandler.HandleListOutbox)
+	apiMux.HandleFunc("POST /admin/outbox/retry", noteHandler.HandleRetryOutbox)
+	apiMux.HandleFunc("GET /admin/templates", noteHandler.HandleListTemplates)
+	apiMux.HandleFunc("PUT /admin/templates", noteHandler.HandleSaveTemplate)
+	apiMux.HandleFunc("DELETE /admin/templates", noteHandler.HandleResetTemplate)
+	apiMux.HandleFunc("POST /admin/templates/preview", noteHandler.HandlePreviewTemplate)
+
+	// Announcements
+	announcementHandler := &announcements.Handler{Service: announcementService, UserRepo: userRepo, EventRepo: eventRepo, Orgs: orgRepo}
+	apiMux.HandleFunc("POST /events/announcements", announcementHandler.HandleCreateAnnouncement)
+	apiMux.HandleFunc("GET /events/announcements", announcementHandler.HandleListAnnouncements)
+	apiMux.HandleFunc("DELETE /events/announcements", announcementHandler.HandleCancelAnnouncement)
+	apiMux.HandleFunc("GET /events/announcements/recipients", announcementHandler.HandleListRecipients)
+
+	// Recommendations
+	recHandler := &recommendations.Handler{Service: recommendations.NewService(db, aiService), UserRepo: userRepo}
+	apiMux.HandleFunc("GET /recommendations", recHandler.HandleRecommendations)
+
+	// Webhooks
+	webhookHandler := &webhooks.Handler{Service: webhookService, UserRepo: userRepo}
+	apiMux.HandleFunc("GET /webhooks", webhookHandler.HandleListWebhooks)
+	apiMux.HandleFunc("POST /webhooks", webhookHandler.HandleCreateWebhook)
+	apiMux.HandleFunc("DELETE /webhooks", webhookHandler.HandleDeleteWebhook)
+	apiMux.HandleFunc("GET /webhooks/deliveries", webhookHandler.HandleListDeliveries)
+	apiMux.HandleFunc("POST /webhooks/redeliver", webhookHandler.HandleRedeliver)
+	apiMux.HandleFunc("POST /webhooks/ping", webhookHandler.HandlePing)
+
+	// Organizations
+	orgHandler := &organizations.Handler{Repo: orgRepo, UserRepo: userRepo}
+	apiMux.HandleFunc("GET /organizations", orgHandler.HandleListOrganizations)
+	apiMux.HandleFunc("POST /organizations", orgHandler.HandleCreateOrganization)
+	apiMux.HandleFunc("GET /organizations/members", orgHandler.HandleListMembers)
+	apiMux.HandleFunc("PUT /organizations/members", orgHandler.HandleSetMember)
+	apiMux.HandleFunc("DELETE /organizations/members", orgHandler.HandleRemoveMember)
+	apiMux.HandleFunc("POST /organizations/transfer", orgHandler.HandleTransferOwnership)
+	apiMux.HandleFunc("GET /organizations/events", orgHandler.HandleListEvents)
+	apiMux.HandleFunc("GET /organizations/analytics", orgHandler.HandleAnalytics)
+	apiMux.HandleFunc("GET /organizations/export", orgHandler.HandleExport)
+
+	// Follows
+	followHandler := &follows.Handler{Repo: store.NewFollowRepository(db, authz.RolesWith(authz.EventCreate)), UserRepo: userRepo}
+	apiMux.HandleFunc("GET /follows", followHandler.HandleListFollowing)
+	apiMux.HandleFunc("POST /follows", followHandler.HandleFollow)
+	apiMux.HandleFunc("DELETE /follows", followHandler.HandleUnfollow)
+	apiMux.HandleFunc("GET /follows/feed", followHandler.HandleFeed)
+	apiMux.HandleFunc("GET /organizers", followHandler.HandleOrganizerProfile)
+
+	// Friends
+	friendHandler := &friends.Handler{Repo: store.NewFriendRepository(db), UserRepo: userRepo}
+	apiMux.HandleFunc("GET /friends", friendHandler.HandleListFriends)
+	apiMux.HandleFunc("DELETE /friends", friendHandler.HandleRemove)
+	apiMux.HandleFunc("POST /friends/requests", friendHandler.HandleRequest)
+	apiMux.HandleFunc("POST /friends/requests/respond", friendHandler.HandleRespond)
+	apiMux.HandleFunc("GET /events/friends", friendHandler.HandleFriendsGoing)
+	apiMux.HandleFunc("PATCH /registrations/visibility", friendHandler.HandleShowAttendance)
 
 	// Analytics (Advanced)
 	apiMux.Handle("GET /analytics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -145,7 +315,8 @@ func main() {
 		}
 	}))
 
-	mux.Handle("/api/", http.StripPrefix("/api", authMiddleware(apiMux)))
+	// Every protected route needs an entry in authz.Policy.
+	mux.Handle("/api/", http.StripPrefix("/api", authMiddleware(authz.Guard(apiMux, userRepo, authz.Policy))))
 
 	srv := &http.Server{
 		Addr:         ":8080",
@@ -0,0 +1,8 @@
+ALTER TYPE registration_status ADD VALUE IF NOT EXISTS 'PENDING';
+ALTER TYPE registration_status ADD VALUE IF NOT EXISTS 'REJECTED';
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS requires_approval BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS decided_by INT REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS decided_at TIMESTAMP(0) WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_registrations_event_status ON registrations (event_id, status);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS email_outbox
+(
+    id              BIGSERIAL PRIMARY KEY,
+    to_email        VARCHAR(255)                NOT NULL,
+    subject         TEXT                        NOT NULL,
+    body            TEXT                        NOT NULL,
+    status          VARCHAR(20)                 NOT NULL DEFAULT 'PENDING', -- PENDING, SENT, DEAD
+    attempts        INT                         NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    sent_at         TIMESTAMP(0) WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox (status, next_attempt_at);
@@ -0,0 +1,17 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT 'en';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE email_outbox ADD COLUMN IF NOT EXISTS html_body TEXT;
+
+-- Admin overrides of the built-in templates in internal/notifications.
+CREATE TABLE IF NOT EXISTS notification_templates
+(
+    name       VARCHAR(100)                NOT NULL,
+    locale     VARCHAR(10)                 NOT NULL,
+    subject    TEXT                        NOT NULL,
+    text_body  TEXT                        NOT NULL,
+    html_body  TEXT                        NOT NULL DEFAULT '',
+    updated_by INT REFERENCES users (id) ON DELETE SET NULL,
+    updated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (name, locale)
+);
@@ -0,0 +1,12 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS reminder_note TEXT NOT NULL DEFAULT '';
+
+-- One row per reminder delivered. The primary key is what makes reminders
+-- exactly-once across restarts and multiple API instances.
+CREATE TABLE IF NOT EXISTS event_reminders_sent
+(
+    event_id       INT                         NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    user_id        INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    offset_minutes INT                         NOT NULL,
+    sent_at        TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, user_id, offset_minutes)
+);
@@ -0,0 +1,33 @@
+-- Missing rows mean "use the defaults" defined in internal/notifications.
+CREATE TABLE IF NOT EXISTS notification_preferences
+(
+    user_id  INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    category VARCHAR(30) NOT NULL,
+    in_app   BOOLEAN     NOT NULL DEFAULT TRUE,
+    email    VARCHAR(10) NOT NULL DEFAULT 'instant', -- instant, daily, weekly, off
+    PRIMARY KEY (user_id, category)
+);
+
+CREATE TABLE IF NOT EXISTS digest_items
+(
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    category    VARCHAR(30)                 NOT NULL,
+    frequency   VARCHAR(10)                 NOT NULL, -- daily, weekly
+    subject     TEXT                        NOT NULL,
+    body        TEXT                        NOT NULL,
+    created_at  TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    digested_at TIMESTAMP(0) WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_digest_items_pending ON digest_items (frequency, user_id) WHERE digested_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS digest_runs
+(
+    user_id      INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    frequency    VARCHAR(10)                 NOT NULL,
+    last_sent_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, frequency)
+);
+
+ALTER TABLE email_outbox ADD COLUMN IF NOT EXISTS unsubscribe_url TEXT;
@@ -0,0 +1,86 @@
+-- Per-user event log behind GET /notifications/stream. Triggers append a row
+-- and pg_notify its id, so every API instance hears about changes made by
+-- any other, and clients reconnecting with Last-Event-ID can catch up.
+CREATE TABLE IF NOT EXISTS stream_events
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type       VARCHAR(30)                 NOT NULL, -- notification, registration
+    payload    JSONB                       NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stream_events_user ON stream_events (user_id, id);
+
+CREATE OR REPLACE FUNCTION stream_publish(p_user_id INT, p_type TEXT, p_payload JSONB) RETURNS VOID AS
+$$
+DECLARE
+    new_id BIGINT;
+BEGIN
+    -- Rows removed by ON DELETE CASCADE from users have no one to tell.
+    IF NOT EXISTS (SELECT 1 FROM users WHERE id = p_user_id) THEN
+        RETURN;
+    END IF;
+    INSERT INTO stream_events (user_id, type, payload) VALUES (p_user_id, p_type, p_payload) RETURNING id INTO new_id;
+    PERFORM pg_notify('stream_events', json_build_object('id', new_id, 'user_id', p_user_id)::text);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION stream_notification() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM stream_publish(NEW.user_id, 'notification', jsonb_build_object(
+            'id', NEW.id, 'user_id', NEW.user_id, 'message', NEW.message,
+            'is_read', COALESCE(NEW.is_read, FALSE), 'created_at', NEW.created_at));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION stream_registration() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM stream_publish(OLD.user_id, 'registration',
+                               jsonb_build_object('event_id', OLD.event_id, 'status', 'REMOVED'));
+        RETURN OLD;
+    END IF;
+    PERFORM stream_publish(NEW.user_id, 'registration',
+                           jsonb_build_object('event_id', NEW.event_id, 'status', NEW.status::text));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION stream_waitlist() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM stream_publish(OLD.user_id, 'registration',
+                               jsonb_build_object('event_id', OLD.event_id, 'status', 'REMOVED'));
+        RETURN OLD;
+    END IF;
+    PERFORM stream_publish(NEW.user_id, 'registration',
+                           jsonb_build_object('event_id', NEW.event_id, 'status', 'WAITLISTED'));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_stream_notification ON notifications;
+CREATE TRIGGER trg_stream_notification
+    AFTER INSERT
+    ON notifications
+    FOR EACH ROW
+EXECUTE FUNCTION stream_notification();
+
+DROP TRIGGER IF EXISTS trg_stream_registration ON registrations;
+CREATE TRIGGER trg_stream_registration
+    AFTER INSERT OR DELETE OR UPDATE OF status
+    ON registrations
+    FOR EACH ROW
+EXECUTE FUNCTION stream_registration();
+
+DROP TRIGGER IF EXISTS trg_stream_waitlist ON waitlist;
+CREATE TRIGGER trg_stream_waitlist
+    AFTER INSERT OR DELETE
+    ON waitlist
+    FOR EACH ROW
+EXECUTE FUNCTION stream_waitlist();
@@ -0,0 +1,24 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS category VARCHAR(30) NOT NULL DEFAULT 'general';
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS event_id INT REFERENCES events (id) ON DELETE SET NULL;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS action VARCHAR(30);
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP(0) WITH TIME ZONE;
+
+-- Inbox listing pages by id within a user; the partial index serves unread counts.
+CREATE INDEX IF NOT EXISTS idx_notif_user_id ON notifications (user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_notif_unread ON notifications (user_id) WHERE is_read = FALSE AND archived_at IS NULL;
+
+-- Stream payloads carry the same fields as GET /notifications.
+CREATE OR REPLACE FUNCTION stream_notification() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM stream_publish(NEW.user_id, 'notification', jsonb_build_object(
+            'id', NEW.id, 'user_id', NEW.user_id, 'message', NEW.message,
+            'is_read', COALESCE(NEW.is_read, FALSE), 'created_at', NEW.created_at,
+            'category', NEW.category,
+            'link', CASE
+                        WHEN NEW.event_id IS NULL AND NEW.action IS NULL THEN NULL
+                        ELSE jsonb_build_object('event_id', NEW.event_id, 'action', NEW.action) END,
+            'archived_at', NEW.archived_at));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
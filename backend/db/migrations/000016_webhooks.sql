@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id          BIGSERIAL PRIMARY KEY,
+    owner_id    INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    url         TEXT                        NOT NULL,
+    secret      TEXT                        NOT NULL,
+    event_types TEXT[]                      NOT NULL,
+    active      BOOLEAN                     NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id               BIGSERIAL PRIMARY KEY,
+    webhook_id       BIGINT                      NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_type       VARCHAR(50)                 NOT NULL,
+    payload          JSONB                       NOT NULL,
+    status           VARCHAR(10)                 NOT NULL DEFAULT 'PENDING', -- PENDING, DELIVERED, DEAD
+    attempts         INT                         NOT NULL DEFAULT 0,
+    last_status_code INT,
+    last_error       TEXT,
+    redelivery_of    BIGINT REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+    next_attempt_at  TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at       TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at     TIMESTAMP(0) WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id DESC);
@@ -0,0 +1,34 @@
+-- Check-in marks registrations ATTENDED; announcements can target them.
+ALTER TYPE registration_status ADD VALUE IF NOT EXISTS 'ATTENDED';
+
+CREATE TABLE IF NOT EXISTS announcements
+(
+    id         BIGSERIAL PRIMARY KEY,
+    event_id   INT                         NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    author_id  INT                         REFERENCES users (id) ON DELETE SET NULL,
+    subject    VARCHAR(200)                NOT NULL,
+    body       TEXT                        NOT NULL,
+    audiences  TEXT[]                      NOT NULL,
+    status     VARCHAR(10)                 NOT NULL DEFAULT 'SCHEDULED', -- SCHEDULED, SENT, CANCELLED
+    send_at    TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    sent_at    TIMESTAMP(0) WITH TIME ZONE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcements_event ON announcements (event_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_announcements_due ON announcements (send_at) WHERE status = 'SCHEDULED';
+
+-- One row per person an announcement was resolved to. The notification and
+-- outbox ids point at what was actually written, so delivery and read
+-- status are read live from those tables.
+CREATE TABLE IF NOT EXISTS announcement_recipients
+(
+    announcement_id BIGINT       NOT NULL REFERENCES announcements (id) ON DELETE CASCADE,
+    email           VARCHAR(255) NOT NULL,
+    user_id         INT REFERENCES users (id) ON DELETE SET NULL,
+    audience        VARCHAR(20)  NOT NULL,
+    notification_id BIGINT REFERENCES notifications (id) ON DELETE SET NULL,
+    outbox_id       BIGINT REFERENCES email_outbox (id) ON DELETE SET NULL,
+    digested        BOOLEAN      NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (announcement_id, email)
+);
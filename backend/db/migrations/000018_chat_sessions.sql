@@ -0,0 +1,22 @@
+-- Session ids are random tokens, so anonymous visitors can keep a
+-- conversation without an account.
+CREATE TABLE IF NOT EXISTS chat_sessions
+(
+    id             VARCHAR(64) PRIMARY KEY,
+    user_id        INT REFERENCES users (id) ON DELETE CASCADE,
+    pending_action JSONB,
+    created_at     TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS chat_messages
+(
+    id         BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(64)                 NOT NULL REFERENCES chat_sessions (id) ON DELETE CASCADE,
+    role       VARCHAR(10)                 NOT NULL, -- user, assistant
+    content    TEXT                        NOT NULL,
+    citations  BIGINT[]                    NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_messages_session ON chat_messages (session_id, id);
@@ -0,0 +1,10 @@
+-- Daily AI usage per caller. subject is "user:<id>" for signed-in users and
+-- "ip:<address>" for anonymous ones.
+CREATE TABLE IF NOT EXISTS ai_usage
+(
+    subject  VARCHAR(80) NOT NULL,
+    day      DATE        NOT NULL,
+    requests INT         NOT NULL DEFAULT 0,
+    tokens   INT         NOT NULL DEFAULT 0,
+    PRIMARY KEY (subject, day)
+);
@@ -0,0 +1,9 @@
+-- AI summaries are cached per event. source_hash fingerprints the feedback
+-- the summary was built from, so new or edited feedback invalidates it.
+CREATE TABLE IF NOT EXISTS feedback_summaries
+(
+    event_id     INT PRIMARY KEY REFERENCES events (id) ON DELETE CASCADE,
+    source_hash  VARCHAR(32)                 NOT NULL,
+    summary      JSONB                       NOT NULL,
+    generated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,14 @@
+-- Sentiment is scored in the background. Feedback that already exists is
+-- left DONE (with an unknown source) until it is requeued by the backfill
+-- command; new feedback starts PENDING.
+ALTER TABLE event_feedback
+    ADD COLUMN IF NOT EXISTS sentiment_status          VARCHAR(10)                 NOT NULL DEFAULT 'DONE', -- PENDING, DONE
+    ADD COLUMN IF NOT EXISTS sentiment_source          VARCHAR(10),                                        -- ai, lexicon
+    ADD COLUMN IF NOT EXISTS sentiment_attempts        INT                         NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS sentiment_error           TEXT,
+    ADD COLUMN IF NOT EXISTS sentiment_next_attempt_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+ALTER TABLE event_feedback ALTER COLUMN sentiment_status SET DEFAULT 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_event_feedback_sentiment_pending
+    ON event_feedback (sentiment_next_attempt_at) WHERE sentiment_status = 'PENDING';
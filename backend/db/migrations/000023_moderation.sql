@@ -0,0 +1,78 @@
+-- comments and event_photos used to be created only by the server at
+-- startup; create them here too so the moderation columns can be added.
+CREATE TABLE IF NOT EXISTS comments
+(
+    id         SERIAL PRIMARY KEY,
+    event_id   INT  NOT NULL,
+    user_id    INT  NOT NULL,
+    text       TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS event_photos
+(
+    id          SERIAL PRIMARY KEY,
+    event_id    INT  NOT NULL,
+    url         TEXT NOT NULL,
+    uploaded_by INT  NOT NULL,
+    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every piece of user content carries a moderation status:
+-- PUBLISHED, PENDING (held for review) or HIDDEN. Rejected content is never
+-- stored; the rejection is only recorded in moderation_actions.
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS moderation_status VARCHAR(10) NOT NULL DEFAULT 'PUBLISHED',
+    ADD COLUMN IF NOT EXISTS moderation_reason TEXT;
+ALTER TABLE event_photos
+    ADD COLUMN IF NOT EXISTS moderation_status VARCHAR(10) NOT NULL DEFAULT 'PUBLISHED',
+    ADD COLUMN IF NOT EXISTS moderation_reason TEXT;
+ALTER TABLE event_feedback
+    ADD COLUMN IF NOT EXISTS moderation_status VARCHAR(10) NOT NULL DEFAULT 'PUBLISHED',
+    ADD COLUMN IF NOT EXISTS moderation_reason TEXT;
+
+-- One open report per user and piece of content.
+CREATE TABLE IF NOT EXISTS content_reports
+(
+    id           BIGSERIAL PRIMARY KEY,
+    content_type VARCHAR(10)                 NOT NULL, -- comment, photo, feedback
+    content_id   BIGINT                      NOT NULL,
+    reporter_id  INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason       TEXT                        NOT NULL DEFAULT '',
+    created_at   TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at  TIMESTAMP(0) WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_content_reports_open
+    ON content_reports (content_type, content_id, reporter_id) WHERE resolved_at IS NULL;
+
+-- A ban with no event_id stops the user posting anywhere; otherwise only on
+-- that event.
+CREATE TABLE IF NOT EXISTS moderation_bans
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    event_id   INT REFERENCES events (id) ON DELETE CASCADE,
+    reason     TEXT                        NOT NULL DEFAULT '',
+    created_by INT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_moderation_bans_scope
+    ON moderation_bans (user_id, COALESCE(event_id, 0));
+
+-- Audit trail. actor_id is NULL for automatic decisions.
+CREATE TABLE IF NOT EXISTS moderation_actions
+(
+    id           BIGSERIAL PRIMARY KEY,
+    actor_id     INT REFERENCES users (id) ON DELETE SET NULL,
+    action       VARCHAR(20)                 NOT NULL, -- held, rejected, reported, hide, restore, ban, unban
+    content_type VARCHAR(10),
+    content_id   BIGINT,
+    user_id      INT REFERENCES users (id) ON DELETE SET NULL,
+    event_id     INT REFERENCES events (id) ON DELETE SET NULL,
+    reason       TEXT                        NOT NULL DEFAULT '',
+    created_at   TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_event ON moderation_actions (event_id, created_at DESC);
@@ -0,0 +1,12 @@
+-- One embedding per event for semantic search. Vectors are plain REAL[]
+-- compared in the application, so the pgvector extension is not needed at
+-- campus scale. model names the embedder; source_hash fingerprints the
+-- event text, so edits and model changes are re-indexed.
+CREATE TABLE IF NOT EXISTS event_embeddings
+(
+    event_id    INT PRIMARY KEY REFERENCES events (id) ON DELETE CASCADE,
+    model       VARCHAR(100)                NOT NULL,
+    source_hash VARCHAR(32)                 NOT NULL,
+    embedding   REAL[]                      NOT NULL,
+    updated_at  TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
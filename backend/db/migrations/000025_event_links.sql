@@ -0,0 +1,14 @@
+-- Links between events that are the same thing posted twice. A DUPLICATE
+-- link is stored once, with the smaller id in event_id; a MERGED link
+-- points from the cancelled event to the one its attendees moved to.
+CREATE TABLE IF NOT EXISTS event_links
+(
+    event_id        INT                         NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    linked_event_id INT                         NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    kind            VARCHAR(10)                 NOT NULL,
+    created_by      INT                         REFERENCES users (id) ON DELETE SET NULL,
+    created_at      TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, linked_event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_links_linked ON event_links (linked_event_id);
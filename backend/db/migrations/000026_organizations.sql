@@ -0,0 +1,39 @@
+-- Clubs and departments own events. Members-only events are visible to
+-- the organization's members.
+ALTER TYPE event_visibility ADD VALUE IF NOT EXISTS 'MEMBERS';
+
+CREATE TABLE IF NOT EXISTS organizations
+(
+    id          SERIAL PRIMARY KEY,
+    name        VARCHAR(120)                NOT NULL UNIQUE,
+    description TEXT                        NOT NULL DEFAULT '',
+    created_at  TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- role is OWNER, OFFICER or MEMBER; each organization has one OWNER.
+CREATE TABLE IF NOT EXISTS organization_members
+(
+    organization_id INT                         NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id         INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role            VARCHAR(10)                 NOT NULL DEFAULT 'MEMBER' CHECK (role IN ('OWNER', 'OFFICER', 'MEMBER')),
+    joined_at       TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organization_members_owner ON organization_members (organization_id) WHERE role = 'OWNER';
+CREATE INDEX IF NOT EXISTS idx_organization_members_user ON organization_members (user_id);
+
+-- Ownership transfers, kept so a handover can be traced later.
+CREATE TABLE IF NOT EXISTS organization_transfers
+(
+    id              BIGSERIAL PRIMARY KEY,
+    organization_id INT                         NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    from_user_id    INT                         REFERENCES users (id) ON DELETE SET NULL,
+    to_user_id      INT                         REFERENCES users (id) ON DELETE SET NULL,
+    transferred_by  INT                         REFERENCES users (id) ON DELETE SET NULL,
+    created_at      TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS organization_id INT REFERENCES organizations (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_events_organization ON events (organization_id);
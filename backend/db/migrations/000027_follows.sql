@@ -0,0 +1,22 @@
+-- Users following organizers and organizations, to hear about their new
+-- events.
+CREATE TABLE IF NOT EXISTS organizer_follows
+(
+    follower_id  INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    organizer_id INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at   TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (follower_id, organizer_id),
+    CHECK (follower_id <> organizer_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organizer_follows_organizer ON organizer_follows (organizer_id);
+
+CREATE TABLE IF NOT EXISTS organization_follows
+(
+    follower_id     INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    organization_id INT                         NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    created_at      TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (follower_id, organization_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_follows_organization ON organization_follows (organization_id);
@@ -0,0 +1,25 @@
+-- Friendships. A request is PENDING until the addressee accepts it; a pair
+-- of users has at most one row, whichever of them asked.
+CREATE TABLE IF NOT EXISTS friendships
+(
+    requester_id INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    addressee_id INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status       VARCHAR(10)                 NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'ACCEPTED')),
+    created_at   TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    accepted_at  TIMESTAMP(0) WITH TIME ZONE,
+    PRIMARY KEY (requester_id, addressee_id),
+    CHECK (requester_id <> addressee_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_friendships_pair
+    ON friendships (LEAST(requester_id, addressee_id), GREATEST(requester_id, addressee_id));
+CREATE INDEX IF NOT EXISTS idx_friendships_addressee ON friendships (addressee_id);
+
+-- Who may see a user's registrations: FRIENDS or nobody (HIDDEN).
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS attendance_visibility VARCHAR(10) NOT NULL DEFAULT 'FRIENDS'
+        CHECK (attendance_visibility IN ('FRIENDS', 'HIDDEN'));
+
+-- A per-event choice that overrides the user's setting and the default for
+-- sensitive categories; NULL follows them.
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS show_to_friends BOOLEAN;
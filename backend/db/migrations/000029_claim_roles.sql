@@ -0,0 +1,22 @@
+-- Roles come from two places: manual_role is assigned by an admin,
+-- claim_role is granted by identity-provider claims on each sync. role is
+-- the stronger of the two and is what permissions are checked against.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS manual_role VARCHAR(20);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS claim_role VARCHAR(20);
+UPDATE users SET manual_role = role WHERE manual_role IS NULL;
+
+-- Memberships granted by claims are removed again when the claim goes away;
+-- MANUAL ones are never touched by a sync.
+ALTER TABLE organization_members
+    ADD COLUMN IF NOT EXISTS source VARCHAR(10) NOT NULL DEFAULT 'MANUAL' CHECK (source IN ('MANUAL', 'CLAIM'));
+
+-- What each login changed.
+CREATE TABLE IF NOT EXISTS identity_sync_log
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INT                         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    changes    JSONB                       NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_identity_sync_log_user ON identity_sync_log (user_id, created_at DESC);
@@ -0,0 +1,4 @@
+-- Protected accounts cannot be demoted or deactivated through the API; only
+-- the break-glass command changes them. Accounts listed in
+-- PROTECTED_ADMIN_SUBJECTS are flagged when the API starts.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_protected BOOLEAN NOT NULL DEFAULT FALSE;
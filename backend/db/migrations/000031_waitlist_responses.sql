@@ -0,0 +1,3 @@
+-- Applicants moved to the waitlist keep their answers to the registration
+-- questions, and get them back when a seat frees up.
+ALTER TABLE waitlist ADD COLUMN IF NOT EXISTS form_responses TEXT NOT NULL DEFAULT '{}';
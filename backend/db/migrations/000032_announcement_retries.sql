@@ -0,0 +1,6 @@
+-- A scheduled announcement that fails to send is retried with a growing
+-- delay and marked FAILED after a few attempts, so it cannot hold up the
+-- announcements due after it.
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS last_error TEXT;
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW();
@@ -6,11 +6,11 @@ toolchain go1.24.10
 
 require (
 	github.com/auth0/go-jwt-middleware/v2 v2.3.1
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/lib/pq v1.10.9
 )
 
 require (
-	github.com/jung-kurt/gofpdf v1.16.2 // indirect
 	golang.org/x/crypto v0.45.0 // indirect
 	golang.org/x/sync v0.18.0 // indirect
 	gopkg.in/go-jose/go-jose.v2 v2.6.3 // indirect
@@ -0,0 +1,173 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Client adds the behavior every provider shares: a per-attempt timeout,
+// retries with exponential backoff on rate limits, server errors and
+// timeouts, cancellation through ctx, and running token totals.
+type Client struct {
+	Provider Provider
+	Timeout  time.Duration
+	// StreamTimeout bounds a whole streamed completion, which can take far
+	// longer than a single request.
+	StreamTimeout time.Duration
+	MaxRetries    int
+	BaseBackoff   time.Duration
+	MaxBackoff    time.Duration
+
+	mu    sync.Mutex
+	usage Usage
+	calls int
+}
+
+func NewClient(provider Provider) *Client {
+	return &Client{
+		Provider:      provider,
+		Timeout:       30 * time.Second,
+		StreamTimeout: 2 * time.Minute,
+		MaxRetries:    3,
+		BaseBackoff:   1 * time.Second,
+		MaxBackoff:    20 * time.Second,
+	}
+}
+
+// Complete runs req against the provider, retrying transient failures.
+// Usage from every attempt that returned a completion is counted.
+func (c *Client) Complete(ctx context.Context, req CompletionRequest) (*Completion, error) {
+	return c.run(ctx, func(ctx context.Context) (*Completion, bool, error) {
+		comp, err := c.attempt(ctx, c.Timeout, func(ctx context.Context) (*Completion, error) {
+			return c.Provider.Complete(ctx, req)
+		})
+		return comp, true, err
+	})
+}
+
+// Stream is Complete with content delivered through onDelta as it is
+// produced. Providers without streaming deliver it in one piece. Failures
+// are only retried until the first piece has been delivered, so the caller
+// never sees content twice.
+func (c *Client) Stream(ctx context.Context, req CompletionRequest, onDelta func(string)) (*Completion, error) {
+	sp, ok := c.providerStream()
+	if !ok {
+		comp, err := c.Complete(ctx, req)
+		if err == nil && comp.Content != "" {
+			onDelta(comp.Content)
+		}
+		return comp, err
+	}
+
+	started := false
+	return c.run(ctx, func(ctx context.Context) (*Completion, bool, error) {
+		comp, err := c.attempt(ctx, c.StreamTimeout, func(ctx context.Context) (*Completion, error) {
+			return sp.Stream(ctx, req, func(delta string) {
+				started = true
+				onDelta(delta)
+			})
+		})
+		return comp, !started, err
+	})
+}
+
+func (c *Client) providerStream() (StreamProvider, bool) {
+	if c == nil || c.Provider == nil {
+		return nil, false
+	}
+	sp, ok := c.Provider.(StreamProvider)
+	return sp, ok
+}
+
+// run calls once until it succeeds, fails permanently or reports that it
+// may not be retried, and records the usage of the successful call.
+func (c *Client) run(ctx context.Context, once func(ctx context.Context) (*Completion, bool, error)) (*Completion, error) {
+	if c == nil || c.Provider == nil {
+		return nil, ErrUnavailable
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			wait := c.backoff(attempt, lastErr)
+			log.Printf("AI %s attempt %d failed (%v); retrying in %v", c.Provider.Name(), attempt, lastErr, wait)
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		comp, mayRetry, err := once(ctx)
+		if err == nil {
+			c.record(comp.Usage)
+			return comp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !mayRetry || !retryable(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (c *Client) attempt(ctx context.Context, timeout time.Duration, call func(ctx context.Context) (*Completion, error)) (*Completion, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return call(ctx)
+}
+
+// backoff doubles from BaseBackoff, capped at MaxBackoff. A Retry-After
+// from the provider wins when it is longer.
+func (c *Client) backoff(attempt int, err error) time.Duration {
+	wait := c.BaseBackoff << (attempt - 1)
+	if c.MaxBackoff > 0 && (wait > c.MaxBackoff || wait <= 0) {
+		wait = c.MaxBackoff
+	}
+	var se *StatusError
+	if errors.As(err, &se) && se.RetryAfter > wait {
+		wait = se.RetryAfter
+		if c.MaxBackoff > 0 && wait > c.MaxBackoff {
+			wait = c.MaxBackoff
+		}
+	}
+	return wait
+}
+
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Retryable()
+	}
+	// Per-attempt timeouts are worth retrying; other transport errors
+	// (bad URL, refused connection) usually are not.
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+func (c *Client) record(u Usage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.usage.add(u)
+	c.calls++
+}
+
+// Usage returns the tokens used and completions made since start-up.
+func (c *Client) Usage() (Usage, int) {
+	if c == nil {
+		return Usage{}, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usage, c.calls
+}
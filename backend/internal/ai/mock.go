@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockProvider answers locally and deterministically, for tests and offline
+// development. By default the reply is derived from a hash of the prompt,
+// so the same request always gets the same answer.
+type MockProvider struct {
+	// Reply, if set, produces the content instead of the default.
+	Reply func(req CompletionRequest) string
+	// Failures are returned, in order, before any reply is produced.
+	Failures []error
+	// Delay simulates latency; it respects ctx cancellation.
+	Delay time.Duration
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (m *MockProvider) Name() string { return "mock" }
+
+func (m *MockProvider) Complete(ctx context.Context, req CompletionRequest) (*Completion, error) {
+	return m.complete(ctx, req, m.Delay)
+}
+
+func (m *MockProvider) complete(ctx context.Context, req CompletionRequest, delay time.Duration) (*Completion, error) {
+	m.mu.Lock()
+	m.calls++
+	var fail error
+	if len(m.Failures) > 0 {
+		fail, m.Failures = m.Failures[0], m.Failures[1:]
+	}
+	m.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if fail != nil {
+		return nil, fail
+	}
+
+	var prompt strings.Builder
+	for _, msg := range req.Messages {
+		prompt.WriteString(msg.Content)
+	}
+	content := ""
+	if m.Reply != nil {
+		content = m.Reply(req)
+	} else {
+		h := fnv.New32a()
+		h.Write([]byte(prompt.String()))
+		content = fmt.Sprintf("Mock response %08x", h.Sum32())
+	}
+
+	in, out := countTokens(prompt.String()), countTokens(content)
+	return &Completion{
+		Content: content,
+		Usage:   Usage{PromptTokens: in, CompletionTokens: out, TotalTokens: in + out},
+	}, nil
+}
+
+// Stream delivers the same content as Complete, one word at a time, with
+// Delay between words.
+func (m *MockProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(string)) (*Completion, error) {
+	comp, err := m.complete(ctx, req, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, word := range strings.SplitAfter(comp.Content, " ") {
+		if i > 0 && m.Delay > 0 {
+			timer := time.NewTimer(m.Delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		onDelta(word)
+	}
+	return comp, nil
+}
+
+// Calls returns how many completions were attempted.
+func (m *MockProvider) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+// countTokens approximates tokens as whitespace-separated words.
+func countTokens(s string) int {
+	return len(strings.Fields(s))
+}
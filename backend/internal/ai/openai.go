@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider talks to any OpenAI-compatible chat completions endpoint
+// (OpenAI, Groq, Ollama, vLLM, ...).
+type OpenAIProvider struct {
+	BaseURL string
+	Model   string
+	APIKey  string
+	HTTP    *http.Client
+}
+
+type openAIRequest struct {
+	Model         string         `json:"model"`
+	Messages      []Message      `json:"messages"`
+	MaxTokens     int            `json:"max_tokens,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Usage Usage `json:"usage"`
+}
+
+// openAIChunk is one server-sent event of a streamed completion. The last
+// chunk carries usage when stream_options.include_usage is set.
+type openAIChunk struct {
+	Choices []struct {
+		Delta Message `json:"delta"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage"`
+}
+
+func (p *OpenAIProvider) Name() string { return "openai:" + p.Model }
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (*Completion, error) {
+	resp, err := p.post(ctx, openAIRequest{Model: p.Model, Messages: req.Messages, MaxTokens: req.MaxTokens})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	comp := &Completion{Usage: out.Usage}
+	if len(out.Choices) > 0 {
+		comp.Content = out.Choices[0].Message.Content
+	}
+	return comp, nil
+}
+
+// Stream reads the "data: {...}" events of a streamed completion until
+// "data: [DONE]". Cancelling ctx closes the upstream connection.
+func (p *OpenAIProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(string)) (*Completion, error) {
+	resp, err := p.post(ctx, openAIRequest{
+		Model:         p.Model,
+		Messages:      req.Messages,
+		MaxTokens:     req.MaxTokens,
+		Stream:        true,
+		StreamOptions: &streamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var usage *Usage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk openAIChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, err
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			content.WriteString(chunk.Choices[0].Delta.Content)
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	comp := &Completion{Content: content.String()}
+	if usage != nil {
+		comp.Usage = *usage
+	} else {
+		// Some compatible servers ignore include_usage; estimate instead.
+		in := 0
+		for _, m := range req.Messages {
+			in += countTokens(m.Content)
+		}
+		out := countTokens(comp.Content)
+		comp.Usage = Usage{PromptTokens: in, CompletionTokens: out, TotalTokens: in + out}
+	}
+	return comp, nil
+}
+
+// post sends a chat completions request and returns the response when it
+// succeeded; the caller closes the body. Other statuses become a
+// StatusError.
+func (p *OpenAIProvider) post(ctx context.Context, body openAIRequest) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(p.BaseURL, "/")+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	client := p.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		se := &StatusError{Code: resp.StatusCode, Body: string(msg)}
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			se.RetryAfter = time.Duration(secs) * time.Second
+		}
+		return nil, se
+	}
+	return resp, nil
+}
@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is one turn of a chat completion.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// CompletionRequest is what every provider accepts.
+type CompletionRequest struct {
+	Messages  []Message
+	MaxTokens int
+}
+
+// Usage counts tokens as reported by the provider.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u *Usage) add(o Usage) {
+	u.PromptTokens += o.PromptTokens
+	u.CompletionTokens += o.CompletionTokens
+	u.TotalTokens += o.TotalTokens
+}
+
+type Completion struct {
+	Content string
+	Usage   Usage
+}
+
+// Provider makes a single completion attempt. Timeouts, retries and usage
+// accounting are handled by Client, so implementations stay small.
+// Implementations must be safe for concurrent use.
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req CompletionRequest) (*Completion, error)
+}
+
+// StreamProvider is implemented by providers that can deliver a completion
+// incrementally. onDelta is called with each new piece of content, in
+// order; the returned Completion holds the full content and usage.
+type StreamProvider interface {
+	Provider
+	Stream(ctx context.Context, req CompletionRequest, onDelta func(string)) (*Completion, error)
+}
+
+// ErrUnavailable is returned when no provider is configured.
+var ErrUnavailable = errors.New("ai: no provider configured")
+
+// StatusError is an unsuccessful HTTP response from a provider.
+type StatusError struct {
+	Code       int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ai: provider returned status %d: %s", e.Code, e.Body)
+}
+
+// Retryable reports whether the request may succeed if tried again.
+func (e *StatusError) Retryable() bool {
+	return e.Code == 429 || e.Code >= 500
+}
+
+// NewProviderFromEnv picks a provider based on AI_PROVIDER: "openai" (any
+// OpenAI-compatible endpoint at AI_BASE_URL, the default) or "mock" (a
+// deterministic local stand-in). It returns nil when the openai provider
+// has no API key.
+func NewProviderFromEnv() Provider {
+	switch strings.ToLower(os.Getenv("AI_PROVIDER")) {
+	case "mock":
+		return &MockProvider{}
+	default:
+		key := os.Getenv("AI_API_KEY")
+		if key == "" {
+			if key = os.Getenv("GEMINI_API_KEY"); key != "" {
+				log.Println("⚠️ Warning: GEMINI_API_KEY is deprecated, use AI_API_KEY.")
+			}
+		}
+		if key == "" {
+			return nil
+		}
+		return &OpenAIProvider{
+			BaseURL: envOr("AI_BASE_URL", "https://api.groq.com/openai/v1"),
+			Model:   envOr("AI_MODEL", "llama-3.3-70b-versatile"),
+			APIKey:  key,
+		}
+	}
+}
+
+// NewClientFromEnv wraps provider with the timeout (AI_TIMEOUT, default 30s)
+// and retry count (AI_MAX_RETRIES, default 3) from the environment.
+func NewClientFromEnv(provider Provider) *Client {
+	c := NewClient(provider)
+	if v := os.Getenv("AI_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			c.Timeout = d
+		} else {
+			log.Printf("⚠️ Warning: invalid AI_TIMEOUT %q, using %v", v, c.Timeout)
+		}
+	}
+	if v := os.Getenv("AI_MAX_RETRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			c.MaxRetries = n
+		} else {
+			log.Printf("⚠️ Warning: invalid AI_MAX_RETRIES %q, using %d", v, c.MaxRetries)
+		}
+	}
+	return c
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
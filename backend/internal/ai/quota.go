@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+var ErrQuotaExceeded = errors.New("daily AI limit reached, try again tomorrow")
+
+// Quota caps how many AI requests and tokens one caller may use per UTC
+// day, so a single user cannot spend the whole API budget. A limit of 0
+// means unlimited.
+type Quota struct {
+	DB       *sql.DB
+	Requests int
+	Tokens   int
+}
+
+// NewQuotaFromEnv reads AI_DAILY_REQUESTS (default 50) and AI_DAILY_TOKENS
+// (default 100000).
+func NewQuotaFromEnv(db *sql.DB) *Quota {
+	return &Quota{
+		DB:       db,
+		Requests: envInt("AI_DAILY_REQUESTS", 50),
+		Tokens:   envInt("AI_DAILY_TOKENS", 100000),
+	}
+}
+
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Printf("⚠️ Warning: invalid %s %q, using %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+// UserSubject and IPSubject build quota subjects.
+func UserSubject(userID int64) string { return "user:" + strconv.FormatInt(userID, 10) }
+func IPSubject(ip string) string      { return "ip:" + ip }
+
+type subjectKey struct{}
+
+// WithSubject returns a context whose AI requests are charged to subject.
+// Every caller acting for a user sets it before calling the Service;
+// requests without a subject, like background jobs, are not charged.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+func subjectFrom(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
+
+// Reserve counts one request against subject's quota for today, or returns
+// ErrQuotaExceeded without counting it. The check and increment are one
+// statement, so concurrent requests cannot overshoot the request limit.
+func (q *Quota) Reserve(ctx context.Context, subject string) error {
+	if q == nil {
+		return nil
+	}
+	var n int
+	err := q.DB.QueryRowContext(ctx, `
+		INSERT INTO ai_usage (subject, day, requests) VALUES ($1, (NOW() AT TIME ZONE 'UTC')::date, 1)
+		ON CONFLICT (subject, day) DO UPDATE SET requests = ai_usage.requests + 1
+		WHERE ($2 = 0 OR ai_usage.requests < $2) AND ($3 = 0 OR ai_usage.tokens < $3)
+		RETURNING requests
+	`, subject, q.Requests, q.Tokens).Scan(&n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuotaExceeded
+	}
+	return err
+}
+
+// Record adds the tokens a reserved request used.
+func (q *Quota) Record(ctx context.Context, subject string, u Usage) error {
+	if q == nil || u.TotalTokens == 0 {
+		return nil
+	}
+	_, err := q.DB.ExecContext(ctx,
+		"UPDATE ai_usage SET tokens = tokens + $2 WHERE subject = $1 AND day = (NOW() AT TIME ZONE 'UTC')::date",
+		subject, u.TotalTokens)
+	return err
+}
+
+// ResetIn is how long until quotas reset, for Retry-After.
+func ResetIn(now time.Time) time.Duration {
+	now = now.UTC()
+	return now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+}
@@ -0,0 +1,159 @@
+package announcements
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+type Handler struct {
+	Service   *Service
+	UserRepo  *store.UserRepository
+	EventRepo *store.EventRepository
+	Orgs      *store.OrganizationRepository
+}
+
+// authorizeEvent checks that the current user may manage the event: its
+// organizer, an officer of its organization, or an Admin.
+func (h *Handler) authorizeEvent(w http.ResponseWriter, r *http.Request, eventID int64) (*store.User, bool) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	event, err := h.EventRepo.GetEventByID(r.Context(), eventID)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return nil, false
+	}
+	if !authz.CanManage(r.Context(), h.Orgs, user, event) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You can only manage announcements for events you created."})
+		return nil, false
+	}
+	return user, true
+}
+
+// authorizeAnnouncement loads an announcement and checks access to its event.
+func (h *Handler) authorizeAnnouncement(w http.ResponseWriter, r *http.Request) (*Announcement, bool) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return nil, false
+	}
+	a, err := h.Service.Get(r.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return nil, false
+	}
+	if _, ok := h.authorizeEvent(w, r, a.EventID); !ok {
+		return nil, false
+	}
+	return a, true
+}
+
+// HandleCreateAnnouncement sends an announcement now, or schedules it when
+// send_at is in the future.
+func (h *Handler) HandleCreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		EventID   int64      `json:"event_id"`
+		Subject   string     `json:"subject"`
+		Body      string     `json:"body"`
+		Audiences []string   `json:"audiences"`
+		SendAt    *time.Time `json:"send_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := h.authorizeEvent(w, r, req.EventID)
+	if !ok {
+		return
+	}
+
+	if err := validate(req.Subject, req.Body, req.Audiences); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
+
+	a, err := h.Service.Create(r.Context(), req.EventID, user.ID, req.Subject, req.Body, req.Audiences, req.SendAt)
+	if err != nil {
+		http.Error(w, "Failed to create announcement", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+func (h *Handler) HandleListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid event_id", http.StatusBadRequest)
+		return
+	}
+	if _, ok := h.authorizeEvent(w, r, eventID); !ok {
+		return
+	}
+
+	list, err := h.Service.List(r.Context(), eventID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *Handler) HandleListRecipients(w http.ResponseWriter, r *http.Request) {
+	a, ok := h.authorizeAnnouncement(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.Service.Recipients(r.Context(), a.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleCancelAnnouncement cancels an announcement that has not gone out yet.
+func (h *Handler) HandleCancelAnnouncement(w http.ResponseWriter, r *http.Request) {
+	a, ok := h.authorizeAnnouncement(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.Cancel(r.Context(), a.ID); errors.Is(err, ErrNotScheduled) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
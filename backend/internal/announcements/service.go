@@ -0,0 +1,437 @@
+package announcements
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/lib/pq"
+)
+
+// Audiences an announcement can target.
+const (
+	AudienceRegistered = "registered"
+	AudienceWaitlisted = "waitlisted"
+	AudienceInvited    = "invited"
+	AudienceAttended   = "attended"
+	AudienceNoShow     = "no_show"
+)
+
+var Audiences = []string{
+	AudienceRegistered,
+	AudienceWaitlisted,
+	AudienceInvited,
+	AudienceAttended,
+	AudienceNoShow,
+}
+
+// Announcement statuses.
+const (
+	StatusScheduled = "SCHEDULED"
+	StatusSent      = "SENT"
+	StatusCancelled = "CANCELLED"
+	// StatusFailed is an announcement the scheduler gave up on after
+	// maxSendAttempts.
+	StatusFailed = "FAILED"
+)
+
+// maxSendAttempts is how often the scheduler tries a due announcement.
+// Between tries it waits 1, 2, 4, ... minutes, at most an hour.
+const maxSendAttempts = 5
+
+var (
+	ErrNotFound     = errors.New("announcement not found")
+	ErrNotScheduled = errors.New("announcement is not scheduled")
+)
+
+// audienceQueries return (user_id, email, locale, timezone) for everyone in
+// an audience. Invitees without an account have a NULL user_id and only
+// get the email.
+var audienceQueries = map[string]string{
+	AudienceRegistered: `
+		SELECT u.id, u.email, u.locale, u.timezone FROM registrations r JOIN users u ON u.id = r.user_id
+		WHERE r.event_id = $1 AND r.status = 'REGISTERED'`,
+	AudienceWaitlisted: `
+		SELECT u.id, u.email, u.locale, u.timezone FROM waitlist w JOIN users u ON u.id = w.user_id
+		WHERE w.event_id = $1 ORDER BY w.created_at`,
+	AudienceInvited: `
+		SELECT u.id, i.email, COALESCE(u.locale, ''), COALESCE(u.timezone, '') FROM invitations i LEFT JOIN users u ON u.email = i.email
+		WHERE i.event_id = $1`,
+	AudienceAttended: `
+		SELECT u.id, u.email, u.locale, u.timezone FROM registrations r JOIN users u ON u.id = r.user_id
+		WHERE r.event_id = $1 AND r.status = 'ATTENDED'`,
+	AudienceNoShow: `
+		SELECT u.id, u.email, u.locale, u.timezone FROM registrations r
+		JOIN users u ON u.id = r.user_id
+		JOIN events e ON e.id = r.event_id
+		WHERE r.event_id = $1 AND r.status = 'REGISTERED' AND e.end_time < NOW()`,
+}
+
+type Announcement struct {
+	ID        int64      `json:"id"`
+	EventID   int64      `json:"event_id"`
+	AuthorID  *int64     `json:"author_id"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	Audiences []string   `json:"audiences"`
+	Status    string     `json:"status"`
+	SendAt    time.Time  `json:"send_at"`
+	SentAt    *time.Time `json:"sent_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	Stats     Stats      `json:"stats"`
+	// Attempts and LastError describe failed scheduled sends.
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Stats summarises delivery for a sent announcement. Email counts only
+// cover instant emails; recipients who chose a digest are counted in
+// Digested.
+type Stats struct {
+	Recipients  int `json:"recipients"`
+	InApp       int `json:"in_app"`
+	Read        int `json:"read"`
+	Emailed     int `json:"emailed"`
+	EmailSent   int `json:"email_sent"`
+	EmailFailed int `json:"email_failed"`
+	Digested    int `json:"digested"`
+}
+
+// Recipient is one person an announcement was sent to. EmailStatus is the
+// outbox status (PENDING, SENT, DEAD), DIGEST, or empty when no email was
+// sent.
+type Recipient struct {
+	UserID      *int64 `json:"user_id"`
+	Email       string `json:"email"`
+	Audience    string `json:"audience"`
+	InApp       bool   `json:"in_app"`
+	Read        bool   `json:"read"`
+	EmailStatus string `json:"email_status"`
+}
+
+type Service struct {
+	DB            *sql.DB
+	Notifications *notifications.Service
+}
+
+func NewService(db *sql.DB, notify *notifications.Service) *Service {
+	return &Service{DB: db, Notifications: notify}
+}
+
+func validate(subject, body string, audiences []string) error {
+	if strings.TrimSpace(subject) == "" || strings.TrimSpace(body) == "" {
+		return errors.New("subject and body are required")
+	}
+	if len(subject) > 200 {
+		return errors.New("subject must be at most 200 characters")
+	}
+	if len(audiences) == 0 {
+		return errors.New("at least one audience is required")
+	}
+	for _, a := range audiences {
+		if _, ok := audienceQueries[a]; !ok {
+			return errors.New("unknown audience: " + a)
+		}
+	}
+	return nil
+}
+
+// Create stores an announcement. Without sendAt (or with a time in the
+// past) it is sent straight away; otherwise the scheduler sends it when due.
+func (s *Service) Create(ctx context.Context, eventID, authorID int64, subject, body string, audiences []string, sendAt *time.Time) (*Announcement, error) {
+	if err := validate(subject, body, audiences); err != nil {
+		return nil, err
+	}
+	when := time.Now()
+	if sendAt != nil && sendAt.After(when) {
+		when = *sendAt
+	}
+
+	var id int64
+	err := s.DB.QueryRowContext(ctx, `
+		INSERT INTO announcements (event_id, author_id, subject, body, audiences, send_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id
+	`, eventID, authorID, strings.TrimSpace(subject), body, pq.Array(audiences), when).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sendAt == nil || !sendAt.After(time.Now()) {
+		if err := s.Send(ctx, id); err != nil && !errors.Is(err, ErrNotScheduled) {
+			return nil, err
+		}
+	}
+	return s.Get(ctx, id)
+}
+
+const selectAnnouncement = `
+	SELECT a.id, a.event_id, a.author_id, a.subject, a.body, a.audiences, a.status, a.send_at, a.sent_at, a.created_at,
+	       a.attempts, COALESCE(a.last_error, ''),
+	       COUNT(ar.email),
+	       COUNT(ar.notification_id),
+	       COUNT(n.id) FILTER (WHERE n.is_read),
+	       COUNT(ar.outbox_id),
+	       COUNT(o.id) FILTER (WHERE o.status = 'SENT'),
+	       COUNT(o.id) FILTER (WHERE o.status = 'DEAD'),
+	       COUNT(ar.email) FILTER (WHERE ar.digested)
+	FROM announcements a
+	LEFT JOIN announcement_recipients ar ON ar.announcement_id = a.id
+	LEFT JOIN notifications n ON n.id = ar.notification_id
+	LEFT JOIN email_outbox o ON o.id = ar.outbox_id
+`
+
+func scanAnnouncement(row interface{ Scan(...any) error }) (*Announcement, error) {
+	var a Announcement
+	var author sql.NullInt64
+	var sentAt sql.NullTime
+	err := row.Scan(&a.ID, &a.EventID, &author, &a.Subject, &a.Body, pq.Array(&a.Audiences), &a.Status, &a.SendAt, &sentAt, &a.CreatedAt,
+		&a.Attempts, &a.LastError,
+		&a.Stats.Recipients, &a.Stats.InApp, &a.Stats.Read, &a.Stats.Emailed, &a.Stats.EmailSent, &a.Stats.EmailFailed, &a.Stats.Digested)
+	if err != nil {
+		return nil, err
+	}
+	if author.Valid {
+		a.AuthorID = &author.Int64
+	}
+	if sentAt.Valid {
+		a.SentAt = &sentAt.Time
+	}
+	return &a, nil
+}
+
+// Get returns one announcement with its delivery stats.
+func (s *Service) Get(ctx context.Context, id int64) (*Announcement, error) {
+	a, err := scanAnnouncement(s.DB.QueryRowContext(ctx, selectAnnouncement+" WHERE a.id = $1 GROUP BY a.id", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return a, err
+}
+
+// List returns an event's announcements, newest first, with stats.
+func (s *Service) List(ctx context.Context, eventID int64) ([]*Announcement, error) {
+	rows, err := s.DB.QueryContext(ctx, selectAnnouncement+" WHERE a.event_id = $1 GROUP BY a.id ORDER BY a.id DESC", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*Announcement{}
+	for rows.Next() {
+		a, err := scanAnnouncement(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// Recipients lists who an announcement went to and whether they got it.
+func (s *Service) Recipients(ctx context.Context, id int64) ([]*Recipient, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT ar.user_id, ar.email, ar.audience, ar.notification_id IS NOT NULL, COALESCE(n.is_read, FALSE),
+		       CASE WHEN ar.digested THEN 'DIGEST' ELSE COALESCE(o.status, '') END
+		FROM announcement_recipients ar
+		LEFT JOIN notifications n ON n.id = ar.notification_id
+		LEFT JOIN email_outbox o ON o.id = ar.outbox_id
+		WHERE ar.announcement_id = $1
+		ORDER BY ar.email
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*Recipient{}
+	for rows.Next() {
+		var rc Recipient
+		var userID sql.NullInt64
+		if err := rows.Scan(&userID, &rc.Email, &rc.Audience, &rc.InApp, &rc.Read, &rc.EmailStatus); err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			rc.UserID = &userID.Int64
+		}
+		list = append(list, &rc)
+	}
+	return list, rows.Err()
+}
+
+// Cancel stops a scheduled announcement from going out.
+func (s *Service) Cancel(ctx context.Context, id int64) error {
+	res, err := s.DB.ExecContext(ctx, "UPDATE announcements SET status = 'CANCELLED' WHERE id = $1 AND status = 'SCHEDULED'", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotScheduled
+	}
+	return nil
+}
+
+// Send delivers a scheduled announcement now. It returns ErrNotScheduled if
+// it was already sent or cancelled, or is being sent by another instance.
+func (s *Service) Send(ctx context.Context, id int64) error {
+	return s.claimAndSend(ctx, "id = $1 AND status = 'SCHEDULED'", id)
+}
+
+// SendDue sends every scheduled announcement whose time has come and
+// returns how many were sent. One that fails is retried later (see
+// maxSendAttempts) and does not stop the others; the failures are returned
+// together.
+func (s *Service) SendDue(ctx context.Context) (int, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id FROM announcements
+		WHERE status = 'SCHEDULED' AND send_at <= NOW() AND next_attempt_at <= NOW()
+		ORDER BY send_at, id
+	`)
+	if err != nil {
+		return 0, err
+	}
+	var due []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, id := range due {
+		err := s.claimAndSend(ctx, "id = $1 AND status = 'SCHEDULED' AND next_attempt_at <= NOW()", id)
+		switch {
+		case errors.Is(err, ErrNotScheduled):
+			// Sent, cancelled or claimed by another instance meanwhile.
+		case err != nil:
+			errs = append(errs, fmt.Errorf("announcement %d: %w", id, err))
+			if err := s.recordFailure(ctx, id, err); err != nil {
+				errs = append(errs, fmt.Errorf("announcement %d: recording failure: %w", id, err))
+			}
+		default:
+			sent++
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// recordFailure counts a failed send and schedules the next try, or marks
+// the announcement FAILED once it has used up its attempts.
+func (s *Service) recordFailure(ctx context.Context, id int64, sendErr error) error {
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE announcements SET
+			attempts = attempts + 1,
+			last_error = $2,
+			status = CASE WHEN attempts + 1 >= $3 THEN 'FAILED' ELSE status END,
+			next_attempt_at = NOW() + LEAST(INTERVAL '1 minute' * POWER(2, attempts), INTERVAL '1 hour')
+		WHERE id = $1 AND status = 'SCHEDULED'
+	`, id, sendErr.Error(), maxSendAttempts)
+	return err
+}
+
+type target struct {
+	rcpt     notifications.Recipient
+	audience string
+}
+
+// claimAndSend locks one matching announcement, resolves its audiences at
+// send time and delivers it, all in one transaction. SKIP LOCKED lets
+// several API instances run the scheduler without sending twice.
+func (s *Service) claimAndSend(ctx context.Context, where string, args ...any) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var a Announcement
+	err = tx.QueryRowContext(ctx,
+		"SELECT id, event_id, subject, body, audiences FROM announcements WHERE "+where+" LIMIT 1 FOR UPDATE SKIP LOCKED", args...,
+	).Scan(&a.ID, &a.EventID, &a.Subject, &a.Body, pq.Array(&a.Audiences))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotScheduled
+	} else if err != nil {
+		return err
+	}
+
+	data := notifications.TemplateData{
+		Announcement: notifications.AnnouncementVars{Subject: a.Subject, Body: a.Body},
+	}
+	data.Event.ID = a.EventID
+	err = tx.QueryRowContext(ctx, "SELECT title, location, start_time, end_time FROM events WHERE id=$1", a.EventID).
+		Scan(&data.Event.Title, &data.Event.Location, &data.Event.StartTime, &data.Event.EndTime)
+	if err != nil {
+		return err
+	}
+
+	targets, err := resolve(ctx, tx, a.EventID, a.Audiences)
+	if err != nil {
+		return err
+	}
+	for _, t := range targets {
+		d, err := s.Notifications.Deliver(ctx, tx, t.rcpt, notifications.TmplAnnouncement, data)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO announcement_recipients (announcement_id, email, user_id, audience, notification_id, outbox_id, digested)
+			VALUES ($1, $2, NULLIF($3, 0), $4, NULLIF($5, 0), NULLIF($6, 0), $7)
+		`, a.ID, t.rcpt.Email, t.rcpt.UserID, t.audience, d.NotificationID, d.OutboxID, d.Digested)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE announcements SET status = 'SENT', sent_at = NOW() WHERE id = $1", a.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// resolve expands audiences into recipients. Someone in several audiences
+// is contacted once, under the first audience listed.
+func resolve(ctx context.Context, tx *sql.Tx, eventID int64, audiences []string) ([]target, error) {
+	seen := map[string]bool{}
+	var targets []target
+	for _, audience := range audiences {
+		query, ok := audienceQueries[audience]
+		if !ok {
+			continue
+		}
+		rows, err := tx.QueryContext(ctx, query, eventID)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var userID sql.NullInt64
+			var rc notifications.Recipient
+			if err := rows.Scan(&userID, &rc.Email, &rc.Locale, &rc.Timezone); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			key := strings.ToLower(rc.Email)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			rc.UserID = userID.Int64
+			targets = append(targets, target{rcpt: rc, audience: audience})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
@@ -2,9 +2,11 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
@@ -14,13 +16,82 @@ import (
 
 type CustomClaims struct {
 	Scope string `json:"scope"`
+
+	// All holds every claim in the token, including namespaced custom
+	// claims, for role mapping.
+	All map[string]any `json:"-"`
+}
+
+func (c *CustomClaims) UnmarshalJSON(data []byte) error {
+	var all map[string]any
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	c.All = all
+	c.Scope, _ = all["scope"].(string)
+	return nil
+}
+
+// ClaimsFrom returns every claim of a validated token, or nil if the token
+// carried no custom claims.
+func ClaimsFrom(v *validator.ValidatedClaims) map[string]any {
+	if c, ok := v.CustomClaims.(*CustomClaims); ok {
+		return c.All
+	}
+	return nil
+}
+
+// VerifiedEmail returns the token's email if the identity provider marked
+// it verified, or "". Access tokens carry it as "email" and
+// "email_verified", or under a namespace (e.g. "https://campussync/email"),
+// which is how Auth0 Actions add them.
+func VerifiedEmail(claims map[string]any) string {
+	var email string
+	var verified bool
+	for k, v := range claims {
+		switch {
+		case k == "email" || strings.HasSuffix(k, "/email"):
+			email, _ = v.(string)
+		case k == "email_verified" || strings.HasSuffix(k, "/email_verified"):
+			verified, _ = v.(bool)
+		}
+	}
+	if !verified {
+		return ""
+	}
+	return strings.TrimSpace(email)
 }
 
 func (c *CustomClaims) Validate(ctx context.Context) error {
 	return nil
 }
 
+// streamTokenExtractor reads the Authorization header, and also accepts an
+// access_token query parameter on the notification stream, because browser
+// EventSource connections cannot set headers.
+func streamTokenExtractor(r *http.Request) (string, error) {
+	token, err := jwtmiddleware.AuthHeaderTokenExtractor(r)
+	if err != nil || token != "" {
+		return token, err
+	}
+	if strings.HasSuffix(r.URL.Path, "/notifications/stream") {
+		return r.URL.Query().Get("access_token"), nil
+	}
+	return "", nil
+}
+
 func EnsureValidToken(domain string, audience string) func(next http.Handler) http.Handler {
+	return newJWTMiddleware(domain, audience, false)
+}
+
+// OptionalToken validates a bearer token when one is sent and lets
+// anonymous requests through without claims. Handlers behind it must check
+// whether claims are present.
+func OptionalToken(domain string, audience string) func(next http.Handler) http.Handler {
+	return newJWTMiddleware(domain, audience, true)
+}
+
+func newJWTMiddleware(domain string, audience string, optional bool) func(next http.Handler) http.Handler {
 	issuerURL, err := url.Parse("https://" + domain + "/")
 	if err != nil {
 		log.Fatalf("Failed to parse the issuer url: %v", err)
@@ -50,6 +121,8 @@ func EnsureValidToken(domain string, audience string) func(next http.Handler) ht
 			w.WriteHeader(http.StatusUnauthorized)
 			w.Write([]byte(`{"message":"Failed to validate JWT."}`))
 		}),
+		jwtmiddleware.WithTokenExtractor(streamTokenExtractor),
+		jwtmiddleware.WithCredentialsOptional(optional),
 	)
 
 	return func(next http.Handler) http.Handler {
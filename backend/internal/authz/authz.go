@@ -0,0 +1,119 @@
+package authz
+
+import "slices"
+
+// Permission names something a user may do. Handlers and routes check
+// permissions, never role names, so what a role can do is decided here.
+type Permission string
+
+// Authenticated is the permission of routes any signed-in user may call.
+const Authenticated Permission = ""
+
+const (
+	// EventCreate lets a user create events and manage the ones they
+	// organize.
+	EventCreate Permission = "event.create"
+	// EventEditAny lets a user manage and see every event, including
+	// private events of other organizers.
+	EventEditAny Permission = "event.edit.any"
+	// EventCheckIn lets a user check attendees in by scanning their code.
+	EventCheckIn Permission = "event.checkin"
+
+	UsersManage         Permission = "users.manage"
+	AnalyticsView       Permission = "analytics.view"
+	NotificationsManage Permission = "notifications.manage"
+
+	// ModerationReview covers the queue, actions and bans for a user's own
+	// events; ModerationAny covers every event and site-wide bans.
+	ModerationReview Permission = "moderation.review"
+	ModerationAny    Permission = "moderation.any"
+
+	// WebhooksManage covers a user's own webhooks; WebhooksManageAny
+	// covers everyone's.
+	WebhooksManage    Permission = "webhooks.manage"
+	WebhooksManageAny Permission = "webhooks.manage.any"
+
+	// OrgCreate lets a user start an organization, which they then own.
+	// OrgManageAny lets a user act as the owner of every organization,
+	// e.g. to hand one over when its officers graduate.
+	OrgCreate    Permission = "org.create"
+	OrgManageAny Permission = "org.manage.any"
+)
+
+const (
+	RoleAdmin     = "Admin"
+	RoleOrganizer = "Organizer"
+	RoleMember    = "Member"
+)
+
+// roleOrder lists the roles from weakest to strongest.
+var roleOrder = []string{RoleMember, RoleOrganizer, RoleAdmin}
+
+var organizerPermissions = []Permission{
+	EventCreate,
+	EventCheckIn,
+	ModerationReview,
+	WebhooksManage,
+	OrgCreate,
+}
+
+// roles defines each role as a set of permissions.
+var roles = map[string][]Permission{
+	RoleMember:    {},
+	RoleOrganizer: organizerPermissions,
+	RoleAdmin: append(slices.Clone(organizerPermissions),
+		EventEditAny,
+		UsersManage,
+		AnalyticsView,
+		NotificationsManage,
+		ModerationAny,
+		WebhooksManageAny,
+		OrgManageAny,
+	),
+}
+
+// ValidRole reports whether role is one of the defined roles.
+func ValidRole(role string) bool {
+	_, ok := roles[role]
+	return ok
+}
+
+// Strongest returns the strongest of the given roles, ignoring empty and
+// unknown ones, or RoleMember if none is valid.
+func Strongest(roles ...string) string {
+	best := 0
+	for _, r := range roles {
+		if i := slices.Index(roleOrder, r); i > best {
+			best = i
+		}
+	}
+	return roleOrder[best]
+}
+
+// Permissions returns the permissions of a role, none for an unknown role.
+func Permissions(role string) []Permission {
+	return slices.Clone(roles[role])
+}
+
+// RolesWith returns the roles that have permission p, weakest first, for
+// queries that must check many users at once.
+func RolesWith(p Permission) []string {
+	var list []string
+	for _, r := range roleOrder {
+		if Can(r, p) {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// Can reports whether a user with role has permission p.
+func Can(role string, p Permission) bool {
+	return p == Authenticated || slices.Contains(roles[role], p)
+}
+
+// CanManageEvent reports whether a user may manage an event: its organizer
+// always may, anyone else needs EventEditAny.
+func CanManageEvent(role string, userID, organizerID int64) bool {
+	return userID == organizerID || Can(role, EventEditAny)
+}
@@ -0,0 +1,40 @@
+package authz
+
+import (
+	"context"
+	"log"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+// CanManage reports whether user may manage e: its organizer, an owner or
+// officer of its organization, or anyone with EventEditAny. Every handler
+// that guards an event uses it, so organization officers get the same
+// access everywhere. With a nil orgs only CanManageEvent applies.
+func CanManage(ctx context.Context, orgs *store.OrganizationRepository, user *store.User, e *store.Event) bool {
+	if CanManageEvent(user.Role, user.ID, e.OrganizerID) {
+		return true
+	}
+	if e.OrganizationID == 0 {
+		return false
+	}
+	role, err := orgs.MemberRole(ctx, e.OrganizationID, user.ID)
+	if err != nil {
+		log.Printf("Failed to look up organization role of user %d: %v", user.ID, err)
+	}
+	return store.IsManager(role)
+}
+
+// CanCreateEvents reports whether user may create events at all: with
+// EventCreate, or as an owner or officer of some organization, who may post
+// for it. Which organization an event may go to is checked separately.
+func CanCreateEvents(ctx context.Context, orgs *store.OrganizationRepository, user *store.User) bool {
+	if Can(user.Role, EventCreate) {
+		return true
+	}
+	ok, err := orgs.ManagesAny(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to look up organizations of user %d: %v", user.ID, err)
+	}
+	return ok
+}
@@ -0,0 +1,45 @@
+package background
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/announcements"
+)
+
+// AnnouncementScheduler sends scheduled announcements once they are due.
+type AnnouncementScheduler struct {
+	Announcements *announcements.Service
+}
+
+func NewAnnouncementScheduler(svc *announcements.Service) *AnnouncementScheduler {
+	return &AnnouncementScheduler{Announcements: svc}
+}
+
+func (s *AnnouncementScheduler) Start() {
+	ticker := time.NewTicker(1 * time.Minute)
+	go func() {
+		for range ticker.C {
+			s.sendDue()
+		}
+	}()
+}
+
+func (s *AnnouncementScheduler) sendDue() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	sent, err := s.Announcements.SendDue(ctx)
+	if err != nil {
+		log.Printf("Error sending scheduled announcements: %v", err)
+	}
+	if sent > 0 {
+		log.Printf("📣 [Background Job] Announcements: %d scheduled announcements sent.", sent)
+	}
+}
+
+// Test_SendDue is only used in tests.
+func (s *AnnouncementScheduler) Test_SendDue() {
+	s.sendDue()
+}
@@ -0,0 +1,25 @@
+package background
+
+import "time"
+
+// Backoff returns the exponential delay before retry number attempt
+// (1-based): base, 2*base, 4*base, ... capped at max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	return d
+}
+
+// claimLease is how long a row claimed by a background job is hidden from
+// other instances while it is worked on outside any transaction. If an
+// instance dies mid-batch, the row is picked up again once the lease runs
+// out.
+const claimLease = 5 * time.Minute
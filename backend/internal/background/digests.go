@@ -0,0 +1,160 @@
+package background
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+)
+
+// DigestBuilder emails users who chose a daily or weekly digest a single
+// summary of the notifications collected in digest_items since their last
+// one.
+type DigestBuilder struct {
+	DB            *sql.DB
+	Notifications *notifications.Service
+}
+
+func NewDigestBuilder(db *sql.DB, notify *notifications.Service) *DigestBuilder {
+	return &DigestBuilder{DB: db, Notifications: notify}
+}
+
+var digestPeriods = []struct {
+	frequency string
+	period    time.Duration
+}{
+	{notifications.EmailDaily, 24 * time.Hour},
+	{notifications.EmailWeekly, 7 * 24 * time.Hour},
+}
+
+func (b *DigestBuilder) Start() {
+	ticker := time.NewTicker(15 * time.Minute)
+	go func() {
+		for range ticker.C {
+			b.buildDigests()
+		}
+	}()
+}
+
+func (b *DigestBuilder) buildDigests() {
+	for _, p := range digestPeriods {
+		sent, err := b.sendFor(p.frequency, p.period)
+		if err != nil {
+			log.Printf("Error building %s digests: %v", p.frequency, err)
+			continue
+		}
+		if sent > 0 {
+			log.Printf("📰 [Background Job] Digests: %d %s digests queued.", sent, p.frequency)
+		}
+	}
+}
+
+// sendFor queues a digest for every user whose last digest of this
+// frequency (or, for a first digest, whose oldest pending item) is at least
+// one period old.
+func (b *DigestBuilder) sendFor(frequency string, period time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	rows, err := b.DB.QueryContext(ctx, `
+		SELECT d.user_id
+		FROM digest_items d
+		LEFT JOIN digest_runs r ON r.user_id = d.user_id AND r.frequency = d.frequency
+		WHERE d.digested_at IS NULL AND d.frequency = $1
+		GROUP BY d.user_id, r.last_sent_at
+		HAVING COALESCE(r.last_sent_at, MIN(d.created_at)) <= $2
+	`, frequency, time.Now().Add(-period))
+	if err != nil {
+		return 0, err
+	}
+	var users []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		users = append(users, id)
+	}
+	rows.Close()
+
+	sent := 0
+	for _, userID := range users {
+		ok, err := b.sendDigest(ctx, userID, frequency)
+		if err != nil {
+			log.Printf("Error building %s digest for user %d: %v", frequency, userID, err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func (b *DigestBuilder) sendDigest(ctx context.Context, userID int64, frequency string) (bool, error) {
+	tx, err := b.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Lock the pending items so a concurrent instance cannot include them
+	// in a second digest.
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, category, subject, body, created_at
+		FROM digest_items
+		WHERE user_id = $1 AND frequency = $2 AND digested_at IS NULL
+		ORDER BY created_at ASC
+		FOR UPDATE SKIP LOCKED
+	`, userID, frequency)
+	if err != nil {
+		return false, err
+	}
+	var ids []int64
+	digest := notifications.DigestVars{Period: frequency}
+	for rows.Next() {
+		var id int64
+		var item notifications.DigestItem
+		if err := rows.Scan(&id, &item.Category, &item.Subject, &item.Text, &item.CreatedAt); err != nil {
+			rows.Close()
+			return false, err
+		}
+		ids = append(ids, id)
+		digest.Items = append(digest.Items, item)
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return false, nil
+	}
+
+	rcpt := notifications.Recipient{UserID: userID}
+	err = tx.QueryRowContext(ctx, "SELECT email, locale, timezone FROM users WHERE id=$1", userID).Scan(&rcpt.Email, &rcpt.Locale, &rcpt.Timezone)
+	if err != nil {
+		return false, err
+	}
+	if err := b.Notifications.EnqueueDigest(ctx, tx, rcpt, digest); err != nil {
+		return false, err
+	}
+
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, "UPDATE digest_items SET digested_at = NOW() WHERE id = $1", id); err != nil {
+			return false, err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO digest_runs (user_id, frequency, last_sent_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id, frequency) DO UPDATE SET last_sent_at = NOW()
+	`, userID, frequency)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Test_BuildDigests is only used in tests.
+func (b *DigestBuilder) Test_BuildDigests() {
+	b.buildDigests()
+}
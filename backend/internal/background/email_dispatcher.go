@@ -0,0 +1,129 @@
+package background
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+)
+
+// EmailDispatcher delivers messages queued in email_outbox. Rows are claimed
+// with FOR UPDATE SKIP LOCKED and leased, so several API instances can run
+// it at once without picking up the same message.
+type EmailDispatcher struct {
+	DB          *sql.DB
+	Transport   notifications.Transport
+	BatchSize   int
+	MaxAttempts int
+}
+
+func NewEmailDispatcher(db *sql.DB, transport notifications.Transport) *EmailDispatcher {
+	return &EmailDispatcher{
+		DB:          db,
+		Transport:   transport,
+		BatchSize:   50,
+		MaxAttempts: 6,
+	}
+}
+
+func (d *EmailDispatcher) Start() {
+	ticker := time.NewTicker(10 * time.Second)
+	go func() {
+		for range ticker.C {
+			d.dispatchBatch()
+		}
+	}()
+}
+
+type outboxRow struct {
+	id       int64
+	msg      notifications.Message
+	attempts int
+}
+
+func (d *EmailDispatcher) dispatchBatch() {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	batch, err := d.claim(ctx)
+	if err != nil {
+		log.Printf("Error claiming outbox messages: %v", err)
+		return
+	}
+
+	// Each message is sent and marked on its own, outside any transaction,
+	// so a slow mail server holds no locks and one failure does not resend
+	// what already went out. If marking fails after a send, the message is
+	// sent again when the lease ends: delivery is at least once.
+	var sent, failed int
+	for _, o := range batch {
+		sendErr := d.Transport.Send(ctx, o.msg)
+
+		switch {
+		case sendErr == nil:
+			_, err = d.DB.ExecContext(ctx,
+				"UPDATE email_outbox SET status='SENT', last_error=NULL, sent_at=NOW() WHERE id=$1",
+				o.id)
+			sent++
+		case o.attempts >= d.MaxAttempts:
+			_, err = d.DB.ExecContext(ctx,
+				"UPDATE email_outbox SET status='DEAD', last_error=$1 WHERE id=$2",
+				sendErr.Error(), o.id)
+			failed++
+		default:
+			next := time.Now().Add(Backoff(o.attempts, 30*time.Second, 1*time.Hour))
+			_, err = d.DB.ExecContext(ctx,
+				"UPDATE email_outbox SET last_error=$1, next_attempt_at=$2 WHERE id=$3",
+				sendErr.Error(), next, o.id)
+			failed++
+		}
+		if err != nil {
+			log.Printf("Error updating outbox message %d: %v", o.id, err)
+		}
+	}
+
+	if sent > 0 || failed > 0 {
+		log.Printf("📧 [Background Job] Email Dispatch: %d sent, %d failed.", sent, failed)
+	}
+}
+
+// claim picks due messages, counts the attempt and leases them for
+// claimLease in one statement. attempts in the result already includes
+// the attempt about to be made.
+func (d *EmailDispatcher) claim(ctx context.Context) ([]outboxRow, error) {
+	rows, err := d.DB.QueryContext(ctx, `
+		WITH due AS (
+			SELECT id FROM email_outbox
+			WHERE status = 'PENDING' AND next_attempt_at <= NOW()
+			ORDER BY id ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE email_outbox o
+		SET attempts = o.attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		FROM due
+		WHERE o.id = due.id
+		RETURNING o.id, o.to_email, o.subject, o.body, COALESCE(o.html_body, ''), COALESCE(o.unsubscribe_url, ''), o.attempts
+	`, d.BatchSize, int(claimLease.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []outboxRow
+	for rows.Next() {
+		var o outboxRow
+		if err := rows.Scan(&o.id, &o.msg.To, &o.msg.Subject, &o.msg.Body, &o.msg.HTMLBody, &o.msg.UnsubscribeURL, &o.attempts); err != nil {
+			return nil, err
+		}
+		batch = append(batch, o)
+	}
+	return batch, rows.Err()
+}
+
+// Test_DispatchBatch is only used in tests.
+func (d *EmailDispatcher) Test_DispatchBatch() {
+	d.dispatchBatch()
+}
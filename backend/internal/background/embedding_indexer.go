@@ -0,0 +1,62 @@
+package background
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/search"
+)
+
+// EmbeddingIndexer keeps event embeddings up to date for semantic search.
+// New and edited events are indexed within one tick.
+type EmbeddingIndexer struct {
+	Index     *search.Index
+	BatchSize int
+}
+
+func NewEmbeddingIndexer(index *search.Index) *EmbeddingIndexer {
+	return &EmbeddingIndexer{Index: index, BatchSize: 50}
+}
+
+func (e *EmbeddingIndexer) Start() {
+	ticker := time.NewTicker(30 * time.Second)
+	go func() {
+		e.Drain()
+		for range ticker.C {
+			e.Drain()
+		}
+	}()
+}
+
+// Drain indexes batches until every event is up to date.
+func (e *EmbeddingIndexer) Drain() int {
+	total := 0
+	for {
+		n := e.indexBatch()
+		if n == 0 {
+			return total
+		}
+		total += n
+	}
+}
+
+func (e *EmbeddingIndexer) indexBatch() int {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	n, err := e.Index.IndexBatch(ctx, e.BatchSize)
+	if err != nil {
+		log.Printf("Error indexing event embeddings: %v", err)
+		return 0
+	}
+	if n > 0 {
+		log.Printf("🧭 [Background Job] Indexed %d event embeddings", n)
+	}
+	return n
+}
+
+// Test_IndexBatch is only used in tests.
+func (e *EmbeddingIndexer) Test_IndexBatch() int {
+	return e.indexBatch()
+}
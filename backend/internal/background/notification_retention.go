@@ -0,0 +1,70 @@
+package background
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// NotificationRetention deletes read notifications older than MaxAge.
+// Unread ones are kept however old they are, so nothing disappears before
+// the user has seen it. Archived ones are kept too: archiving marks them
+// read, but the user chose to keep them, so only the user deletes them.
+type NotificationRetention struct {
+	DB     *sql.DB
+	MaxAge time.Duration
+}
+
+// ParseRetention parses NOTIFICATION_RETENTION. An empty string yields the
+// 90-day default. The value must be positive: zero or less would prune
+// every read notification.
+func ParseRetention(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 90 * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("notification retention %q must be positive", s)
+	}
+	return d, nil
+}
+
+func NewNotificationRetention(db *sql.DB, maxAge time.Duration) *NotificationRetention {
+	return &NotificationRetention{DB: db, MaxAge: maxAge}
+}
+
+func (n *NotificationRetention) Start() {
+	ticker := time.NewTicker(1 * time.Hour)
+	go func() {
+		for range ticker.C {
+			n.prune()
+		}
+	}()
+}
+
+func (n *NotificationRetention) prune() {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	res, err := n.DB.ExecContext(ctx,
+		"DELETE FROM notifications WHERE is_read = TRUE AND archived_at IS NULL AND created_at < $1", time.Now().Add(-n.MaxAge))
+	if err != nil {
+		log.Printf("Error pruning notifications: %v", err)
+		return
+	}
+	if rows, _ := res.RowsAffected(); rows > 0 {
+		log.Printf("🧹 [Background Job] Notification Retention: %d old read notifications deleted.", rows)
+	}
+}
+
+// Test_Prune is only used in tests.
+func (n *NotificationRetention) Test_Prune() {
+	n.prune()
+}
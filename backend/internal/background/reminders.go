@@ -0,0 +1,167 @@
+package background
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+)
+
+// ReminderScheduler sends in-app and email reminders to registered users at
+// fixed offsets before an event starts (e.g. 24h and 1h).
+type ReminderScheduler struct {
+	DB            *sql.DB
+	Notifications *notifications.Service
+	Offsets       []time.Duration
+}
+
+func NewReminderScheduler(db *sql.DB, notify *notifications.Service, offsets []time.Duration) *ReminderScheduler {
+	sorted := append([]time.Duration(nil), offsets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+	return &ReminderScheduler{DB: db, Notifications: notify, Offsets: sorted}
+}
+
+// ParseOffsets parses a comma-separated list such as "24h,1h". An empty
+// string yields the defaults. Offsets must be positive whole minutes, since
+// sent reminders are recorded by offset_minutes; repeats are dropped so the
+// same reminder is not sent twice.
+func ParseOffsets(s string) ([]time.Duration, error) {
+	if strings.TrimSpace(s) == "" {
+		return []time.Duration{24 * time.Hour, 1 * time.Hour}, nil
+	}
+	var offsets []time.Duration
+	seen := make(map[time.Duration]bool)
+	for _, part := range strings.Split(s, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("reminder offset %q must be positive", strings.TrimSpace(part))
+		}
+		if d%time.Minute != 0 {
+			return nil, fmt.Errorf("reminder offset %q must be a whole number of minutes", strings.TrimSpace(part))
+		}
+		if seen[d] {
+			continue
+		}
+		seen[d] = true
+		offsets = append(offsets, d)
+	}
+	return offsets, nil
+}
+
+func (s *ReminderScheduler) Start() {
+	ticker := time.NewTicker(1 * time.Minute)
+	go func() {
+		for range ticker.C {
+			s.sendDueReminders()
+		}
+	}()
+}
+
+func (s *ReminderScheduler) sendDueReminders() {
+	for i, offset := range s.Offsets {
+		// Only the closest applicable reminder is sent: if a registration is
+		// already inside the next (smaller) window, this one is skipped.
+		var floor time.Duration
+		if i+1 < len(s.Offsets) {
+			floor = s.Offsets[i+1]
+		}
+		sent, err := s.sendForOffset(offset, floor)
+		if err != nil {
+			log.Printf("Error sending %v reminders: %v", offset, err)
+			continue
+		}
+		if sent > 0 {
+			log.Printf("🔔 [Background Job] Reminders: %d sent for the %v window.", sent, offset)
+		}
+	}
+}
+
+type dueReminder struct {
+	eventID, userID int64
+}
+
+func (s *ReminderScheduler) sendForOffset(offset, floor time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Claim due reminders. A concurrent instance inserting the same keys
+	// blocks on the primary key and then skips them, so each reminder is
+	// sent once. Registrations made after the window opened are skipped.
+	minutes := int(offset.Minutes())
+	rows, err := tx.QueryContext(ctx, `
+		INSERT INTO event_reminders_sent (event_id, user_id, offset_minutes)
+		SELECT r.event_id, r.user_id, $1
+		FROM registrations r
+		JOIN events e ON e.id = r.event_id
+		WHERE r.status = 'REGISTERED'
+		  AND e.status = 'UPCOMING'
+		  AND e.start_time <= NOW() + make_interval(mins => $1)
+		  AND e.start_time > NOW() + make_interval(mins => $2)
+		  AND r.created_at < e.start_time - make_interval(mins => $1)
+		ON CONFLICT DO NOTHING
+		RETURNING event_id, user_id
+	`, minutes, int(floor.Minutes()))
+	if err != nil {
+		return 0, err
+	}
+
+	var due []dueReminder
+	for rows.Next() {
+		var d dueReminder
+		if err := rows.Scan(&d.eventID, &d.userID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+
+	events := map[int64]notifications.TemplateData{}
+	for _, d := range due {
+		data, ok := events[d.eventID]
+		if !ok {
+			data.Event.ID = d.eventID
+			err := tx.QueryRowContext(ctx,
+				"SELECT title, location, start_time, end_time, reminder_note FROM events WHERE id=$1", d.eventID,
+			).Scan(&data.Event.Title, &data.Event.Location, &data.Event.StartTime, &data.Event.EndTime, &data.Note)
+			if err != nil {
+				return 0, err
+			}
+			events[d.eventID] = data
+		}
+
+		rcpt := notifications.Recipient{UserID: d.userID}
+		err := tx.QueryRowContext(ctx, "SELECT email, locale, timezone FROM users WHERE id=$1", d.userID).Scan(&rcpt.Email, &rcpt.Locale, &rcpt.Timezone)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := s.Notifications.Notify(ctx, tx, rcpt, notifications.TmplEventReminder, data); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(due), nil
+}
+
+// Test_SendDueReminders is only used in tests.
+func (s *ReminderScheduler) Test_SendDueReminders() {
+	s.sendDueReminders()
+}
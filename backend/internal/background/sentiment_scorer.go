@@ -0,0 +1,144 @@
+package background
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/sentiment"
+)
+
+// SentimentScorer labels queued feedback, so a slow or rate-limited model
+// never holds up the request that submitted it. Feedback the model keeps
+// failing on is labelled by the lexicon instead of staying NEUTRAL.
+type SentimentScorer struct {
+	DB          *sql.DB
+	Scorer      *sentiment.Scorer
+	BatchSize   int
+	MaxAttempts int
+}
+
+func NewSentimentScorer(db *sql.DB, scorer *sentiment.Scorer) *SentimentScorer {
+	return &SentimentScorer{DB: db, Scorer: scorer, BatchSize: 20, MaxAttempts: 5}
+}
+
+func (s *SentimentScorer) Start() {
+	ticker := time.NewTicker(10 * time.Second)
+	go func() {
+		for range ticker.C {
+			s.scoreBatch()
+		}
+	}()
+}
+
+// Drain scores batches until nothing is due, for the backfill command.
+func (s *SentimentScorer) Drain() int {
+	total := 0
+	for {
+		n := s.scoreBatch()
+		if n == 0 {
+			return total
+		}
+		total += n
+	}
+}
+
+// scoreTimeout bounds one model call, so a hung request cannot use up the
+// lease of the rest of the batch.
+const scoreTimeout = 30 * time.Second
+
+type feedbackRow struct {
+	id       int64
+	comment  string
+	attempts int
+}
+
+// scoreBatch returns how many rows it claimed.
+func (s *SentimentScorer) scoreBatch() int {
+	ctx, cancel := context.WithTimeout(context.Background(), claimLease)
+	defer cancel()
+
+	batch, err := s.claim(ctx)
+	if err != nil {
+		log.Printf("Error claiming feedback for sentiment: %v", err)
+		return 0
+	}
+
+	// The model is called outside any transaction and each result is saved
+	// on its own. Results are only saved while the comment is unchanged, so
+	// feedback resubmitted meanwhile keeps the fresh queue entry AddFeedback
+	// gave it.
+	var scored, retried int
+	for _, row := range batch {
+		scoreCtx, cancelScore := context.WithTimeout(ctx, scoreTimeout)
+		label, source, scoreErr := s.Scorer.Score(scoreCtx, row.comment)
+		cancelScore()
+		if scoreErr != nil && row.attempts >= s.MaxAttempts {
+			log.Printf("Sentiment for feedback %d failed %d times, using lexicon: %v", row.id, row.attempts, scoreErr)
+			label, source, _ = s.Scorer.Fallback(row.comment)
+			scoreErr = nil
+		}
+
+		if scoreErr == nil {
+			_, err = s.DB.ExecContext(ctx,
+				"UPDATE event_feedback SET sentiment=$1, sentiment_source=$2, sentiment_status='DONE', sentiment_error=NULL WHERE id=$3 AND sentiment_status='PENDING' AND COALESCE(comment, '')=$4",
+				label, source, row.id, row.comment)
+			scored++
+		} else {
+			next := time.Now().Add(Backoff(row.attempts, 30*time.Second, 1*time.Hour))
+			_, err = s.DB.ExecContext(ctx,
+				"UPDATE event_feedback SET sentiment_error=$1, sentiment_next_attempt_at=$2 WHERE id=$3 AND sentiment_status='PENDING' AND COALESCE(comment, '')=$4",
+				scoreErr.Error(), next, row.id, row.comment)
+			retried++
+		}
+		if err != nil {
+			log.Printf("Error updating sentiment for feedback %d: %v", row.id, err)
+		}
+	}
+
+	if scored > 0 || retried > 0 {
+		log.Printf("💬 [Background Job] Sentiment: %d scored, %d to retry.", scored, retried)
+	}
+	return len(batch)
+}
+
+// claim picks due feedback, counts the attempt and leases it for
+// claimLease in one statement. attempts in the result already includes
+// the attempt about to be made.
+func (s *SentimentScorer) claim(ctx context.Context) ([]feedbackRow, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		WITH due AS (
+			SELECT id FROM event_feedback
+			WHERE sentiment_status = 'PENDING' AND sentiment_next_attempt_at <= NOW()
+			ORDER BY id ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE event_feedback f
+		SET sentiment_attempts = f.sentiment_attempts + 1,
+		    sentiment_next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		FROM due
+		WHERE f.id = due.id
+		RETURNING f.id, COALESCE(f.comment, ''), f.sentiment_attempts
+	`, s.BatchSize, int(claimLease.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []feedbackRow
+	for rows.Next() {
+		var row feedbackRow
+		if err := rows.Scan(&row.id, &row.comment, &row.attempts); err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	return batch, rows.Err()
+}
+
+// Test_ScoreBatch is only used in tests.
+func (s *SentimentScorer) Test_ScoreBatch() {
+	s.scoreBatch()
+}
@@ -0,0 +1,132 @@
+package background
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/webhooks"
+)
+
+// WebhookDispatcher sends queued webhook deliveries, claiming them with
+// FOR UPDATE SKIP LOCKED like the EmailDispatcher.
+type WebhookDispatcher struct {
+	DB          *sql.DB
+	Client      *http.Client
+	BatchSize   int
+	MaxAttempts int
+}
+
+func NewWebhookDispatcher(db *sql.DB) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		DB:          db,
+		Client:      webhooks.NewHTTPClient(webhooks.AllowLocalFromEnv()),
+		BatchSize:   20,
+		MaxAttempts: 8,
+	}
+}
+
+func (d *WebhookDispatcher) Start() {
+	ticker := time.NewTicker(5 * time.Second)
+	go func() {
+		for range ticker.C {
+			d.dispatchBatch()
+		}
+	}()
+}
+
+type webhookRow struct {
+	id        int64
+	eventType string
+	payload   []byte
+	attempts  int
+	url       string
+	secret    string
+}
+
+func (d *WebhookDispatcher) dispatchBatch() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	batch, err := d.claim(ctx)
+	if err != nil {
+		log.Printf("Error claiming webhook deliveries: %v", err)
+		return
+	}
+
+	// Each delivery is sent and recorded on its own, outside any
+	// transaction, so a slow endpoint holds no locks and one failure does
+	// not resend what already went out.
+	var delivered, failed int
+	for _, row := range batch {
+		code, sendErr := webhooks.Deliver(ctx, d.Client, row.url, row.secret, row.id, row.eventType, row.payload)
+		statusCode := sql.NullInt64{Int64: int64(code), Valid: code != 0}
+
+		switch {
+		case sendErr == nil:
+			_, err = d.DB.ExecContext(ctx,
+				"UPDATE webhook_deliveries SET status='DELIVERED', last_status_code=$1, last_error=NULL, delivered_at=NOW() WHERE id=$2",
+				statusCode, row.id)
+			delivered++
+		case row.attempts >= d.MaxAttempts:
+			_, err = d.DB.ExecContext(ctx,
+				"UPDATE webhook_deliveries SET status='DEAD', last_status_code=$1, last_error=$2 WHERE id=$3",
+				statusCode, sendErr.Error(), row.id)
+			failed++
+		default:
+			next := time.Now().Add(Backoff(row.attempts, 30*time.Second, 6*time.Hour))
+			_, err = d.DB.ExecContext(ctx,
+				"UPDATE webhook_deliveries SET last_status_code=$1, last_error=$2, next_attempt_at=$3 WHERE id=$4",
+				statusCode, sendErr.Error(), next, row.id)
+			failed++
+		}
+		if err != nil {
+			log.Printf("Error updating webhook delivery %d: %v", row.id, err)
+		}
+	}
+
+	if delivered > 0 || failed > 0 {
+		log.Printf("🪝 [Background Job] Webhooks: %d delivered, %d failed.", delivered, failed)
+	}
+}
+
+// claim picks due deliveries, counts the attempt and leases them for
+// claimLease in one short transaction. attempts in the result already
+// includes the attempt about to be made.
+func (d *WebhookDispatcher) claim(ctx context.Context) ([]webhookRow, error) {
+	rows, err := d.DB.QueryContext(ctx, `
+		WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'PENDING' AND next_attempt_at <= NOW()
+			ORDER BY id ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET attempts = d.attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		FROM due, webhooks w
+		WHERE d.id = due.id AND w.id = d.webhook_id
+		RETURNING d.id, d.event_type, d.payload, d.attempts, w.url, w.secret
+	`, d.BatchSize, int(claimLease.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []webhookRow
+	for rows.Next() {
+		var row webhookRow
+		if err := rows.Scan(&row.id, &row.eventType, &row.payload, &row.attempts, &row.url, &row.secret); err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	return batch, rows.Err()
+}
+
+// Test_DispatchBatch is only used in tests.
+func (d *WebhookDispatcher) Test_DispatchBatch() {
+	d.dispatchBatch()
+}
@@ -0,0 +1,176 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+const (
+	maxQuestion = 1000
+	// replyDeadline replaces the server's WriteTimeout for non-streamed
+	// answers, which can take longer than an ordinary request.
+	replyDeadline = 2 * time.Minute
+)
+
+// Handler serves the chatbot. Its routes sit behind auth.OptionalToken:
+// anonymous visitors can chat about public events, and a valid token adds
+// the caller's private events and lets the bot act for them.
+type Handler struct {
+	Service  *Service
+	UserRepo *store.UserRepository
+}
+
+// viewer identifies the caller, or returns the anonymous viewer when there
+// is no token or the user has not been synced yet.
+func (h *Handler) viewer(r *http.Request) Viewer {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	claims, ok := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	if !ok {
+		return Viewer{IP: ip}
+	}
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		return Viewer{IP: ip}
+	}
+	return Viewer{UserID: user.ID, Email: user.Email, Role: user.Role, IP: ip}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNoPendingAction):
+		status = http.StatusConflict
+	case errors.Is(err, ErrLoginRequired):
+		status = http.StatusUnauthorized
+	case errors.Is(err, ErrQuotaExceeded):
+		status = http.StatusTooManyRequests
+		w.Header().Set("Retry-After", strconv.Itoa(int(ai.ResetIn(time.Now()).Seconds())))
+	default:
+		http.Error(w, "AI Error", status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+}
+
+func (h *Handler) HandleChat(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Question  string `json:"question"`
+		SessionID string `json:"session_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" || len(req.Question) > maxQuestion {
+		http.Error(w, "Question must be between 1 and 1000 characters", http.StatusBadRequest)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.streamChat(w, r, req.SessionID, req.Question)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Now().Add(replyDeadline))
+
+	reply, err := h.Service.Ask(r.Context(), h.viewer(r), req.SessionID, req.Question)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reply)
+}
+
+// streamChat answers over server-sent events: "delta" events carry answer
+// text as it is generated and a final "done" event carries the full Reply.
+// Errors found before anything was sent get an ordinary error response;
+// later ones end the stream with an "error" event. A client that goes away
+// cancels the request context, which stops the upstream call.
+func (h *Handler) streamChat(w http.ResponseWriter, r *http.Request, sessionID, question string) {
+	// The server's WriteTimeout would otherwise cut long answers off.
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	started := false
+	send := func(event string, data any) {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.Header().Set("X-Accel-Buffering", "no")
+			w.WriteHeader(http.StatusOK)
+		}
+		payload, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		rc.Flush()
+	}
+
+	reply, err := h.Service.AskStream(r.Context(), h.viewer(r), sessionID, question, func(text string) {
+		send("delta", map[string]string{"text": text})
+	})
+	switch {
+	case err == nil:
+		send("done", reply)
+	case r.Context().Err() != nil:
+		// The client went away; there is no one left to tell.
+	case started:
+		send("error", map[string]string{"message": "AI Error"})
+	default:
+		writeError(w, err)
+	}
+}
+
+// HandleConfirm runs or discards the action the bot last proposed.
+func (h *Handler) HandleConfirm(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionID string `json:"session_id"`
+		Confirm   bool   `json:"confirm"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.Service.Confirm(r.Context(), h.viewer(r), req.SessionID, req.Confirm)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *Handler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	msgs, err := h.Service.History(r.Context(), h.viewer(r), r.URL.Query().Get("session_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(msgs)
+}
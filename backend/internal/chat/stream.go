@@ -0,0 +1,51 @@
+package chat
+
+import "strings"
+
+// metaMarker starts the line of citations and actions that follows the
+// model's answer.
+const metaMarker = "@@meta"
+
+// metaSplitter passes streamed answer text on as it arrives and holds back
+// everything from metaMarker on. Text that might be the start of the marker
+// is held until the next piece shows whether it is.
+type metaSplitter struct {
+	emit    func(string)
+	pending string
+	done    bool
+}
+
+func (m *metaSplitter) write(delta string) {
+	if m.done {
+		return
+	}
+	m.pending += delta
+	if i := strings.Index(m.pending, metaMarker); i >= 0 {
+		m.send(m.pending[:i])
+		m.pending, m.done = "", true
+		return
+	}
+	keep := 0
+	for n := min(len(metaMarker)-1, len(m.pending)); n > 0; n-- {
+		if strings.HasSuffix(m.pending, metaMarker[:n]) {
+			keep = n
+			break
+		}
+	}
+	m.send(m.pending[:len(m.pending)-keep])
+	m.pending = m.pending[len(m.pending)-keep:]
+}
+
+// flush sends held-back text once the stream has ended without a marker.
+func (m *metaSplitter) flush() {
+	if !m.done {
+		m.send(m.pending)
+		m.pending = ""
+	}
+}
+
+func (m *metaSplitter) send(s string) {
+	if s != "" {
+		m.emit(s)
+	}
+}
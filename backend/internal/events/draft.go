@@ -0,0 +1,150 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+const (
+	maxDraftNotes     = 10
+	maxDraftNoteLen   = 300
+	maxDraftTitle     = 120
+	maxDraftDescLen   = 3000
+	draftWriteTimeout = 1 * time.Minute
+)
+
+// EventDraft is a suggestion the organizer edits before creating the event
+// with HandleCreateEvent; nothing is saved here.
+type EventDraft struct {
+	Title        string              `json:"title"`
+	Description  string              `json:"description"`
+	Category     string              `json:"category"`
+	Tags         []string            `json:"tags"`
+	CustomFields []store.CustomField `json:"custom_fields"`
+}
+
+// checkDraft validates the model's suggestion with the same rules as a real
+// event, so a draft can always be submitted as is.
+func checkDraft(d *ai.EventDraft) (*EventDraft, error) {
+	out := &EventDraft{
+		Title:        strings.TrimSpace(d.Title),
+		Description:  strings.TrimSpace(d.Description),
+		Category:     d.Category,
+		CustomFields: []store.CustomField{},
+	}
+	if out.Title == "" || utf8.RuneCountInString(out.Title) > maxDraftTitle {
+		return nil, errors.New("title is missing or too long")
+	}
+	if out.Description == "" || utf8.RuneCountInString(out.Description) > maxDraftDescLen {
+		return nil, errors.New("description is missing or too long")
+	}
+	if !slices.Contains(Categories, out.Category) {
+		return nil, fmt.Errorf("unknown category %q", out.Category)
+	}
+	for _, f := range d.CustomFields {
+		out.CustomFields = append(out.CustomFields, store.CustomField{Label: strings.TrimSpace(f.Label), Type: f.Type, Required: f.Required})
+	}
+	if err := validateCustomFields(out.CustomFields); err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(d.Tags)
+	if err != nil {
+		return nil, err
+	}
+	out.Tags = tags
+	return out, nil
+}
+
+// HandleDraftEvent suggests a title, description, category, tags and
+// registration questions from an organizer's bullet points.
+func (h *Handler) HandleDraftEvent(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+	if !authz.CanCreateEvents(r.Context(), h.Orgs, user) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: Only Organizers can create events."})
+		return
+	}
+
+	var req struct {
+		Notes []string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	notes := []string{}
+	for _, n := range req.Notes {
+		if n = strings.TrimSpace(n); n != "" {
+			notes = append(notes, n)
+		}
+	}
+	if len(notes) == 0 || len(notes) > maxDraftNotes {
+		http.Error(w, "Provide between 1 and 10 notes", http.StatusBadRequest)
+		return
+	}
+	for _, n := range notes {
+		if len(n) > maxDraftNoteLen {
+			http.Error(w, "Each note must be at most 300 characters", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Now().Add(draftWriteTimeout))
+
+	suggestion, err := h.AI.DraftEvent(ai.WithSubject(r.Context(), ai.UserSubject(user.ID)), notes, Categories, CustomFieldTypes)
+	var draft *EventDraft
+	if err == nil {
+		draft, err = checkDraft(suggestion)
+		if err != nil {
+			err = fmt.Errorf("%w: %v", ai.ErrInvalidReply, err)
+		}
+	}
+
+	var se *ai.StatusError
+	switch {
+	case err == nil:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(draft)
+		return
+	case errors.Is(err, ai.ErrUnavailable):
+		writeDraftError(w, http.StatusServiceUnavailable, "AI Service Unavailable (Missing Key)")
+	case errors.Is(err, ai.ErrQuotaExceeded):
+		w.Header().Set("Retry-After", strconv.Itoa(int(ai.ResetIn(time.Now()).Seconds())))
+		writeDraftError(w, http.StatusTooManyRequests, err.Error())
+	case errors.As(err, &se) && se.Code == http.StatusTooManyRequests:
+		writeDraftError(w, http.StatusTooManyRequests, "AI Limit Reached. Try again later.")
+	case errors.Is(err, ai.ErrInvalidReply):
+		log.Printf("Event draft rejected: %v", err)
+		writeDraftError(w, http.StatusBadGateway, "The AI returned an unusable draft. Please try again.")
+	default:
+		log.Printf("Event draft failed: %v", err)
+		writeDraftError(w, http.StatusBadGateway, "AI currently busy")
+	}
+}
+
+func writeDraftError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,398 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/search"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/webhooks"
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+// What HandleCreateEvent does when the new event looks like an existing
+// one. The default (empty) is to refuse with 409 and list the matches.
+const (
+	DuplicateIgnore = "ignore"
+	DuplicateLink   = "link"
+)
+
+const (
+	// duplicateSlack widens the time window, so a re-post that starts an
+	// hour off is still compared.
+	duplicateSlack = time.Hour
+	minTitleScore  = 0.5
+	minMatchScore  = 0.6
+)
+
+// Match is an existing event that looks like the one being created.
+type Match struct {
+	store.EventSummary
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// scoreDuplicate compares a new event to an existing one. Titles count for
+// half the score, the time overlap and the location for a quarter each. A
+// different title is never a duplicate, however well the rest matches.
+func scoreDuplicate(title, location string, start, end time.Time, e *store.EventSummary) (float64, []string) {
+	titleScore := titleSimilarity(title, e.Title)
+	if titleScore < minTitleScore {
+		return 0, nil
+	}
+	timeScore := timeOverlap(start, end, e.StartTime, e.EndTime)
+	locationScore := locationSimilarity(location, e.Location)
+
+	reasons := []string{"similar title"}
+	if timeScore > 0.5 {
+		reasons = append(reasons, "overlapping time")
+	}
+	if locationScore >= 0.8 {
+		reasons = append(reasons, "same location")
+	}
+	return 0.5*titleScore + 0.25*timeScore + 0.25*locationScore, reasons
+}
+
+// titleSimilarity is the better of word overlap, which ignores word order
+// and plurals, and character bigram overlap, which tolerates typos.
+func titleSimilarity(a, b string) float64 {
+	return max(jaccard(search.Tokens(a), search.Tokens(b)), dice(bigrams(a), bigrams(b)))
+}
+
+// locationSimilarity is 1 for the same place, 0.8 when one names a part of
+// the other ("Library" and "Library, Room 2") and the word overlap
+// otherwise.
+func locationSimilarity(a, b string) float64 {
+	na, nb := normalize(a), normalize(b)
+	switch {
+	case na == "" || nb == "":
+		return 0
+	case na == nb:
+		return 1
+	case strings.Contains(na, nb) || strings.Contains(nb, na):
+		return 0.8
+	}
+	return jaccard(search.Tokens(a), search.Tokens(b))
+}
+
+// timeOverlap is the share of the shorter event that overlaps the other.
+// Events that only come within duplicateSlack of each other get up to 0.5.
+func timeOverlap(aStart, aEnd, bStart, bEnd time.Time) float64 {
+	shorter := min(aEnd.Sub(aStart), bEnd.Sub(bStart))
+	if shorter < time.Minute {
+		shorter = time.Minute
+	}
+	first, last := aEnd, aStart
+	if bEnd.Before(first) {
+		first = bEnd
+	}
+	if bStart.After(last) {
+		last = bStart
+	}
+	overlap := first.Sub(last)
+	if overlap > 0 {
+		return min(float64(overlap)/float64(shorter), 1)
+	}
+	if gap := -overlap; gap < duplicateSlack {
+		return 0.5 * (1 - float64(gap)/float64(duplicateSlack))
+	}
+	return 0
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := map[string]bool{}
+	for _, w := range a {
+		set[w] = true
+	}
+	union := len(set)
+	shared := map[string]bool{}
+	for _, w := range b {
+		if set[w] {
+			shared[w] = true
+		} else if !shared[w] {
+			set[w] = true
+			union++
+		}
+	}
+	return float64(len(shared)) / float64(union)
+}
+
+func bigrams(s string) []string {
+	r := []rune(strings.ReplaceAll(normalize(s), " ", ""))
+	out := make([]string, 0, len(r))
+	for i := 0; i+1 < len(r); i++ {
+		out = append(out, string(r[i:i+2]))
+	}
+	return out
+}
+
+// dice is the Sørensen–Dice coefficient of two multisets.
+func dice(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	counts := map[string]int{}
+	for _, g := range a {
+		counts[g]++
+	}
+	shared := 0
+	for _, g := range b {
+		if counts[g] > 0 {
+			counts[g]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
+
+// findDuplicates returns the active events the user can see that probably
+// are the event described by req, best match first.
+func (h *Handler) findDuplicates(ctx context.Context, req *CreateEventRequest, excludeID int64, user *store.User) ([]*Match, error) {
+	candidates, err := h.Repo.DuplicateCandidates(ctx, req.StartTime, req.EndTime, duplicateSlack, excludeID, user.ID, authz.Can(user.Role, authz.EventEditAny))
+	if err != nil {
+		return nil, err
+	}
+	matches := []*Match{}
+	for _, c := range candidates {
+		score, reasons := scoreDuplicate(req.Title, req.Location, req.StartTime, req.EndTime, c)
+		if score >= minMatchScore {
+			matches = append(matches, &Match{EventSummary: *c, Score: score, Reasons: reasons})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches, nil
+}
+
+// linkMatches links a new event to the matches the organizer picked, or to
+// all of them when they picked none.
+func (h *Handler) linkMatches(ctx context.Context, eventID, userID int64, matches []*Match, pick []int64) {
+	for _, m := range matches {
+		if len(pick) > 0 && !slices.Contains(pick, m.ID) {
+			continue
+		}
+		if err := h.Repo.LinkEvents(ctx, eventID, m.ID, userID); err != nil {
+			log.Printf("Failed to link event %d to %d: %v", eventID, m.ID, err)
+		}
+	}
+}
+
+func writeDuplicates(w http.ResponseWriter, matches []*Match) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":    "This looks like an event that already exists. Link it, or create it anyway.",
+		"duplicates": matches,
+	})
+}
+
+// organizer loads the current user and checks they may create events (see
+// authz.CanCreateEvents).
+func (h *Handler) organizer(w http.ResponseWriter, r *http.Request) (*store.User, bool) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return nil, false
+	}
+	if !authz.CanCreateEvents(r.Context(), h.Orgs, user) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: Only Organizers can manage events."})
+		return nil, false
+	}
+	return user, true
+}
+
+// HandleCheckDuplicates lists the probable duplicates of an event before it
+// is created, for the create form and for imports. exclude_id skips the
+// event being edited.
+func (h *Handler) HandleCheckDuplicates(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.organizer(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		CreateEventRequest
+		ExcludeID int64 `json:"exclude_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Title) == "" || req.StartTime.IsZero() || req.EndTime.Before(req.StartTime) {
+		http.Error(w, "title, start_time and end_time are required", http.StatusBadRequest)
+		return
+	}
+
+	matches, err := h.findDuplicates(r.Context(), &req.CreateEventRequest, req.ExcludeID, user)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"duplicates": matches})
+}
+
+// canSee reports whether the user may see an event at all.
+func (h *Handler) canSee(ctx context.Context, user *store.User, e *store.Event) bool {
+	return e.Visibility == "PUBLIC" || h.canManage(ctx, user, e)
+}
+
+// HandleListLinks returns the events linked to ?event_id=.
+func (h *Handler) HandleListLinks(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+	eventID, err := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid event_id", http.StatusBadRequest)
+		return
+	}
+	event, err := h.Repo.GetEventByID(r.Context(), eventID)
+	if err != nil || !h.canSee(r.Context(), user, event) {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+
+	links, err := h.Repo.GetLinkedEvents(r.Context(), eventID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	visible := []*store.LinkedEvent{}
+	for _, l := range links {
+		if l.Visibility == "PUBLIC" {
+			visible = append(visible, l)
+			continue
+		}
+		if e, err := h.Repo.GetEventByID(r.Context(), l.ID); err == nil && h.canManage(r.Context(), user, e) {
+			visible = append(visible, l)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(visible)
+}
+
+// HandleLinkEvents marks two existing events as duplicates. The caller must
+// organize one of them, and be able to see the other.
+func (h *Handler) HandleLinkEvents(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.organizer(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		EventID       int64 `json:"event_id"`
+		LinkedEventID int64 `json:"linked_event_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.EventID == req.LinkedEventID {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	a, errA := h.Repo.GetEventByID(r.Context(), req.EventID)
+	b, errB := h.Repo.GetEventByID(r.Context(), req.LinkedEventID)
+	if errA != nil || errB != nil || !h.canSee(r.Context(), user, a) || !h.canSee(r.Context(), user, b) {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !h.canManage(r.Context(), user, a) && !h.canManage(r.Context(), user, b) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You can only link events you created."})
+		return
+	}
+
+	if err := h.Repo.LinkEvents(r.Context(), a.ID, b.ID, user.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Events linked"})
+}
+
+// HandleMergeEvents moves the attendees of a duplicate into the event that
+// is kept and cancels the duplicate. The caller must organize both.
+func (h *Handler) HandleMergeEvents(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.organizer(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		SourceID int64 `json:"source_id"`
+		TargetID int64 `json:"target_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SourceID == req.TargetID {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	source, errS := h.Repo.GetEventByID(r.Context(), req.SourceID)
+	target, errT := h.Repo.GetEventByID(r.Context(), req.TargetID)
+	if errS != nil || errT != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !h.canManage(r.Context(), user, source) || !h.canManage(r.Context(), user, target) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You can only merge events you created."})
+		return
+	}
+
+	moved, err := h.Repo.MergeEvents(r.Context(), source.ID, target.ID, user.ID)
+	if errors.Is(err, store.ErrMergeConflict) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	source.Status = "CANCELLED"
+	h.emitWebhook(r.Context(), webhooks.EventCancelled, source.ID, source)
+
+	// Moved attendees hear about the event they are now on; the target's
+	// own attendees see no change.
+	go func() {
+		ctx := context.Background()
+		data := notifications.TemplateData{Event: eventVars(target)}
+		for _, id := range moved {
+			u, err := h.UserRepo.GetByID(ctx, id)
+			if err != nil {
+				continue
+			}
+			rcpt := notifications.Recipient{UserID: u.ID, Email: u.Email, Locale: u.Locale, Timezone: u.Timezone}
+			if err := h.Notifications.Notify(ctx, nil, rcpt, notifications.TmplEventUpdated, data); err != nil {
+				log.Printf("Failed to notify user %d of merge into event %d: %v", id, target.ID, err)
+			}
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"message": "Events merged", "moved": len(moved)})
+}
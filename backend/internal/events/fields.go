@@ -0,0 +1,63 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+// Categories are the event categories offered by the dashboard. Attendance
+// of the ones in store.SensitiveCategories is hidden from friends by default.
+var Categories = []string{"General", "Workshop", "Seminar", "Club Meeting", "Social", "Sports", "Health & Wellness", "Support Group"}
+
+// CustomFieldTypes are the registration question types the form can render.
+var CustomFieldTypes = []string{"text", "number", "boolean"}
+
+const (
+	maxCustomFields = 10
+	maxTags         = 8
+	maxTagLen       = 30
+)
+
+func validateCustomFields(fields []store.CustomField) error {
+	if len(fields) > maxCustomFields {
+		return fmt.Errorf("at most %d custom fields are allowed", maxCustomFields)
+	}
+	seen := map[string]bool{}
+	for _, f := range fields {
+		label := strings.TrimSpace(f.Label)
+		if label == "" {
+			return errors.New("custom field labels are required")
+		}
+		if !slices.Contains(CustomFieldTypes, f.Type) {
+			return fmt.Errorf("custom field %q has invalid type %q (must be text, number or boolean)", label, f.Type)
+		}
+		if seen[strings.ToLower(label)] {
+			return fmt.Errorf("custom field %q is listed twice", label)
+		}
+		seen[strings.ToLower(label)] = true
+	}
+	return nil
+}
+
+// normalizeTags lowercases, trims and de-duplicates tags.
+func normalizeTags(tags []string) ([]string, error) {
+	out := []string{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(t), "#")))
+		if t == "" || slices.Contains(out, t) {
+			continue
+		}
+		if len(t) > maxTagLen {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", t, maxTagLen)
+		}
+		out = append(out, t)
+	}
+	if len(out) > maxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+	return out, nil
+}
@@ -1,18 +1,23 @@
 package events
 
 import (
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/moderation"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/search"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/webhooks"
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
 	"github.com/jung-kurt/gofpdf"
@@ -23,6 +28,10 @@ type Handler struct {
 	UserRepo      *store.UserRepository
 	Notifications *notifications.Service
 	AI            *ai.Service
+	Webhooks      *webhooks.Service
+	Moderation    *moderation.Service
+	Search        *search.Service
+	Orgs          *store.OrganizationRepository
 }
 
 // CreateEventRequest defines what the frontend sends
@@ -35,6 +44,28 @@ type CreateEventRequest struct {
 	Capacity    int       `json:"capacity"`
 	Visibility  string    `json:"visibility"`
 	Category    string    `json:"category"`
+
+	// RequiresApproval turns registrations into applications that an
+	// organizer must approve before a seat is confirmed.
+	RequiresApproval bool `json:"requires_approval"`
+
+	// ReminderNote is appended to the automatic reminders sent before the event.
+	ReminderNote string `json:"reminder_note"`
+
+	// CustomFields are extra questions asked at registration.
+	CustomFields []store.CustomField `json:"custom_fields"`
+	Tags         []string            `json:"tags"`
+
+	// OrganizationID posts the event for a club or department the
+	// organizer is an officer of. MEMBERS visibility needs one.
+	OrganizationID int64 `json:"organization_id"`
+
+	// DuplicateAction says what to do if the event looks like one that
+	// already exists: "" refuses with the matches, "ignore" creates it
+	// anyway and "link" creates it linked to the matches in LinkTo (all of
+	// them when empty).
+	DuplicateAction string  `json:"duplicate_action"`
+	LinkTo          []int64 `json:"link_to"`
 }
 type SelfCheckInRequest struct {
 	Email string `json:"email"`
@@ -54,12 +85,89 @@ func (req *CreateEventRequest) Validate() error {
 	if req.EndTime.Before(req.StartTime) {
 		return errors.New("end time must be after start time")
 	}
-	if req.Visibility != "PUBLIC" && req.Visibility != "PRIVATE" {
-		return errors.New("invalid visibility (must be PUBLIC or PRIVATE)")
+	if req.Visibility != "PUBLIC" && req.Visibility != "PRIVATE" && req.Visibility != "MEMBERS" {
+		return errors.New("invalid visibility (must be PUBLIC, PRIVATE or MEMBERS)")
+	}
+	if req.Visibility == "MEMBERS" && req.OrganizationID == 0 {
+		return errors.New("members-only events need an organization")
+	}
+	if err := validateCustomFields(req.CustomFields); err != nil {
+		return err
 	}
+	if req.DuplicateAction != "" && req.DuplicateAction != DuplicateIgnore && req.DuplicateAction != DuplicateLink {
+		return errors.New("invalid duplicate_action (must be ignore or link)")
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return err
+	}
+	req.Tags = tags
 	return nil
 }
 
+// attendeeFacingChange reports whether an edit changed when, where or what
+// the event is.
+func attendeeFacingChange(before, after *store.Event) bool {
+	return before.Title != after.Title ||
+		before.Location != after.Location ||
+		!before.StartTime.Equal(after.StartTime) ||
+		!before.EndTime.Equal(after.EndTime)
+}
+
+// canManage reports whether the user may manage an event (see
+// authz.CanManage).
+func (h *Handler) canManage(ctx context.Context, user *store.User, e *store.Event) bool {
+	return authz.CanManage(ctx, h.Orgs, user, e)
+}
+
+// canPostFor reports whether the user may post events for an organization.
+func (h *Handler) canPostFor(ctx context.Context, user *store.User, orgID int64) bool {
+	if authz.Can(user.Role, authz.OrgManageAny) {
+		return true
+	}
+	role, err := h.Orgs.MemberRole(ctx, orgID, user.ID)
+	if err != nil {
+		log.Printf("Failed to look up organization role of user %d: %v", user.ID, err)
+	}
+	return store.IsManager(role)
+}
+
+// notifyFollowers tells the followers of the organizer and of the
+// organization about a new event. Note names who posted it.
+func (h *Handler) notifyFollowers(user *store.User, event *store.Event) {
+	ctx := context.Background()
+	poster := user.Email
+	if event.OrganizationID != 0 && h.Orgs != nil {
+		if org, err := h.Orgs.Get(ctx, event.OrganizationID, user.ID); err == nil {
+			poster = org.Name
+		}
+	}
+	data := notifications.TemplateData{Event: eventVars(event), Note: poster}
+	err := h.Notifications.NotifyFollowers(ctx, user.ID, event.OrganizationID, event.Visibility == "MEMBERS", notifications.TmplEventPublished, data)
+	if err != nil {
+		log.Printf("Failed to notify followers of event %d: %v", event.ID, err)
+	}
+}
+
+// eventVars exposes an event to notification templates.
+func eventVars(e *store.Event) notifications.EventVars {
+	return notifications.EventVars{
+		ID:        e.ID,
+		Title:     e.Title,
+		Location:  e.Location,
+		StartTime: e.StartTime,
+		EndTime:   e.EndTime,
+	}
+}
+
+// emitWebhook queues a webhook for an event change. Delivery problems must
+// not fail the request, so errors are only logged.
+func (h *Handler) emitWebhook(ctx context.Context, eventType string, eventID int64, data any) {
+	if err := h.Webhooks.Emit(ctx, nil, eventType, eventID, data); err != nil {
+		log.Printf("Failed to queue %s webhook for event %d: %v", eventType, eventID, err)
+	}
+}
+
 func (h *Handler) HandleCreateEvent(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
 	auth0ID := claims.RegisteredClaims.Subject
@@ -70,16 +178,24 @@ func (h *Handler) HandleCreateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if user.Role != "Organizer" && user.Role != "Admin" {
+	var req CreateEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body format", http.StatusBadRequest)
+		return
+	}
+
+	// Officers may post for their organization whatever their site role;
+	// personal events need EventCreate.
+	if req.OrganizationID != 0 && !h.canPostFor(r.Context(), user, req.OrganizationID) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusForbidden)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: Only Organizers can create events."})
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: Only officers can post events for this organization."})
 		return
 	}
-
-	var req CreateEventRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid body format", http.StatusBadRequest)
+	if req.OrganizationID == 0 && !authz.Can(user.Role, authz.EventCreate) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: Only Organizers can create events."})
 		return
 	}
 
@@ -90,6 +206,18 @@ func (h *Handler) HandleCreateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var matches []*Match
+	if req.DuplicateAction != DuplicateIgnore {
+		if matches, err = h.findDuplicates(r.Context(), &req, 0, user); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if len(matches) > 0 && req.DuplicateAction == "" {
+			writeDuplicates(w, matches)
+			return
+		}
+	}
+
 	event := &store.Event{
 		Title:       req.Title,
 		Description: req.Description,
@@ -101,6 +229,12 @@ func (h *Handler) HandleCreateEvent(w http.ResponseWriter, r *http.Request) {
 		Status:      "UPCOMING",
 		Visibility:  req.Visibility,
 		Category:    req.Category,
+
+		RequiresApproval: req.RequiresApproval,
+		ReminderNote:     req.ReminderNote,
+		CustomFields:     req.CustomFields,
+		Tags:             req.Tags,
+		OrganizationID:   req.OrganizationID,
 	}
 
 	if err := h.Repo.Create(r.Context(), event); err != nil {
@@ -108,6 +242,12 @@ func (h *Handler) HandleCreateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.linkMatches(r.Context(), event.ID, user.ID, matches, req.LinkTo)
+	h.emitWebhook(r.Context(), webhooks.EventCreated, event.ID, event)
+	if event.Visibility != "PRIVATE" {
+		go h.notifyFollowers(user, event)
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(event)
 }
@@ -129,12 +269,6 @@ func (h *Handler) HandleUpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 👇 CHECK: Must be Organizer/Admin
-	if user.Role != "Organizer" && user.Role != "Admin" {
-		http.Error(w, "Forbidden", http.StatusForbidden)
-		return
-	}
-
 	// 👇 CHECK: Ownership (New Logic)
 	existingEvent, err := h.Repo.GetEventByID(r.Context(), req.ID)
 	if err != nil {
@@ -142,13 +276,19 @@ func (h *Handler) HandleUpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// If NOT Admin AND NOT the owner, block it
-	if user.Role != "Admin" && existingEvent.OrganizerID != user.ID {
+	// Only those who manage the event (see authz.CanManage) may edit it.
+	if !h.canManage(r.Context(), user, existingEvent) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You can only edit events you created."})
 		return
 	}
+	if req.OrganizationID != 0 && req.OrganizationID != existingEvent.OrganizationID && !h.canPostFor(r.Context(), user, req.OrganizationID) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: Only officers can post events for this organization."})
+		return
+	}
 
 	if err := req.Validate(); err != nil {
 		w.Header().Set("Content-Type", "application/json")
@@ -167,6 +307,12 @@ func (h *Handler) HandleUpdateEvent(w http.ResponseWriter, r *http.Request) {
 		Capacity:    req.Capacity,
 		Visibility:  req.Visibility,
 		Category:    req.Category,
+
+		RequiresApproval: req.RequiresApproval,
+		ReminderNote:     req.ReminderNote,
+		CustomFields:     req.CustomFields,
+		Tags:             req.Tags,
+		OrganizationID:   req.OrganizationID,
 	}
 
 	if err := h.Repo.Update(r.Context(), event); err != nil {
@@ -174,13 +320,71 @@ func (h *Handler) HandleUpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if updated, err := h.Repo.GetEventByID(r.Context(), event.ID); err == nil {
+		h.emitWebhook(r.Context(), webhooks.EventUpdated, event.ID, updated)
+	}
+
+	// Only changes attendees need to act on are announced; fixing a typo in
+	// the description should not notify everyone.
+	if attendeeFacingChange(existingEvent, event) {
+		go func() {
+			data := notifications.TemplateData{Event: eventVars(event)}
+			if err := h.Notifications.NotifyAttendees(context.Background(), event.ID, notifications.TmplEventUpdated, data); err != nil {
+				log.Printf("Failed to notify attendees of event %d: %v", event.ID, err)
+			}
+		}()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Event updated"})
+}
+
+func (h *Handler) HandleCancelEvent(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.Repo.GetEventByID(r.Context(), req.ID)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !h.canManage(r.Context(), user, event) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You can only cancel events you created."})
+		return
+	}
+
+	if err := h.Repo.CancelEvent(r.Context(), event.ID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
+	event.Status = "CANCELLED"
+
+	h.emitWebhook(r.Context(), webhooks.EventCancelled, event.ID, event)
 	go func() {
-		msg := "Update: Details for '" + event.Title + "' have changed."
-		h.Repo.NotifyAllAttendees(r.Context(), event.ID, msg)
+		data := notifications.TemplateData{Event: eventVars(event)}
+		if err := h.Notifications.NotifyAttendees(context.Background(), event.ID, notifications.TmplEventCancelled, data); err != nil {
+			log.Printf("Failed to notify attendees of cancelled event %d: %v", event.ID, err)
+		}
 	}()
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Event updated"})
+	json.NewEncoder(w).Encode(map[string]string{"message": "Event cancelled"})
 }
 
 func (h *Handler) HandleListEvents(w http.ResponseWriter, r *http.Request) {
@@ -188,6 +392,28 @@ func (h *Handler) HandleListEvents(w http.ResponseWriter, r *http.Request) {
 	location := r.URL.Query().Get("location")
 	category := r.URL.Query().Get("category")
 
+	// ?mode=semantic or hybrid ranks by meaning instead of matching q
+	// literally; the location and category filters still apply.
+	if mode := r.URL.Query().Get("mode"); mode != "" && mode != search.ModeKeyword {
+		if h.Search == nil {
+			http.Error(w, "Semantic search is not configured", http.StatusServiceUnavailable)
+			return
+		}
+		results, err := h.Search.Search(r.Context(), query, location, category, mode)
+		if errors.Is(err, search.ErrInvalidMode) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Semantic search failed: %v", err)
+			http.Error(w, "Failed to fetch events", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(results)
+		return
+	}
+
 	events, err := h.Repo.Search(r.Context(), query, location, category)
 	if err != nil {
 		http.Error(w, "Failed to fetch events", http.StatusInternalServerError)
@@ -198,11 +424,37 @@ func (h *Handler) HandleListEvents(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(events)
 }
 
+// managedEvent loads the event in ?event_id= and checks that the caller
+// manages it, writing the error response if not.
+func (h *Handler) managedEvent(w http.ResponseWriter, r *http.Request) (*store.Event, bool) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return nil, false
+	}
+	eventID, _ := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
+	event, err := h.Repo.GetEventByID(r.Context(), eventID)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return nil, false
+	}
+	if !h.canManage(r.Context(), user, event) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You can only view attendees of events you manage."})
+		return nil, false
+	}
+	return event, true
+}
+
 func (h *Handler) HandleListAttendees(w http.ResponseWriter, r *http.Request) {
-	eventIDStr := r.URL.Query().Get("event_id")
-	eventID, _ := strconv.ParseInt(eventIDStr, 10, 64)
+	event, ok := h.managedEvent(w, r)
+	if !ok {
+		return
+	}
 
-	attendees, err := h.Repo.GetAttendees(r.Context(), eventID)
+	attendees, err := h.Repo.GetAttendees(r.Context(), event.ID)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -213,10 +465,12 @@ func (h *Handler) HandleListAttendees(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleExportAttendees(w http.ResponseWriter, r *http.Request) {
-	eventIDStr := r.URL.Query().Get("event_id")
-	eventID, _ := strconv.ParseInt(eventIDStr, 10, 64)
+	event, ok := h.managedEvent(w, r)
+	if !ok {
+		return
+	}
 
-	attendees, err := h.Repo.GetAttendees(r.Context(), eventID)
+	attendees, err := h.Repo.GetAttendees(r.Context(), event.ID)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -246,12 +500,6 @@ func (h *Handler) HandleInviteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Only Organizer/Admin
-	if user.Role != "Organizer" && user.Role != "Admin" {
-		http.Error(w, "Forbidden", http.StatusForbidden)
-		return
-	}
-
 	var req struct {
 		EventID int64  `json:"event_id"`
 		Email   string `json:"email"`
@@ -266,7 +514,7 @@ func (h *Handler) HandleInviteUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Event not found", http.StatusNotFound)
 		return
 	}
-	if user.Role != "Admin" && existingEvent.OrganizerID != user.ID {
+	if !h.canManage(r.Context(), user, existingEvent) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You can only invite to your own events."})
@@ -288,10 +536,6 @@ func (h *Handler) HandleBulkInvite(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "User not found", http.StatusUnauthorized)
 		return
 	}
-	if user.Role != "Organizer" && user.Role != "Admin" {
-		http.Error(w, "Forbidden", http.StatusForbidden)
-		return
-	}
 
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
 		http.Error(w, "File too large", http.StatusBadRequest)
@@ -309,7 +553,7 @@ func (h *Handler) HandleBulkInvite(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Event not found", http.StatusNotFound)
 		return
 	}
-	if user.Role != "Admin" && existingEvent.OrganizerID != user.ID {
+	if !h.canManage(r.Context(), user, existingEvent) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You can only invite to your own events."})
@@ -341,13 +585,14 @@ func (h *Handler) HandleBulkInvite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	go func() {
-		for _, email := range emails {
-			if email != "" {
-				h.Notifications.SendInviteEmail(email, "Exclusive Campus Event")
+	data := notifications.TemplateData{Event: eventVars(existingEvent)}
+	for _, email := range emails {
+		if email != "" {
+			if err := h.Notifications.Notify(r.Context(), nil, notifications.Recipient{Email: email}, notifications.TmplEventInvite, data); err != nil {
+				log.Printf("Failed to queue invite email for %s: %v", email, err)
 			}
 		}
-	}()
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -384,19 +629,23 @@ func (h *Handler) HandleAddFeedback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 5. Analyze Sentiment (Now 'req' is definitely defined)
-	sentiment := "NEUTRAL"
-	if h.AI != nil {
-		sentiment = h.AI.AnalyzeSentiment(req.Comment)
+	// 5. Moderate the comment, if any
+	verdict := moderation.Verdict{Decision: moderation.Publish}
+	if strings.TrimSpace(req.Comment) != "" {
+		var ok bool
+		if verdict, ok = h.screen(w, r, user.ID, req.EventID, moderation.TypeFeedback, req.Comment); !ok {
+			return
+		}
 	}
 
-	// 6. Create Feedback Object
+	// 6. Create Feedback Object. Sentiment is scored in the background.
 	feedback := &store.Feedback{
-		EventID:   req.EventID,
-		UserID:    user.ID,
-		Rating:    req.Rating,
-		Comment:   req.Comment,
-		Sentiment: sentiment,
+		EventID:      req.EventID,
+		UserID:       user.ID,
+		Rating:       req.Rating,
+		Comment:      req.Comment,
+		Status:       verdict.Status(),
+		StatusReason: verdict.Reason,
 	}
 
 	// 7. Save to DB
@@ -404,6 +653,7 @@ func (h *Handler) HandleAddFeedback(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
+	h.recordVerdict(r.Context(), moderation.TypeFeedback, feedback.ID, req.EventID, user.ID, verdict)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Feedback submitted successfully"})
@@ -416,7 +666,7 @@ func (h *Handler) HandleGetAnalytics(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "User not found", http.StatusUnauthorized)
 		return
 	}
-	if user.Role != "Admin" {
+	if !authz.Can(user.Role, authz.AnalyticsView) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
@@ -434,7 +684,7 @@ func (h *Handler) HandleGetAnalytics(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) HandleCheckIn(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
 	adminUser, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
-	if err != nil || (adminUser.Role != "Admin" && adminUser.Role != "Organizer") {
+	if err != nil || !authz.Can(adminUser.Role, authz.EventCheckIn) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
@@ -448,11 +698,34 @@ func (h *Handler) HandleCheckIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Checking someone in fires the event's webhooks, so it is limited to
+	// the event's managers like the attendee list is.
+	event, err := h.Repo.GetEventByID(r.Context(), req.EventID)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !h.canManage(r.Context(), adminUser, event) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You can only check in attendees of events you manage."})
+		return
+	}
+
+	previous, _ := h.Repo.GetRegistrationStatus(r.Context(), req.EventID, req.UserID)
 	if err := h.Repo.MarkAttended(r.Context(), req.EventID, req.UserID); err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
+	if previous == "REGISTERED" {
+		hook := webhooks.RegistrationPayload{EventID: req.EventID, UserID: req.UserID, Status: "ATTENDED"}
+		if attendee, err := h.UserRepo.GetByID(r.Context(), req.UserID); err == nil {
+			hook.UserEmail = attendee.Email
+		}
+		h.emitWebhook(r.Context(), webhooks.CheckinRecorded, req.EventID, hook)
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "User checked in successfully"})
 }
@@ -527,45 +800,19 @@ func (h *Handler) HandleSelfCheckIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.Repo.SelfCheckInUser(r.Context(), input.Email)
+	checkins, err := h.Repo.SelfCheckInUser(r.Context(), input.Email)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Check-in successful"})
-}
-
-func (h *Handler) HandleChat(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Question string `json:"question"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
-	}
-
-	events, err := h.Repo.Search(r.Context(), "", "", "")
-	if err != nil {
-		http.Error(w, "DB Error", http.StatusInternalServerError)
-		return
-	}
-
-	var contextData string
-	for _, e := range events {
-		contextData += fmt.Sprintf("- ID: %d, Title: %s, Time: %s, Location: %s, Category: %s\n",
-			e.ID, e.Title, e.StartTime.Format("Jan 02 15:04"), e.Location, e.Category)
-	}
-
-	answer, err := h.AI.ChatWithData(req.Question, contextData)
-	if err != nil {
-		http.Error(w, "AI Error", http.StatusInternalServerError)
-		return
+	for _, c := range checkins {
+		hook := webhooks.RegistrationPayload{EventID: c.EventID, UserID: c.UserID, UserEmail: input.Email, Status: "ATTENDED"}
+		h.emitWebhook(r.Context(), webhooks.CheckinRecorded, c.EventID, hook)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"answer": answer})
+	json.NewEncoder(w).Encode(map[string]string{"message": "Check-in successful"})
 }
 
 func (h *Handler) HandleAddComment(w http.ResponseWriter, r *http.Request) {
@@ -585,16 +832,35 @@ func (h *Handler) HandleAddComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	verdict, ok := h.screen(w, r, user.ID, req.EventID, moderation.TypeComment, req.Text)
+	if !ok {
+		return
+	}
+
 	comment := &store.Comment{
-		EventID: req.EventID,
-		UserID:  user.ID,
-		Text:    req.Text,
+		EventID:      req.EventID,
+		UserID:       user.ID,
+		Text:         strings.TrimSpace(req.Text),
+		Status:       verdict.Status(),
+		StatusReason: verdict.Reason,
 	}
 
 	if err := h.Repo.AddComment(r.Context(), comment); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.recordVerdict(r.Context(), moderation.TypeComment, comment.ID, req.EventID, user.ID, verdict)
+
+	// Held comments reach the organizer through the moderation queue.
+	if event, err := h.Repo.GetEventByID(r.Context(), req.EventID); err == nil && event.OrganizerID != user.ID && verdict.Decision == moderation.Publish {
+		if organizer, err := h.UserRepo.GetByID(r.Context(), event.OrganizerID); err == nil {
+			rcpt := notifications.Recipient{UserID: organizer.ID, Email: organizer.Email, Locale: organizer.Locale, Timezone: organizer.Timezone}
+			data := notifications.TemplateData{Event: eventVars(event), Note: req.Text}
+			if err := h.Notifications.Notify(r.Context(), nil, rcpt, notifications.TmplCommentAdded, data); err != nil {
+				log.Printf("Failed to notify organizer of comment on event %d: %v", event.ID, err)
+			}
+		}
+	}
 
 	// Return full object with email so UI updates instantly
 	comment.UserEmail = user.Email
@@ -602,6 +868,37 @@ func (h *Handler) HandleAddComment(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(comment)
 }
 
+// screen runs user content through moderation. When it may not be posted
+// it writes the response and returns false: 403 for banned users, 422 for
+// rejected content.
+func (h *Handler) screen(w http.ResponseWriter, r *http.Request, userID, eventID int64, kind, text string) (moderation.Verdict, bool) {
+	verdict, err := h.Moderation.Screen(r.Context(), userID, eventID, kind, text)
+	if errors.Is(err, moderation.ErrBanned) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You are banned from posting on this event."})
+		return verdict, false
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return verdict, false
+	}
+	if verdict.Decision == moderation.Reject {
+		h.recordVerdict(r.Context(), kind, 0, eventID, userID, verdict)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Rejected: " + verdict.Reason})
+		return verdict, false
+	}
+	return verdict, true
+}
+
+func (h *Handler) recordVerdict(ctx context.Context, kind string, contentID, eventID, userID int64, v moderation.Verdict) {
+	if err := h.Moderation.Decided(ctx, kind, contentID, eventID, userID, v); err != nil {
+		log.Printf("Failed to record moderation decision for %s %d: %v", kind, contentID, err)
+	}
+}
+
 func (h *Handler) HandleGetComments(w http.ResponseWriter, r *http.Request) {
 	eventID, _ := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
 
@@ -634,15 +931,23 @@ func (h *Handler) HandleAddPhoto(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	verdict, ok := h.screen(w, r, user.ID, req.EventID, moderation.TypePhoto, req.URL)
+	if !ok {
+		return
+	}
+
 	photo := &store.Photo{
-		EventID: req.EventID,
-		URL:     req.URL,
+		EventID:      req.EventID,
+		URL:          strings.TrimSpace(req.URL),
+		Status:       verdict.Status(),
+		StatusReason: verdict.Reason,
 	}
 
 	if err := h.Repo.AddPhoto(r.Context(), photo, user.ID); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.recordVerdict(r.Context(), moderation.TypePhoto, photo.ID, req.EventID, user.ID, verdict)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(photo)
@@ -0,0 +1,82 @@
+package feedback
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+// reportDeadline replaces the server's WriteTimeout: generating a summary
+// can take longer than an ordinary request.
+const reportDeadline = 2 * time.Minute
+
+type Handler struct {
+	Service   *Service
+	UserRepo  *store.UserRepository
+	EventRepo *store.EventRepository
+	Orgs      *store.OrganizationRepository
+}
+
+// HandleReport returns the feedback report of an event as JSON, or as a PDF
+// download with ?format=pdf. Only those who manage the event (its
+// organizer, officers of its organization and Admins) may see it.
+func (h *Handler) HandleReport(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+
+	eventID, err := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid event_id", http.StatusBadRequest)
+		return
+	}
+	event, err := h.EventRepo.GetEventByID(r.Context(), eventID)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !authz.CanManage(r.Context(), h.Orgs, user, event) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You can only view feedback for events you created."})
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Now().Add(reportDeadline))
+
+	// A summary the cache cannot serve is charged to the viewer's AI quota.
+	rep, err := h.Service.Report(ai.WithSubject(r.Context(), ai.UserSubject(user.ID)), eventID)
+	if errors.Is(err, ErrEventNotFound) {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=feedback_event_%d.pdf", eventID))
+		if err := WritePDF(w, rep); err != nil {
+			log.Printf("Writing feedback PDF for event %d failed: %v", eventID, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rep)
+}
@@ -0,0 +1,81 @@
+package feedback
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// WritePDF renders the report as a printable A4 document.
+func WritePDF(w io.Writer, rep *Report) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	// The core fonts are Latin-1; translate so accents in comments survive.
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Arial", "B", 20)
+	pdf.MultiCell(0, 10, tr("Feedback Report: "+rep.EventTitle), "", "L", false)
+	pdf.Ln(4)
+
+	pdf.SetFont("Arial", "", 12)
+	pdf.CellFormat(0, 7, fmt.Sprintf("Responses: %d    Average rating: %.1f / 5", rep.Count, rep.AverageRating), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	heading(pdf, "Ratings")
+	for stars := 5; stars >= 1; stars-- {
+		n := rep.Ratings[stars]
+		pdf.CellFormat(25, 7, fmt.Sprintf("%d star", stars), "", 0, "L", false, 0, "")
+		if rep.Count > 0 {
+			pdf.SetFillColor(79, 70, 229)
+			pdf.Rect(pdf.GetX(), pdf.GetY()+1.5, 120*float64(n)/float64(rep.Count), 4, "F")
+		}
+		pdf.SetX(pdf.GetX() + 125)
+		pdf.CellFormat(0, 7, fmt.Sprintf("%d", n), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	heading(pdf, "Sentiment")
+	for _, s := range []string{"POSITIVE", "NEUTRAL", "NEGATIVE"} {
+		pdf.CellFormat(0, 7, fmt.Sprintf("%s: %d", s, rep.Sentiments[s]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	heading(pdf, "Summary")
+	switch {
+	case rep.Summary != nil:
+		pdf.MultiCell(0, 6, tr(rep.Summary.Overview), "", "L", false)
+		themes(pdf, tr, "What attendees liked", rep.Summary.Praise)
+		themes(pdf, tr, "What attendees disliked", rep.Summary.Complaints)
+	case rep.SummaryStatus == SummaryNoComments:
+		pdf.MultiCell(0, 6, "No written comments yet.", "", "L", false)
+	default:
+		pdf.MultiCell(0, 6, "The AI summary is not available right now.", "", "L", false)
+	}
+
+	return pdf.Output(w)
+}
+
+func heading(pdf *gofpdf.Fpdf, text string) {
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(0, 9, text, "", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 12)
+}
+
+func themes(pdf *gofpdf.Fpdf, tr func(string) string, title string, list []Theme) {
+	if len(list) == 0 {
+		return
+	}
+	pdf.Ln(3)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(0, 7, title, "", 1, "L", false, 0, "")
+	for _, t := range list {
+		pdf.SetFont("Arial", "", 12)
+		pdf.MultiCell(0, 6, tr("- "+t.Theme), "", "L", false)
+		pdf.SetFont("Arial", "I", 10)
+		for _, q := range t.Quotes {
+			pdf.SetX(pdf.GetX() + 6)
+			pdf.MultiCell(0, 5, tr("\u201c"+q+"\u201d"), "", "L", false)
+		}
+	}
+}
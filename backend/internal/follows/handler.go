@@ -0,0 +1,150 @@
+package follows
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+const feedLimit = 50
+
+type Handler struct {
+	Repo     *store.FollowRepository
+	UserRepo *store.UserRepository
+}
+
+func (h *Handler) currentUser(w http.ResponseWriter, r *http.Request) (*store.User, bool) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return nil, false
+	}
+	return user, true
+}
+
+// target reads which organizer or organization a request is about, from
+// organizer_id or organization_id.
+func target(organizerID, organizationID int64) (string, int64, error) {
+	switch {
+	case organizerID != 0 && organizationID == 0:
+		return store.FollowOrganizer, organizerID, nil
+	case organizationID != 0 && organizerID == 0:
+		return store.FollowOrganization, organizationID, nil
+	}
+	return "", 0, errors.New("give exactly one of organizer_id or organization_id")
+}
+
+// HandleFollow follows an organizer or organization. Users who are not
+// organizers cannot be followed (404).
+func (h *Handler) HandleFollow(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		OrganizerID    int64 `json:"organizer_id"`
+		OrganizationID int64 `json:"organization_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	kind, id, err := target(req.OrganizerID, req.OrganizationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Repo.Follow(r.Context(), user.ID, kind, id); err != nil {
+		if errors.Is(err, store.ErrFollowSelf) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) || strings.Contains(err.Error(), "foreign key") {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) HandleUnfollow(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	organizerID, _ := strconv.ParseInt(r.URL.Query().Get("organizer_id"), 10, 64)
+	organizationID, _ := strconv.ParseInt(r.URL.Query().Get("organization_id"), 10, 64)
+	kind, id, err := target(organizerID, organizationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Repo.Unfollow(r.Context(), user.ID, kind, id); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleListFollowing lists the organizers and organizations the caller
+// follows.
+func (h *Handler) HandleListFollowing(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := h.Repo.Following(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleFeed returns upcoming events from everything the caller follows.
+func (h *Handler) HandleFeed(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := h.Repo.Feed(r.Context(), user.ID, feedLimit)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleOrganizerProfile shows an organizer's follower count and upcoming
+// public events.
+func (h *Handler) HandleOrganizerProfile(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	profile, err := h.Repo.OrganizerProfile(r.Context(), id, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Organizer not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
@@ -0,0 +1,175 @@
+package friends
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+type Handler struct {
+	Repo     *store.FriendRepository
+	UserRepo *store.UserRepository
+}
+
+func (h *Handler) currentUser(w http.ResponseWriter, r *http.Request) (*store.User, bool) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return nil, false
+	}
+	return user, true
+}
+
+// HandleListFriends returns the caller's friends and pending requests.
+func (h *Handler) HandleListFriends(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	lists, err := h.Repo.List(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lists)
+}
+
+// HandleRequest sends a friend request by email or user ID. Asking someone
+// who already asked you accepts their request.
+func (h *Handler) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		UserID int64  `json:"user_id"`
+		Email  string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == 0 {
+		other, err := h.UserRepo.GetByEmail(r.Context(), strings.TrimSpace(req.Email))
+		if err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		req.UserID = other.ID
+	}
+
+	accepted, err := h.Repo.Request(r.Context(), user.ID, req.UserID)
+	if errors.Is(err, store.ErrFriendSelf) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		if strings.Contains(err.Error(), "foreign key") {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	status := "PENDING"
+	if accepted {
+		status = "ACCEPTED"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"user_id": req.UserID, "status": status})
+}
+
+// HandleRespond accepts or declines a request sent to the caller.
+func (h *Handler) HandleRespond(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		UserID int64 `json:"user_id"`
+		Accept bool  `json:"accept"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.Repo.Respond(r.Context(), req.UserID, user.ID, req.Accept); err != nil {
+		if errors.Is(err, store.ErrFriendRequest) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleRemove unfriends a user, or withdraws a request to them.
+func (h *Handler) HandleRemove(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	otherID, _ := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if err := h.Repo.Remove(r.Context(), user.ID, otherID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleFriendsGoing lists the caller's friends registered for an event,
+// leaving out those who hide their attendance.
+func (h *Handler) HandleFriendsGoing(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	eventID, _ := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
+	list, err := h.Repo.Going(r.Context(), eventID, user.ID, user.Email)
+	if errors.Is(err, store.ErrEventNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleShowAttendance shows or hides the caller's registration for one
+// event from friends; null goes back to their default.
+func (h *Handler) HandleShowAttendance(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		EventID       int64 `json:"event_id"`
+		ShowToFriends *bool `json:"show_to_friends"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Repo.ShowAttendance(r.Context(), user.ID, req.EventID, req.ShowToFriends); err != nil {
+		if errors.Is(err, store.ErrNotRegistered) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
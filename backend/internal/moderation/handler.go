@@ -0,0 +1,186 @@
+package moderation
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+type Handler struct {
+	Service  *Service
+	UserRepo *store.UserRepository
+}
+
+func (h *Handler) actor(w http.ResponseWriter, r *http.Request) (Actor, bool) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return Actor{}, false
+	}
+	return Actor{ID: user.ID, Role: user.Role}, true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrForbidden):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: " + err.Error()})
+	default:
+		log.Printf("Moderation error: %v", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// HandleReport lets any signed-in user report a published comment or photo.
+func (h *Handler) HandleReport(w http.ResponseWriter, r *http.Request) {
+	actor, ok := h.actor(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		ContentType string `json:"content_type"`
+		ContentID   int64  `json:"content_id"`
+		Reason      string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Reason) > 500 {
+		req.Reason = req.Reason[:500]
+	}
+	if err := h.Service.Report(r.Context(), actor.ID, req.ContentType, req.ContentID, req.Reason); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"message": "Thanks, a moderator will review it."})
+}
+
+// HandleQueue lists held and reported content on the events the caller
+// moderates. ?event_id= narrows it to one event.
+func (h *Handler) HandleQueue(w http.ResponseWriter, r *http.Request) {
+	actor, ok := h.actor(w, r)
+	if !ok {
+		return
+	}
+	if ok, err := h.Service.IsModerator(r.Context(), actor); err != nil {
+		writeError(w, err)
+		return
+	} else if !ok {
+		writeError(w, ErrForbidden)
+		return
+	}
+	eventID, _ := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
+	items, err := h.Service.Queue(r.Context(), actor, eventID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// HandleAction hides or restores a piece of content.
+func (h *Handler) HandleAction(w http.ResponseWriter, r *http.Request) {
+	actor, ok := h.actor(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		ContentType string `json:"content_type"`
+		ContentID   int64  `json:"content_id"`
+		Action      string `json:"action"` // hide, restore
+		Reason      string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := h.Service.Act(r.Context(), actor, req.ContentType, req.ContentID, req.Action, req.Reason); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Done"})
+}
+
+// HandleBan bans a user from posting on one event, or site-wide when
+// event_id is omitted (Admins only).
+func (h *Handler) HandleBan(w http.ResponseWriter, r *http.Request) {
+	actor, ok := h.actor(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		UserID  int64  `json:"user_id"`
+		EventID int64  `json:"event_id"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := h.Service.Ban(r.Context(), actor, req.UserID, req.EventID, req.Reason); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "User banned"})
+}
+
+// HandleUnban lifts a ban: DELETE /moderation/bans?user_id=&event_id=.
+func (h *Handler) HandleUnban(w http.ResponseWriter, r *http.Request) {
+	actor, ok := h.actor(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	userID, err := strconv.ParseInt(q.Get("user_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user_id", http.StatusBadRequest)
+		return
+	}
+	eventID, _ := strconv.ParseInt(q.Get("event_id"), 10, 64)
+	if err := h.Service.Unban(r.Context(), actor, userID, eventID, q.Get("reason")); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Ban lifted"})
+}
+
+// HandleAudit returns the audit trail the caller may see.
+func (h *Handler) HandleAudit(w http.ResponseWriter, r *http.Request) {
+	actor, ok := h.actor(w, r)
+	if !ok {
+		return
+	}
+	if ok, err := h.Service.IsModerator(r.Context(), actor); err != nil {
+		writeError(w, err)
+		return
+	} else if !ok {
+		writeError(w, ErrForbidden)
+		return
+	}
+	eventID, _ := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
+	list, err := h.Service.Audit(r.Context(), actor, eventID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
@@ -0,0 +1,165 @@
+package moderation
+
+import (
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Content types.
+const (
+	TypeComment  = "comment"
+	TypePhoto    = "photo"
+	TypeFeedback = "feedback"
+)
+
+// Statuses stored on content. Rejected content is not stored.
+const (
+	StatusPublished = "PUBLISHED"
+	StatusPending   = "PENDING"
+	StatusHidden    = "HIDDEN"
+)
+
+// Decisions.
+const (
+	Publish = "publish"
+	Hold    = "hold"
+	Reject  = "reject"
+)
+
+const (
+	maxTextLen = 2000
+	maxURLLen  = 2048
+)
+
+// Verdict is the outcome of screening one piece of content.
+type Verdict struct {
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Status is the status content with this verdict is stored with.
+func (v Verdict) Status() string {
+	if v.Decision == Hold {
+		return StatusPending
+	}
+	return StatusPublished
+}
+
+// Rule matches text that should be rejected or held for review.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Rules is the local rule set. Reject rules are checked first.
+type Rules struct {
+	Reject []Rule
+	Hold   []Rule
+}
+
+// DefaultRules rejects threats and obvious spam, and holds profanity, links
+// and contact details for review.
+func DefaultRules() *Rules {
+	return &Rules{
+		Reject: []Rule{
+			{"threat", regexp.MustCompile(`(?i)\b(i'?ll|i will|gonna|going to)\s+(kill|hurt|shoot|stab)\s+(you|u|him|her|them)\b`)},
+			{"threat", regexp.MustCompile(`(?i)\b(kys|kill\s+yourself)\b`)},
+			{"spam", regexp.MustCompile(`(?i)\b(crypto|bitcoin|nft)\s+(giveaway|airdrop)s?\b`)},
+			{"spam", regexp.MustCompile(`(?i)\bfree\s+(money|followers|gift\s*cards?)\b`)},
+		},
+		Hold: []Rule{
+			{"profanity", regexp.MustCompile(`(?i)\b(fuck(s|ing|er|ed)?|shit(ty|s)?|bitch(es)?|assholes?|bastards?|cunts?)\b`)},
+			{"link", regexp.MustCompile(`(?i)(https?://|\bwww\.)`)},
+			{"contact details", regexp.MustCompile(`(?i)[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}|\+?\d[\d\s().-]{8,}\d`)},
+		},
+	}
+}
+
+// NewRulesFromEnv extends DefaultRules with the comma-separated terms in
+// MODERATION_BLOCKED_TERMS (rejected) and MODERATION_REVIEW_TERMS (held).
+func NewRulesFromEnv() *Rules {
+	r := DefaultRules()
+	if re := termsPattern(os.Getenv("MODERATION_BLOCKED_TERMS")); re != nil {
+		r.Reject = append(r.Reject, Rule{"blocked term", re})
+	}
+	if re := termsPattern(os.Getenv("MODERATION_REVIEW_TERMS")); re != nil {
+		r.Hold = append(r.Hold, Rule{"review term", re})
+	}
+	return r
+}
+
+func termsPattern(list string) *regexp.Regexp {
+	var terms []string
+	for _, t := range strings.Split(list, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			terms = append(terms, regexp.QuoteMeta(t))
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)\b(` + strings.Join(terms, "|") + `)\b`)
+}
+
+// Check screens text (or, for photos, the URL) against the rules.
+func (r *Rules) Check(kind, text string) Verdict {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Verdict{Reject, "empty"}
+	}
+
+	if kind == TypePhoto {
+		if len(text) > maxURLLen {
+			return Verdict{Reject, "URL too long"}
+		}
+		u, err := url.Parse(text)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Verdict{Reject, "not an http(s) URL"}
+		}
+		// A URL is all links; only the reject rules apply.
+		if rule := match(r.Reject, text); rule != "" {
+			return Verdict{Reject, rule}
+		}
+		return Verdict{Decision: Publish}
+	}
+
+	if len(text) > maxTextLen {
+		return Verdict{Reject, "too long"}
+	}
+	if rule := match(r.Reject, text); rule != "" {
+		return Verdict{Reject, rule}
+	}
+	if rule := match(r.Hold, text); rule != "" {
+		return Verdict{Hold, rule}
+	}
+	if shouting(text) {
+		return Verdict{Hold, "shouting"}
+	}
+	return Verdict{Decision: Publish}
+}
+
+func match(rules []Rule, text string) string {
+	for _, rule := range rules {
+		if rule.Pattern.MatchString(text) {
+			return rule.Name
+		}
+	}
+	return ""
+}
+
+// shouting is text of some length written mostly in capitals.
+func shouting(text string) bool {
+	letters, upper := 0, 0
+	for _, c := range text {
+		if unicode.IsLetter(c) {
+			letters++
+			if unicode.IsUpper(c) {
+				upper++
+			}
+		}
+	}
+	return letters >= 20 && upper*10 >= letters*8
+}
@@ -0,0 +1,483 @@
+package moderation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrBanned    = errors.New("you are banned from posting here")
+	ErrNotFound  = errors.New("content not found")
+	ErrForbidden = errors.New("not allowed to moderate this content")
+	ErrInvalid   = errors.New("invalid moderation request")
+)
+
+// Audit actions.
+const (
+	ActionHeld     = "held"
+	ActionRejected = "rejected"
+	ActionReported = "reported"
+	ActionHide     = "hide"
+	ActionRestore  = "restore"
+	ActionBan      = "ban"
+	ActionUnban    = "unban"
+)
+
+// tables maps content types to their table and author column.
+var tables = map[string]struct{ table, author string }{
+	TypeComment:  {"comments", "user_id"},
+	TypePhoto:    {"event_photos", "uploaded_by"},
+	TypeFeedback: {"event_feedback", "user_id"},
+}
+
+// Actor is the user taking a moderation action.
+type Actor struct {
+	ID   int64
+	Role string
+}
+
+// Action is one entry of the audit trail. ActorID is nil for automatic
+// decisions and ContentID is nil for rejected content, which is not stored.
+type Action struct {
+	ID          int64     `json:"id"`
+	ActorID     *int64    `json:"actor_id"`
+	Action      string    `json:"action"`
+	ContentType string    `json:"content_type,omitempty"`
+	ContentID   *int64    `json:"content_id"`
+	UserID      *int64    `json:"user_id"`
+	EventID     *int64    `json:"event_id"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Item is content waiting in the moderation queue: held content and
+// published content with open reports.
+type Item struct {
+	ContentType   string    `json:"content_type"`
+	ContentID     int64     `json:"content_id"`
+	EventID       int64     `json:"event_id"`
+	EventTitle    string    `json:"event_title"`
+	UserID        int64     `json:"user_id"`
+	UserEmail     string    `json:"user_email"`
+	Content       string    `json:"content"`
+	Status        string    `json:"status"`
+	Reason        string    `json:"reason"`
+	Reports       int       `json:"reports"`
+	ReportReasons []string  `json:"report_reasons"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type Service struct {
+	DB    *sql.DB
+	Rules *Rules
+	// AI, when set and available, holds text the rules let through but the
+	// model flags as abusive. Failures fall back to the rules' verdict.
+	AI *ai.Service
+	// ReportThreshold is the number of open reports that takes published
+	// content down for review. 0 disables it.
+	ReportThreshold int
+}
+
+// NewServiceFromEnv uses NewRulesFromEnv, enables AI classification when
+// MODERATION_AI=true and reads MODERATION_REPORT_THRESHOLD (default 3).
+func NewServiceFromEnv(db *sql.DB, aiService *ai.Service) *Service {
+	s := &Service{DB: db, Rules: NewRulesFromEnv(), ReportThreshold: 3}
+	if os.Getenv("MODERATION_AI") == "true" {
+		s.AI = aiService
+	}
+	if v := os.Getenv("MODERATION_REPORT_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			s.ReportThreshold = n
+		} else {
+			log.Printf("⚠️ Warning: invalid MODERATION_REPORT_THRESHOLD %q, using %d", v, s.ReportThreshold)
+		}
+	}
+	return s
+}
+
+// Screen decides whether userID may post text on eventID and how it is
+// published. A nil Service applies the default rules only.
+func (s *Service) Screen(ctx context.Context, userID, eventID int64, kind, text string) (Verdict, error) {
+	if s == nil {
+		return DefaultRules().Check(kind, text), nil
+	}
+	banned, err := s.Banned(ctx, userID, eventID)
+	if err != nil {
+		return Verdict{}, err
+	}
+	if banned {
+		return Verdict{}, ErrBanned
+	}
+
+	rules := s.Rules
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	v := rules.Check(kind, text)
+	if v.Decision != Publish || kind == TypePhoto || !s.AI.Available() {
+		return v, nil
+	}
+	// Screening is charged to the poster, so flooding comments cannot spend
+	// the AI budget of others.
+	label, err := s.AI.ClassifyContent(ai.WithSubject(ctx, ai.UserSubject(userID)), text)
+	if err != nil {
+		log.Printf("Moderation: AI classification failed, using rules: %v", err)
+		return v, nil
+	}
+	if label == ai.ContentAbusive {
+		return Verdict{Hold, "flagged by AI"}, nil
+	}
+	return v, nil
+}
+
+// Decided records an automatic hold or rejection in the audit trail.
+// contentID is 0 for rejected content.
+func (s *Service) Decided(ctx context.Context, kind string, contentID, eventID, userID int64, v Verdict) error {
+	if s == nil || v.Decision == Publish {
+		return nil
+	}
+	a := &Action{Action: ActionHeld, ContentType: kind, UserID: &userID, EventID: &eventID, Reason: v.Reason}
+	if v.Decision == Reject {
+		a.Action = ActionRejected
+	} else {
+		a.ContentID = &contentID
+	}
+	return record(ctx, s.DB, a)
+}
+
+// Banned reports whether userID is banned site-wide or from eventID.
+func (s *Service) Banned(ctx context.Context, userID, eventID int64) (bool, error) {
+	var banned bool
+	err := s.DB.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM moderation_bans WHERE user_id=$1 AND (event_id IS NULL OR event_id=$2))",
+		userID, eventID).Scan(&banned)
+	return banned, err
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func record(ctx context.Context, db execer, a *Action) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO moderation_actions (actor_id, action, content_type, content_id, user_id, event_id, reason)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+	`, a.ActorID, a.Action, a.ContentType, a.ContentID, a.UserID, a.EventID, a.Reason)
+	return err
+}
+
+// lookup returns the event, author and status of a piece of content.
+func (s *Service) lookup(ctx context.Context, kind string, id int64) (eventID, authorID int64, status string, err error) {
+	t, ok := tables[kind]
+	if !ok {
+		return 0, 0, "", ErrInvalid
+	}
+	err = s.DB.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT event_id, %s, moderation_status FROM %s WHERE id=$1", t.author, t.table),
+		id).Scan(&eventID, &authorID, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrNotFound
+	}
+	return
+}
+
+// CanModerate reports whether actor moderates eventID: those with
+// ModerationAny moderate everything; organizers, and owners and officers of
+// the event's organization, moderate that event (as in authz.CanManage).
+func (s *Service) CanModerate(ctx context.Context, actor Actor, eventID int64) (bool, error) {
+	if authz.Can(actor.Role, authz.ModerationAny) {
+		return true, nil
+	}
+	var ok bool
+	err := s.DB.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM events e WHERE e.id=$1 AND "+store.ManagesEventSQL("$2")+")", eventID, actor.ID).Scan(&ok)
+	return ok, err
+}
+
+// IsModerator reports whether actor moderates any event at all: with
+// ModerationReview, or as an owner or officer of an organization, who are
+// often plain Members on the site.
+func (s *Service) IsModerator(ctx context.Context, actor Actor) (bool, error) {
+	if authz.Can(actor.Role, authz.ModerationReview) {
+		return true, nil
+	}
+	var ok bool
+	err := s.DB.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM organization_members WHERE user_id=$1 AND role IN ($2, $3))",
+		actor.ID, store.OrgOwner, store.OrgOfficer).Scan(&ok)
+	return ok, err
+}
+
+// Report records that reporterID reported published content. Once
+// ReportThreshold users have reported it, the content is held for review.
+func (s *Service) Report(ctx context.Context, reporterID int64, kind string, id int64, reason string) error {
+	if kind != TypeComment && kind != TypePhoto {
+		return ErrInvalid
+	}
+	eventID, authorID, status, err := s.lookup(ctx, kind, id)
+	if err != nil {
+		return err
+	}
+	if status != StatusPublished {
+		return ErrNotFound
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO content_reports (content_type, content_id, reporter_id, reason) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (content_type, content_id, reporter_id) WHERE resolved_at IS NULL DO NOTHING
+	`, kind, id, reporterID, reason)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return tx.Commit() // already reported
+	}
+	if err := record(ctx, tx, &Action{ActorID: &reporterID, Action: ActionReported, ContentType: kind, ContentID: &id, UserID: &authorID, EventID: &eventID, Reason: reason}); err != nil {
+		return err
+	}
+
+	if s.ReportThreshold > 0 {
+		var open int
+		if err := tx.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM content_reports WHERE content_type=$1 AND content_id=$2 AND resolved_at IS NULL",
+			kind, id).Scan(&open); err != nil {
+			return err
+		}
+		if open >= s.ReportThreshold {
+			reason := fmt.Sprintf("reported by %d users", open)
+			if err := setStatus(ctx, tx, kind, id, StatusPending, reason); err != nil {
+				return err
+			}
+			if err := record(ctx, tx, &Action{Action: ActionHeld, ContentType: kind, ContentID: &id, UserID: &authorID, EventID: &eventID, Reason: reason}); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func setStatus(ctx context.Context, db execer, kind string, id int64, status, reason string) error {
+	t := tables[kind]
+	_, err := db.ExecContext(ctx,
+		fmt.Sprintf("UPDATE %s SET moderation_status=$2, moderation_reason=NULLIF($3, '') WHERE id=$1", t.table),
+		id, status, reason)
+	return err
+}
+
+// Queue lists content waiting for actor: held content and content with open
+// reports, oldest first. eventID 0 means every event actor moderates.
+func (s *Service) Queue(ctx context.Context, actor Actor, eventID int64) ([]*Item, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT x.kind, x.id, x.event_id, e.title, x.author, u.email, x.content, x.moderation_status,
+		       COALESCE(x.moderation_reason, ''), r.reports, COALESCE(r.reasons, '{}'), x.created_at
+		FROM (
+			SELECT 'comment' AS kind, id::bigint, event_id, user_id AS author, text AS content, moderation_status, moderation_reason, created_at FROM comments
+			UNION ALL
+			SELECT 'photo', id::bigint, event_id, uploaded_by, url, moderation_status, moderation_reason, created_at FROM event_photos
+			UNION ALL
+			SELECT 'feedback', id::bigint, event_id, user_id, COALESCE(comment, ''), moderation_status, moderation_reason, created_at FROM event_feedback
+		) x
+		JOIN events e ON e.id = x.event_id
+		JOIN users u ON u.id = x.author
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS reports, ARRAY_AGG(cr.reason) FILTER (WHERE cr.reason <> '') AS reasons
+			FROM content_reports cr
+			WHERE cr.content_type = x.kind AND cr.content_id = x.id AND cr.resolved_at IS NULL
+		) r
+		WHERE (x.moderation_status = 'PENDING' OR (x.moderation_status = 'PUBLISHED' AND r.reports > 0))
+		  AND ($1 OR `+store.ManagesEventSQL("$2")+`)
+		  AND ($3 = 0 OR x.event_id = $3)
+		ORDER BY x.created_at ASC
+		LIMIT 200
+	`, authz.Can(actor.Role, authz.ModerationAny), actor.ID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*Item{}
+	for rows.Next() {
+		var it Item
+		var createdAt sql.NullTime
+		if err := rows.Scan(&it.ContentType, &it.ContentID, &it.EventID, &it.EventTitle, &it.UserID, &it.UserEmail,
+			&it.Content, &it.Status, &it.Reason, &it.Reports, pq.Array(&it.ReportReasons), &createdAt); err != nil {
+			return nil, err
+		}
+		if createdAt.Valid {
+			it.CreatedAt = createdAt.Time
+		}
+		items = append(items, &it)
+	}
+	return items, rows.Err()
+}
+
+// Act hides content or restores it. Either way its open reports are
+// resolved, so restoring reported content also dismisses the reports.
+func (s *Service) Act(ctx context.Context, actor Actor, kind string, id int64, action, reason string) error {
+	status := map[string]string{ActionHide: StatusHidden, ActionRestore: StatusPublished}[action]
+	if status == "" {
+		return ErrInvalid
+	}
+	eventID, authorID, _, err := s.lookup(ctx, kind, id)
+	if err != nil {
+		return err
+	}
+	if ok, err := s.CanModerate(ctx, actor, eventID); err != nil {
+		return err
+	} else if !ok {
+		return ErrForbidden
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setStatus(ctx, tx, kind, id, status, reason); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE content_reports SET resolved_at=NOW() WHERE content_type=$1 AND content_id=$2 AND resolved_at IS NULL",
+		kind, id); err != nil {
+		return err
+	}
+	if err := record(ctx, tx, &Action{ActorID: &actor.ID, Action: action, ContentType: kind, ContentID: &id, UserID: &authorID, EventID: &eventID, Reason: reason}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Ban stops userID posting on eventID, or anywhere when eventID is 0.
+// Site-wide bans are for Admins; organizers may ban from their own events.
+// Admins cannot be banned.
+func (s *Service) Ban(ctx context.Context, actor Actor, userID, eventID int64, reason string) error {
+	if err := s.checkBan(ctx, actor, userID, eventID); err != nil {
+		return err
+	}
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO moderation_bans (user_id, event_id, reason, created_by) VALUES ($1, NULLIF($2, 0), $3, $4)
+		ON CONFLICT (user_id, COALESCE(event_id, 0)) DO UPDATE SET reason = EXCLUDED.reason, created_by = EXCLUDED.created_by
+	`, userID, eventID, reason, actor.ID); err != nil {
+		return err
+	}
+	if err := record(ctx, tx, banAction(actor, ActionBan, userID, eventID, reason)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Unban lifts a ban made with the same scope.
+func (s *Service) Unban(ctx context.Context, actor Actor, userID, eventID int64, reason string) error {
+	if err := s.checkBan(ctx, actor, userID, eventID); err != nil {
+		return err
+	}
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"DELETE FROM moderation_bans WHERE user_id=$1 AND COALESCE(event_id, 0)=$2", userID, eventID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	if err := record(ctx, tx, banAction(actor, ActionUnban, userID, eventID, reason)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Service) checkBan(ctx context.Context, actor Actor, userID, eventID int64) error {
+	if userID == actor.ID {
+		return ErrInvalid
+	}
+	var role string
+	err := s.DB.QueryRowContext(ctx, "SELECT role FROM users WHERE id=$1", userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if authz.Can(role, authz.ModerationAny) {
+		return ErrForbidden
+	}
+	if eventID == 0 {
+		if !authz.Can(actor.Role, authz.ModerationAny) {
+			return ErrForbidden
+		}
+		return nil
+	}
+	ok, err := s.CanModerate(ctx, actor, eventID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func banAction(actor Actor, action string, userID, eventID int64, reason string) *Action {
+	a := &Action{ActorID: &actor.ID, Action: action, UserID: &userID, Reason: reason}
+	if eventID != 0 {
+		a.EventID = &eventID
+	}
+	return a
+}
+
+// Audit returns the newest audit entries actor may see: everything with
+// ModerationAny, otherwise entries about the events they moderate (see
+// CanModerate).
+func (s *Service) Audit(ctx context.Context, actor Actor, eventID int64) ([]*Action, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT a.id, a.actor_id, a.action, COALESCE(a.content_type, ''), a.content_id, a.user_id, a.event_id, a.reason, a.created_at
+		FROM moderation_actions a
+		LEFT JOIN events e ON e.id = a.event_id
+		WHERE ($1 OR `+store.ManagesEventSQL("$2")+`)
+		  AND ($3 = 0 OR a.event_id = $3)
+		ORDER BY a.id DESC
+		LIMIT 200
+	`, authz.Can(actor.Role, authz.ModerationAny), actor.ID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*Action{}
+	for rows.Next() {
+		var a Action
+		if err := rows.Scan(&a.ID, &a.ActorID, &a.Action, &a.ContentType, &a.ContentID, &a.UserID, &a.EventID, &a.Reason, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &a)
+	}
+	return list, rows.Err()
+}
@@ -2,8 +2,14 @@ package notifications
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
@@ -12,8 +18,13 @@ import (
 type Handler struct {
 	Repo     *store.EventRepository
 	UserRepo *store.UserRepository
+	Service  *Service
+	Stream   *Stream
 }
 
+// HandleListNotifications returns one page of the inbox as an array, newest
+// first. When more pages exist, the X-Next-Cursor header holds the value to
+// pass as ?cursor= for the next one.
 func (h *Handler) HandleListNotifications(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
 	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
@@ -22,16 +33,68 @@ func (h *Handler) HandleListNotifications(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	notes, err := h.Repo.GetNotifications(r.Context(), user.ID)
+	q := r.URL.Query()
+	filter := store.NotificationFilter{
+		Category: q.Get("category"),
+		Status:   q.Get("status"),
+	}
+	if filter.Status != "" && filter.Status != "unread" && filter.Status != "archived" {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("cursor"); v != "" {
+		if filter.Cursor, err = strconv.ParseInt(v, 10, 64); err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	notes, next, err := h.Repo.ListNotifications(r.Context(), user.ID, filter)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
+	if next != 0 {
+		w.Header().Set("X-Next-Cursor", strconv.FormatInt(next, 10))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(notes)
 }
 
+func (h *Handler) HandleUnreadCount(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+
+	counts, err := h.Repo.UnreadNotificationCounts(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total":       total,
+		"by_category": counts,
+	})
+}
+
+// HandleMarkRead marks every notification read, or only one category with
+// ?category=.
 func (h *Handler) HandleMarkRead(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
 	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
@@ -40,6 +103,404 @@ func (h *Handler) HandleMarkRead(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.Repo.MarkNotificationsRead(r.Context(), user.ID)
+	if err := h.Repo.MarkNotificationsRead(r.Context(), user.ID, r.URL.Query().Get("category")); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// HandleUpdateNotification changes a single notification. Only the fields
+// present in the body are applied.
+func (h *Handler) HandleUpdateNotification(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		ID       int64 `json:"id"`
+		Read     *bool `json:"read"`
+		Archived *bool `json:"archived"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == 0 {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Archived != nil {
+		err = h.Repo.SetNotificationArchived(r.Context(), user.ID, req.ID, *req.Archived)
+	}
+	if err == nil && req.Read != nil {
+		err = h.Repo.SetNotificationRead(r.Context(), user.ID, req.ID, *req.Read)
+	}
+	if errors.Is(err, store.ErrNotificationNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Notification updated"})
+}
+
+func (h *Handler) HandleDeleteNotification(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	err = h.Repo.DeleteNotification(r.Context(), user.ID, id)
+	if errors.Is(err, store.ErrNotificationNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleStream pushes the user's new notifications and registration status
+// changes as Server-Sent Events. Each event carries its stream_events id, so
+// a client reconnecting with Last-Event-ID gets everything it missed (within
+// StreamReplayWindow). A fresh connection starts from now.
+func (h *Handler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var after int64
+	if lastID != "" {
+		if after, err = strconv.ParseInt(lastID, 10, 64); err != nil {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	} else if after, err = h.Repo.LatestStreamEventID(r.Context(), user.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before the first read so nothing published in between is missed.
+	wakeups, cancel := h.Stream.Subscribe(user.ID)
+	defer cancel()
+
+	// The server's WriteTimeout would otherwise cut the stream off.
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	keepalive := time.NewTicker(25 * time.Second)
+	defer keepalive.Stop()
+
+	// Rows that commit late, below ids already sent, are found by looking
+	// back store.StreamLag. sent remembers what went out in that window so
+	// nothing is sent twice. On reconnect the client is assumed to have
+	// everything up to Last-Event-ID; a late row that committed while it
+	// was away is the one case that can be missed.
+	sent := map[int64]time.Time{}
+	if after > 0 {
+		recent, err := h.Repo.GetLateStreamEvents(r.Context(), user.ID, after)
+		if err != nil {
+			return
+		}
+		for _, e := range recent {
+			sent[e.ID] = e.CreatedAt
+		}
+	}
+	write := func(e *store.StreamEvent) {
+		// The id is the resume point, so a late row never moves it back.
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", max(e.ID, after), e.Type, e.Payload)
+		sent[e.ID] = e.CreatedAt
+	}
+
+	for {
+		late, err := h.Repo.GetLateStreamEvents(r.Context(), user.ID, after)
+		if err != nil {
+			return
+		}
+		for _, e := range late {
+			if _, ok := sent[e.ID]; !ok {
+				write(e)
+			}
+		}
+		for {
+			events, err := h.Repo.GetStreamEventsAfter(r.Context(), user.ID, after, 100)
+			if err != nil {
+				return
+			}
+			for _, e := range events {
+				write(e)
+				after = e.ID
+			}
+			if len(events) < 100 {
+				break
+			}
+		}
+		for id, created := range sent {
+			if time.Since(created) > 2*store.StreamLag {
+				delete(sent, id)
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-wakeups:
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		}
+	}
+}
+
+func (h *Handler) HandleGetPreferences(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+
+	prefs, err := h.Service.GetPreferences(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// HandleSavePreferences accepts a list of per-category preferences;
+// categories that are left out keep their current setting.
+func (h *Handler) HandleSavePreferences(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+
+	var prefs []Preference
+	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.SavePreferences(r.Context(), user.ID, prefs); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Preferences saved"})
+}
+
+// HandleUnsubscribe is public: the signed token is the credential. GET
+// shows a confirmation button (so link scanners cannot unsubscribe anyone);
+// POST, which is also what mail clients send for one-click unsubscribe,
+// turns the email off.
+func (h *Handler) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	userID, category, err := h.Service.ParseUnsubscribeToken(token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == http.MethodGet {
+		fmt.Fprintf(w, `<html><body><form method="POST" action="?token=%s"><p>Stop receiving these emails from CampusSync?</p><button type="submit">Unsubscribe</button></form></body></html>`,
+			url.QueryEscape(token))
+		return
+	}
+
+	if err := h.Service.DisableEmail(r.Context(), userID, category); err != nil {
+		http.Error(w, "Could not unsubscribe", http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(w, `<html><body><p>You have been unsubscribed. You can change this any time under notification preferences.</p></body></html>`)
+}
+
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) (*store.User, bool) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return nil, false
+	}
+	if !authz.Can(user.Role, authz.NotificationsManage) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil, false
+	}
+	return user, true
+}
+
+// HandleListOutbox shows queued email by status. It defaults to the
+// dead-letter view (DEAD): messages that exhausted their retries.
+func (h *Handler) HandleListOutbox(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "DEAD"
+	}
+	if status != "PENDING" && status != "SENT" && status != "DEAD" {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	msgs, err := h.Repo.ListOutbox(r.Context(), status)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(msgs)
+}
+
+func (h *Handler) HandleRetryOutbox(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Repo.RequeueOutboxMessage(r.Context(), req.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Message re-queued"})
+}
+
+func (h *Handler) HandleListTemplates(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	templates, err := h.Service.ListTemplates(r.Context())
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"locales":   Locales,
+		"templates": templates,
+	})
+}
+
+func (h *Handler) HandleSaveTemplate(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var t Template
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.SaveTemplate(r.Context(), t, admin.ID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Template saved"})
+}
+
+func (h *Handler) HandleResetTemplate(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	locale := r.URL.Query().Get("locale")
+	if err := h.Service.ResetTemplate(r.Context(), name, locale); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Template reset to default"})
+}
+
+// HandlePreviewTemplate renders an unsaved template against sample data so
+// admins can check it before saving.
+func (h *Handler) HandlePreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	var req struct {
+		Template
+		Timezone string `json:"timezone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		http.Error(w, "Invalid timezone", http.StatusBadRequest)
+		return
+	}
+
+	rendered, err := RenderTemplate(req.Template, SampleData(), loc)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rendered)
+}
@@ -0,0 +1,183 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Notification categories users can configure independently.
+const (
+	CategoryRegistration  = "registration"
+	CategoryWaitlist      = "waitlist"
+	CategoryEventChanges  = "event_changes"
+	CategoryComments      = "comments"
+	CategoryReminders     = "reminders"
+	CategoryAnnouncements = "announcements"
+	CategoryFollowing     = "following"
+)
+
+var Categories = []string{
+	CategoryRegistration,
+	CategoryWaitlist,
+	CategoryEventChanges,
+	CategoryComments,
+	CategoryReminders,
+	CategoryAnnouncements,
+	CategoryFollowing,
+}
+
+// Email delivery modes.
+const (
+	EmailInstant = "instant"
+	EmailDaily   = "daily"
+	EmailWeekly  = "weekly"
+	EmailOff     = "off"
+)
+
+// Preference is a user's delivery choice for one category.
+type Preference struct {
+	Category string `json:"category"`
+	InApp    bool   `json:"in_app"`
+	Email    string `json:"email"`
+}
+
+// templateCategories maps each template to the category whose preferences
+// govern it.
+var templateCategories = map[string]string{
+	TmplRegistrationConfirmed: CategoryRegistration,
+	TmplApplicationReceived:   CategoryRegistration,
+	TmplApplicationApproved:   CategoryRegistration,
+	TmplApplicationRejected:   CategoryRegistration,
+	TmplRegistrationWaitlist:  CategoryWaitlist,
+	TmplWaitlistPromoted:      CategoryWaitlist,
+	TmplApplicationWaitlisted: CategoryWaitlist,
+	TmplEventUpdated:          CategoryEventChanges,
+	TmplEventCancelled:        CategoryEventChanges,
+	TmplEventInvite:           CategoryAnnouncements,
+	TmplEventReminder:         CategoryReminders,
+	TmplCommentAdded:          CategoryComments,
+	TmplAnnouncement:          CategoryAnnouncements,
+	TmplEventPublished:        CategoryFollowing,
+}
+
+// DefaultPreference is used when the user has not saved a choice.
+// Event edits and new events from followed organizers are batched into the
+// daily digest and comments stay in-app, so frequent small changes do not
+// flood inboxes.
+func DefaultPreference(category string) Preference {
+	p := Preference{Category: category, InApp: true, Email: EmailInstant}
+	switch category {
+	case CategoryEventChanges, CategoryFollowing:
+		p.Email = EmailDaily
+	case CategoryComments:
+		p.Email = EmailOff
+	}
+	return p
+}
+
+func isCategory(c string) bool {
+	for _, known := range Categories {
+		if known == c {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPreferences returns the effective preference for every category.
+func (s *Service) GetPreferences(ctx context.Context, userID int64) ([]Preference, error) {
+	saved := map[string]Preference{}
+	rows, err := s.DB.QueryContext(ctx, "SELECT category, in_app, email FROM notification_preferences WHERE user_id=$1", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p Preference
+		if err := rows.Scan(&p.Category, &p.InApp, &p.Email); err != nil {
+			return nil, err
+		}
+		saved[p.Category] = p
+	}
+
+	var prefs []Preference
+	for _, c := range Categories {
+		if p, ok := saved[c]; ok {
+			prefs = append(prefs, p)
+		} else {
+			prefs = append(prefs, DefaultPreference(c))
+		}
+	}
+	return prefs, nil
+}
+
+func (s *Service) preferenceFor(ctx context.Context, userID int64, category string) (Preference, error) {
+	p := DefaultPreference(category)
+	if s.DB == nil {
+		return p, nil
+	}
+	err := s.DB.QueryRowContext(ctx,
+		"SELECT in_app, email FROM notification_preferences WHERE user_id=$1 AND category=$2",
+		userID, category).Scan(&p.InApp, &p.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return p, err
+	}
+	return p, nil
+}
+
+// SavePreferences validates and stores a set of preferences.
+func (s *Service) SavePreferences(ctx context.Context, userID int64, prefs []Preference) error {
+	for _, p := range prefs {
+		if !isCategory(p.Category) {
+			return errors.New("unknown category: " + p.Category)
+		}
+		switch p.Email {
+		case EmailInstant, EmailDaily, EmailWeekly, EmailOff:
+		default:
+			return errors.New("invalid email mode (must be instant, daily, weekly or off)")
+		}
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, p := range prefs {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO notification_preferences (user_id, category, in_app, email)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, category) DO UPDATE SET in_app = EXCLUDED.in_app, email = EXCLUDED.email
+		`, userID, p.Category, p.InApp, p.Email)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DisableEmail turns email off for one category, or for every category
+// when category is "all". It backs the one-click unsubscribe link.
+func (s *Service) DisableEmail(ctx context.Context, userID int64, category string) error {
+	categories := []string{category}
+	if category == "all" {
+		categories = Categories
+	} else if !isCategory(category) {
+		return errors.New("unknown category: " + category)
+	}
+
+	for _, c := range categories {
+		def := DefaultPreference(c)
+		_, err := s.DB.ExecContext(ctx, `
+			INSERT INTO notification_preferences (user_id, category, in_app, email)
+			VALUES ($1, $2, $3, 'off')
+			ON CONFLICT (user_id, category) DO UPDATE SET email = 'off'
+		`, userID, c, def.InApp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
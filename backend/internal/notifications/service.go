@@ -1,33 +1,53 @@
 package notifications
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"errors"
 	"time"
 )
 
-type Service struct {
-}
-
-func NewService() *Service {
-	return &Service{}
+// Execer is satisfied by both *sql.DB and *sql.Tx, so emails can be queued
+// inside the same transaction as the change that triggered them.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
-func (s *Service) SendRegistrationEmail(toEmail, eventTitle string) {
-	go func() {
-		time.Sleep(2 * time.Second)
+// Service queues outgoing email in the email_outbox table. Nothing is sent
+// here: the background EmailDispatcher delivers queued messages through a
+// Transport, so messages survive restarts and failed sends are retried.
+type Service struct {
+	DB *sql.DB
+	// UnsubscribeTTL is how long unsubscribe links stay valid.
+	UnsubscribeTTL time.Duration
 
-		log.Printf(" [EMAIL SENT] To: %s | Subject: Registration Confirmed! | Body: You are going to %s!", toEmail, eventTitle)
-	}()
+	unsubscribeSecret []byte
+	baseURL           string
 }
 
-func (s *Service) SendWaitlistEmail(toEmail, eventTitle string) {
-	go func() {
-		time.Sleep(2 * time.Second)
+func NewService(db *sql.DB) *Service {
+	secret, base := unsubscribeConfig()
+	return &Service{DB: db, UnsubscribeTTL: DefaultUnsubscribeTTL, unsubscribeSecret: secret, baseURL: base}
+}
 
-		log.Printf(" [EMAIL SENT] To: %s | Subject: Added to Waitlist | Body: You are on the waitlist for %s.", toEmail, eventTitle)
-	}()
+// Enqueue writes a message to the outbox. If tx is nil the message is
+// written outside of any transaction.
+func (s *Service) Enqueue(ctx context.Context, tx Execer, msg Message) error {
+	_, err := s.enqueue(ctx, tx, msg)
+	return err
 }
 
-func (s *Service) SendInviteEmail(toEmail, eventTitle string) {
-	log.Printf(" [EMAIL SENT] To: %s | Subject: You're Invited! | Body: You have been invited to join '%s'. Log in to CampusSync to register.", toEmail, eventTitle)
+func (s *Service) enqueue(ctx context.Context, tx Execer, msg Message) (int64, error) {
+	if tx == nil {
+		if s.DB == nil {
+			return 0, errors.New("notifications: no database configured")
+		}
+		tx = s.DB
+	}
+	var id int64
+	err := tx.QueryRowContext(ctx,
+		"INSERT INTO email_outbox (to_email, subject, body, html_body, unsubscribe_url) VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, '')) RETURNING id",
+		msg.To, msg.Subject, msg.Body, msg.HTMLBody, msg.UnsubscribeURL).Scan(&id)
+	return id, err
 }
@@ -0,0 +1,124 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	"github.com/lib/pq"
+)
+
+// StreamReplayWindow is how long events stay available for clients that
+// reconnect with Last-Event-ID.
+const StreamReplayWindow = 24 * time.Hour
+
+// Stream fans out Postgres NOTIFY messages on the stream_events channel to
+// the SSE connections open on this instance. Subscribers only get a wake-up
+// signal; they read the events themselves from stream_events, so a missed
+// or coalesced signal never loses data.
+type Stream struct {
+	Repo *store.EventRepository
+	dsn  string
+
+	mu          sync.Mutex
+	subscribers map[int64]map[chan struct{}]struct{}
+}
+
+func NewStream(repo *store.EventRepository, dsn string) *Stream {
+	return &Stream{Repo: repo, dsn: dsn, subscribers: map[int64]map[chan struct{}]struct{}{}}
+}
+
+// Start listens for notifications until the process exits. The listener
+// reconnects on its own; after a reconnect every subscriber is woken up to
+// re-check the table, since notifications sent meanwhile were lost. Events
+// older than StreamReplayWindow are pruned hourly.
+func (s *Stream) Start() error {
+	listener := pq.NewListener(s.dsn, 1*time.Second, 1*time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Notification stream listener: %v", err)
+		}
+	})
+	if err := listener.Listen("stream_events"); err != nil {
+		return err
+	}
+
+	go func() {
+		for n := range listener.Notify {
+			if n == nil {
+				s.wakeAll()
+				continue
+			}
+			var msg struct {
+				UserID int64 `json:"user_id"`
+			}
+			if err := json.Unmarshal([]byte(n.Extra), &msg); err != nil {
+				log.Printf("Notification stream: bad payload %q: %v", n.Extra, err)
+				continue
+			}
+			s.Publish(msg.UserID)
+		}
+	}()
+
+	ticker := time.NewTicker(1 * time.Hour)
+	go func() {
+		for range ticker.C {
+			if _, err := s.Repo.PruneStreamEvents(context.Background(), StreamReplayWindow); err != nil {
+				log.Printf("Error pruning stream events: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Subscribe registers interest in a user's events. The returned channel
+// receives a value whenever new events may be available; call the cancel
+// function when the connection closes.
+func (s *Stream) Subscribe(userID int64) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	s.mu.Lock()
+	if s.subscribers[userID] == nil {
+		s.subscribers[userID] = map[chan struct{}]struct{}{}
+	}
+	s.subscribers[userID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.subscribers[userID], ch)
+		if len(s.subscribers[userID]) == 0 {
+			delete(s.subscribers, userID)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Publish wakes every connection of the user on this instance.
+func (s *Stream) Publish(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers[userID] {
+		wake(ch)
+	}
+}
+
+func (s *Stream) wakeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, chans := range s.subscribers {
+		for ch := range chans {
+			wake(ch)
+		}
+	}
+}
+
+// wake never blocks: a pending signal already covers the new event.
+func wake(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
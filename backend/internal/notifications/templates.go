@@ -0,0 +1,449 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// DefaultLocale is used when a user has no locale or a template has no
+// translation for it.
+const DefaultLocale = "en"
+
+// Template is one named, localized message. Text is used for in-app
+// notifications and the plain-text email part; HTML is the optional
+// HTML email part.
+type Template struct {
+	Name       string    `json:"name"`
+	Locale     string    `json:"locale"`
+	Subject    string    `json:"subject"`
+	Text       string    `json:"text"`
+	HTML       string    `json:"html"`
+	Customized bool      `json:"customized"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+}
+
+// TemplateData holds the variables available to templates, e.g.
+// {{.Event.Title}} or {{localtime .Event.StartTime}}.
+type TemplateData struct {
+	User         UserVars
+	Event        EventVars
+	Note         string
+	Digest       DigestVars
+	Announcement AnnouncementVars
+}
+
+type UserVars struct {
+	Email string
+}
+
+type EventVars struct {
+	ID        int64
+	Title     string
+	Location  string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// AnnouncementVars is only populated for the announcement template.
+type AnnouncementVars struct {
+	Subject string
+	Body    string
+}
+
+// DigestVars is only populated for the digest template.
+type DigestVars struct {
+	Period string // "daily" or "weekly"
+	Items  []DigestItem
+}
+
+type DigestItem struct {
+	Category  string
+	Subject   string
+	Text      string
+	CreatedAt time.Time
+}
+
+// Rendered is the output of a template for a single recipient.
+type Rendered struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// Recipient identifies who a notification is for. UserID is zero for people
+// without an account (e.g. invitees); Email is empty for in-app-only notices.
+type Recipient struct {
+	UserID   int64
+	Email    string
+	Locale   string
+	Timezone string
+}
+
+// SampleData is used to validate and preview templates.
+func SampleData() TemplateData {
+	start := time.Date(2030, time.March, 14, 18, 0, 0, 0, time.UTC)
+	return TemplateData{
+		User: UserVars{Email: "student@umd.edu"},
+		Event: EventVars{
+			ID:        42,
+			Title:     "Intro to Robotics",
+			Location:  "Engineering Hall 101",
+			StartTime: start,
+			EndTime:   start.Add(2 * time.Hour),
+		},
+		Note: "Bring your student ID.",
+		Announcement: AnnouncementVars{
+			Subject: "Room change",
+			Body:    "We moved to Engineering Hall 201.",
+		},
+		Digest: DigestVars{
+			Period: "daily",
+			Items: []DigestItem{
+				{Category: CategoryEventChanges, Subject: "Event Updated", Text: "Details for 'Intro to Robotics' have changed.", CreatedAt: start.Add(-24 * time.Hour)},
+			},
+		},
+	}
+}
+
+func templateFuncs(loc *time.Location) map[string]any {
+	return map[string]any{
+		"localtime": func(t time.Time) string {
+			return t.In(loc).Format("Mon Jan 2, 3:04 PM MST")
+		},
+	}
+}
+
+// RenderTemplate executes t with data, showing times in loc.
+func RenderTemplate(t Template, data TemplateData, loc *time.Location) (*Rendered, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	funcs := templateFuncs(loc)
+	out := &Rendered{}
+
+	for _, part := range []struct {
+		src string
+		dst *string
+	}{{t.Subject, &out.Subject}, {t.Text, &out.Text}} {
+		tmpl, err := texttemplate.New(t.Name).Option("missingkey=error").Funcs(funcs).Parse(part.src)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		*part.dst = strings.TrimSpace(buf.String())
+	}
+
+	if t.HTML != "" {
+		tmpl, err := htmltemplate.New(t.Name).Option("missingkey=error").Funcs(funcs).Parse(t.HTML)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		out.HTML = buf.String()
+	}
+	return out, nil
+}
+
+// ValidateTemplate checks that t is a known template and renders against
+// the sample data.
+func ValidateTemplate(t Template) error {
+	if _, ok := defaultTemplates[t.Name]; !ok {
+		return errors.New("unknown template name")
+	}
+	if !isSupportedLocale(t.Locale) {
+		return errors.New("unsupported locale")
+	}
+	if strings.TrimSpace(t.Subject) == "" || strings.TrimSpace(t.Text) == "" {
+		return errors.New("subject and text are required")
+	}
+	_, err := RenderTemplate(t, SampleData(), time.UTC)
+	return err
+}
+
+func isSupportedLocale(locale string) bool {
+	for _, l := range Locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupTemplate resolves a template, preferring admin overrides and the
+// recipient's locale, falling back to the built-in DefaultLocale version.
+func (s *Service) lookupTemplate(ctx context.Context, name, locale string) (Template, error) {
+	builtin, ok := defaultTemplates[name]
+	if !ok {
+		return Template{}, errors.New("unknown template: " + name)
+	}
+
+	candidates := []string{DefaultLocale}
+	if locale != "" && locale != DefaultLocale {
+		candidates = []string{locale, DefaultLocale}
+	}
+
+	for _, l := range candidates {
+		if s.DB != nil {
+			t := Template{Name: name, Locale: l, Customized: true}
+			err := s.DB.QueryRowContext(ctx,
+				"SELECT subject, text_body, html_body, updated_at FROM notification_templates WHERE name=$1 AND locale=$2",
+				name, l).Scan(&t.Subject, &t.Text, &t.HTML, &t.UpdatedAt)
+			if err == nil {
+				return t, nil
+			} else if err != sql.ErrNoRows {
+				return Template{}, err
+			}
+		}
+		if t, ok := builtin[l]; ok {
+			t.Name, t.Locale = name, l
+			return t, nil
+		}
+	}
+	return Template{}, errors.New("template has no " + DefaultLocale + " version: " + name)
+}
+
+// Render resolves and executes a template for one recipient.
+func (s *Service) Render(ctx context.Context, name string, rcpt Recipient, data TemplateData) (*Rendered, error) {
+	t, err := s.lookupTemplate(ctx, name, rcpt.Locale)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(rcpt.Timezone)
+	if err != nil || rcpt.Timezone == "" {
+		loc = time.UTC
+	}
+	data.User.Email = rcpt.Email
+	return RenderTemplate(t, data, loc)
+}
+
+// Notify renders a template for the recipient and delivers it according to
+// their preferences for the template's category: as an in-app notification
+// (when UserID is set), as an email (when Email is set) or as an entry in
+// their next digest. Writes go through tx so they commit together with the
+// caller's change. Recipients without an account always get the email.
+func (s *Service) Notify(ctx context.Context, tx Execer, rcpt Recipient, name string, data TemplateData) error {
+	_, err := s.Deliver(ctx, tx, rcpt, name, data)
+	return err
+}
+
+// Delivery records where a notification went, for callers that report on
+// it. Zero IDs mean that channel was not used.
+type Delivery struct {
+	NotificationID int64
+	OutboxID       int64
+	Digested       bool
+}
+
+// Deliver is Notify, also returning what was written.
+func (s *Service) Deliver(ctx context.Context, tx Execer, rcpt Recipient, name string, data TemplateData) (*Delivery, error) {
+	if tx == nil {
+		tx = s.DB
+	}
+	msg, err := s.Render(ctx, name, rcpt, data)
+	if err != nil {
+		return nil, err
+	}
+
+	category := templateCategories[name]
+	pref := DefaultPreference(category)
+	if rcpt.UserID != 0 {
+		if pref, err = s.preferenceFor(ctx, rcpt.UserID, category); err != nil {
+			return nil, err
+		}
+	}
+
+	d := &Delivery{}
+	if rcpt.UserID != 0 && pref.InApp {
+		action := templateActions[name]
+		if action == "" && data.Event.ID != 0 {
+			action = ActionViewEvent
+		}
+		err := tx.QueryRowContext(ctx,
+			"INSERT INTO notifications (user_id, message, category, event_id, action) VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, '')) RETURNING id",
+			rcpt.UserID, msg.Text, category, data.Event.ID, action).Scan(&d.NotificationID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if rcpt.Email == "" {
+		return d, nil
+	}
+
+	email := Message{To: rcpt.Email, Subject: msg.Subject, Body: msg.Text, HTMLBody: msg.HTML}
+	if rcpt.UserID == 0 {
+		d.OutboxID, err = s.enqueue(ctx, tx, email)
+		return d, err
+	}
+	switch pref.Email {
+	case EmailDaily, EmailWeekly:
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO digest_items (user_id, category, frequency, subject, body) VALUES ($1, $2, $3, $4, $5)",
+			rcpt.UserID, category, pref.Email, msg.Subject, msg.Text)
+		d.Digested = err == nil
+		return d, err
+	case EmailOff:
+		return d, nil
+	default:
+		d.OutboxID, err = s.enqueue(ctx, tx, withUnsubscribe(email, s.UnsubscribeURL(rcpt.UserID, category)))
+		return d, err
+	}
+}
+
+// EnqueueDigest renders the digest template for a user and queues it, with
+// an unsubscribe link that turns off email for every category.
+func (s *Service) EnqueueDigest(ctx context.Context, tx Execer, rcpt Recipient, digest DigestVars) error {
+	msg, err := s.Render(ctx, TmplDigest, rcpt, TemplateData{Digest: digest})
+	if err != nil {
+		return err
+	}
+	email := Message{To: rcpt.Email, Subject: msg.Subject, Body: msg.Text, HTMLBody: msg.HTML}
+	return s.Enqueue(ctx, tx, withUnsubscribe(email, s.UnsubscribeURL(rcpt.UserID, "all")))
+}
+
+// NotifyAttendees notifies everyone registered, checked in or waitlisted
+// for an event, each rendered in their own locale and time zone and
+// delivered per their preferences. Pending, rejected and cancelled
+// registrations are not told.
+func (s *Service) NotifyAttendees(ctx context.Context, eventID int64, name string, data TemplateData) error {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT u.id, u.email, u.locale, u.timezone FROM registrations r JOIN users u ON r.user_id = u.id
+		WHERE r.event_id = $1 AND r.status IN ('REGISTERED', 'ATTENDED')
+		UNION
+		SELECT u.id, u.email, u.locale, u.timezone FROM waitlist w JOIN users u ON w.user_id = u.id WHERE w.event_id = $1
+	`, eventID)
+	if err != nil {
+		return err
+	}
+	var recipients []Recipient
+	for rows.Next() {
+		var rc Recipient
+		if err := rows.Scan(&rc.UserID, &rc.Email, &rc.Locale, &rc.Timezone); err != nil {
+			rows.Close()
+			return err
+		}
+		recipients = append(recipients, rc)
+	}
+	rows.Close()
+
+	// As with followers, one failing attendee must not skip the rest.
+	var firstErr error
+	failed := 0
+	for _, rc := range recipients {
+		if err := s.Notify(ctx, nil, rc, name, data); err != nil {
+			log.Printf("Failed to notify attendee %d: %v", rc.UserID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+		}
+	}
+	if firstErr != nil {
+		return fmt.Errorf("%d of %d attendees not notified: %w", failed, len(recipients), firstErr)
+	}
+	return nil
+}
+
+// NotifyFollowers sends a template to the followers of an event's organizer
+// and organization, each once, leaving out the organizer. For members-only
+// events only followers who are members of the organization are told.
+func (s *Service) NotifyFollowers(ctx context.Context, organizerID, organizationID int64, membersOnly bool, name string, data TemplateData) error {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT u.id, u.email, u.locale, u.timezone FROM users u
+		WHERE u.id <> $1 AND u.is_active
+		  AND (u.id IN (SELECT follower_id FROM organizer_follows WHERE organizer_id = $1)
+		       OR u.id IN (SELECT follower_id FROM organization_follows WHERE organization_id = $2))
+		  AND (NOT $3 OR u.id IN (SELECT user_id FROM organization_members WHERE organization_id = $2))
+	`, organizerID, organizationID, membersOnly)
+	if err != nil {
+		return err
+	}
+	var recipients []Recipient
+	for rows.Next() {
+		var rc Recipient
+		if err := rows.Scan(&rc.UserID, &rc.Email, &rc.Locale, &rc.Timezone); err != nil {
+			rows.Close()
+			return err
+		}
+		recipients = append(recipients, rc)
+	}
+	rows.Close()
+
+	// One follower failing must not cost everyone after them the news.
+	var firstErr error
+	failed := 0
+	for _, rc := range recipients {
+		if err := s.Notify(ctx, nil, rc, name, data); err != nil {
+			log.Printf("Failed to notify follower %d: %v", rc.UserID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+		}
+	}
+	if firstErr != nil {
+		return fmt.Errorf("%d of %d followers not notified: %w", failed, len(recipients), firstErr)
+	}
+	return nil
+}
+
+// ListTemplates returns every template in every supported locale, with
+// admin overrides applied.
+func (s *Service) ListTemplates(ctx context.Context) ([]Template, error) {
+	var names []string
+	for name := range defaultTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var list []Template
+	for _, name := range names {
+		for _, l := range Locales {
+			t, err := s.lookupTemplate(ctx, name, l)
+			if err != nil {
+				return nil, err
+			}
+			if t.Locale != l {
+				// No translation yet; show the fallback under this locale.
+				t.Locale, t.Customized = l, false
+			}
+			list = append(list, t)
+		}
+	}
+	return list, nil
+}
+
+// SaveTemplate validates and stores an admin override.
+func (s *Service) SaveTemplate(ctx context.Context, t Template, updatedBy int64) error {
+	if err := ValidateTemplate(t); err != nil {
+		return err
+	}
+	_, err := s.DB.ExecContext(ctx, `
+		INSERT INTO notification_templates (name, locale, subject, text_body, html_body, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (name, locale) DO UPDATE
+		SET subject = EXCLUDED.subject, text_body = EXCLUDED.text_body, html_body = EXCLUDED.html_body,
+		    updated_by = EXCLUDED.updated_by, updated_at = NOW()
+	`, t.Name, t.Locale, t.Subject, t.Text, t.HTML, updatedBy)
+	return err
+}
+
+// ResetTemplate removes an override so the built-in version is used again.
+func (s *Service) ResetTemplate(ctx context.Context, name, locale string) error {
+	_, err := s.DB.ExecContext(ctx, "DELETE FROM notification_templates WHERE name=$1 AND locale=$2", name, locale)
+	return err
+}
@@ -0,0 +1,222 @@
+package notifications
+
+// Template names used by the services.
+const (
+	TmplRegistrationConfirmed = "registration.confirmed"
+	TmplRegistrationWaitlist  = "registration.waitlisted"
+	TmplWaitlistPromoted      = "waitlist.promoted"
+	TmplApplicationReceived   = "application.received"
+	TmplApplicationApproved   = "application.approved"
+	TmplApplicationRejected   = "application.rejected"
+	TmplApplicationWaitlisted = "application.waitlisted"
+	TmplEventUpdated          = "event.updated"
+	TmplEventCancelled        = "event.cancelled"
+	TmplEventInvite           = "event.invite"
+	TmplEventReminder         = "event.reminder"
+	TmplCommentAdded          = "comment.added"
+	TmplDigest                = "digest"
+	TmplAnnouncement          = "announcement"
+	TmplEventPublished        = "event.published"
+)
+
+// Deep-link actions attached to in-app notifications, telling the client
+// which screen to open for the linked event.
+const (
+	ActionViewEvent    = "view_event"
+	ActionRegister     = "register"
+	ActionViewComments = "view_comments"
+)
+
+var templateActions = map[string]string{
+	TmplEventInvite:    ActionRegister,
+	TmplCommentAdded:   ActionViewComments,
+	TmplEventPublished: ActionRegister,
+}
+
+// Locales lists the languages built-in templates are translated into.
+var Locales = []string{"en", "es"}
+
+// defaultTemplates holds the built-in templates by name and locale.
+// Admins can override any of them from /admin/templates.
+var defaultTemplates = map[string]map[string]Template{
+	TmplRegistrationConfirmed: {
+		"en": {
+			Subject: "Registration Confirmed!",
+			Text:    "Registration Confirmed! You are going to {{.Event.Title}} on {{localtime .Event.StartTime}}.",
+			HTML:    "<p>You are going to <strong>{{.Event.Title}}</strong> on {{localtime .Event.StartTime}} at {{.Event.Location}}.</p>",
+		},
+		"es": {
+			Subject: "¡Inscripción confirmada!",
+			Text:    "¡Inscripción confirmada! Asistirás a {{.Event.Title}} el {{localtime .Event.StartTime}}.",
+			HTML:    "<p>Asistirás a <strong>{{.Event.Title}}</strong> el {{localtime .Event.StartTime}} en {{.Event.Location}}.</p>",
+		},
+	},
+	TmplRegistrationWaitlist: {
+		"en": {
+			Subject: "Added to Waitlist",
+			Text:    "You are on the waitlist for {{.Event.Title}}.",
+			HTML:    "<p>You are on the waitlist for <strong>{{.Event.Title}}</strong>. We will let you know if a seat opens up.</p>",
+		},
+		"es": {
+			Subject: "Agregado a la lista de espera",
+			Text:    "Estás en la lista de espera de {{.Event.Title}}.",
+			HTML:    "<p>Estás en la lista de espera de <strong>{{.Event.Title}}</strong>. Te avisaremos si se libera un lugar.</p>",
+		},
+	},
+	TmplWaitlistPromoted: {
+		"en": {
+			Subject: "You're off the Waitlist!",
+			Text:    "Good news! You have been promoted off the waitlist for {{.Event.Title}}.",
+			HTML:    "<p>Good news! A seat opened up and you are now registered for <strong>{{.Event.Title}}</strong> on {{localtime .Event.StartTime}}.</p>",
+		},
+		"es": {
+			Subject: "¡Saliste de la lista de espera!",
+			Text:    "¡Buenas noticias! Saliste de la lista de espera de {{.Event.Title}}.",
+			HTML:    "<p>¡Buenas noticias! Se liberó un lugar y ya estás inscrito en <strong>{{.Event.Title}}</strong> el {{localtime .Event.StartTime}}.</p>",
+		},
+	},
+	TmplApplicationReceived: {
+		"en": {
+			Subject: "Application Received",
+			Text:    "Application received! The organizers of {{.Event.Title}} will review it soon.",
+			HTML:    "<p>Your application for <strong>{{.Event.Title}}</strong> is awaiting review.</p>",
+		},
+		"es": {
+			Subject: "Solicitud recibida",
+			Text:    "¡Solicitud recibida! Los organizadores de {{.Event.Title}} la revisarán pronto.",
+			HTML:    "<p>Tu solicitud para <strong>{{.Event.Title}}</strong> está pendiente de revisión.</p>",
+		},
+	},
+	TmplApplicationApproved: {
+		"en": {
+			Subject: "Application Approved!",
+			Text:    "Your application was approved! You are going to {{.Event.Title}} on {{localtime .Event.StartTime}}.",
+			HTML:    "<p>Your application was approved! You are going to <strong>{{.Event.Title}}</strong> on {{localtime .Event.StartTime}}.</p>",
+		},
+		"es": {
+			Subject: "¡Solicitud aprobada!",
+			Text:    "¡Tu solicitud fue aprobada! Asistirás a {{.Event.Title}} el {{localtime .Event.StartTime}}.",
+			HTML:    "<p>¡Tu solicitud fue aprobada! Asistirás a <strong>{{.Event.Title}}</strong> el {{localtime .Event.StartTime}}.</p>",
+		},
+	},
+	TmplApplicationRejected: {
+		"en": {
+			Subject: "Application Not Accepted",
+			Text:    "Your application for {{.Event.Title}} was not accepted.",
+			HTML:    "<p>Your application for <strong>{{.Event.Title}}</strong> was not accepted.</p>",
+		},
+		"es": {
+			Subject: "Solicitud no aceptada",
+			Text:    "Tu solicitud para {{.Event.Title}} no fue aceptada.",
+			HTML:    "<p>Tu solicitud para <strong>{{.Event.Title}}</strong> no fue aceptada.</p>",
+		},
+	},
+	TmplApplicationWaitlisted: {
+		"en": {
+			Subject: "Application Waitlisted",
+			Text:    "Your application for {{.Event.Title}} was moved to the waitlist.",
+			HTML:    "<p>Your application for <strong>{{.Event.Title}}</strong> was moved to the waitlist.</p>",
+		},
+		"es": {
+			Subject: "Solicitud en lista de espera",
+			Text:    "Tu solicitud para {{.Event.Title}} pasó a la lista de espera.",
+			HTML:    "<p>Tu solicitud para <strong>{{.Event.Title}}</strong> pasó a la lista de espera.</p>",
+		},
+	},
+	TmplEventUpdated: {
+		"en": {
+			Subject: "Event Updated",
+			Text:    "Update: Details for '{{.Event.Title}}' have changed. It now starts {{localtime .Event.StartTime}} at {{.Event.Location}}.",
+			HTML:    "<p>Details for <strong>{{.Event.Title}}</strong> have changed. It now starts {{localtime .Event.StartTime}} at {{.Event.Location}}.</p>",
+		},
+		"es": {
+			Subject: "Evento actualizado",
+			Text:    "Actualización: Los detalles de '{{.Event.Title}}' cambiaron. Ahora comienza el {{localtime .Event.StartTime}} en {{.Event.Location}}.",
+			HTML:    "<p>Los detalles de <strong>{{.Event.Title}}</strong> cambiaron. Ahora comienza el {{localtime .Event.StartTime}} en {{.Event.Location}}.</p>",
+		},
+	},
+	TmplEventCancelled: {
+		"en": {
+			Subject: "Event Cancelled: {{.Event.Title}}",
+			Text:    "'{{.Event.Title}}' on {{localtime .Event.StartTime}} has been cancelled.",
+			HTML:    "<p><strong>{{.Event.Title}}</strong> on {{localtime .Event.StartTime}} has been cancelled.</p>",
+		},
+		"es": {
+			Subject: "Evento cancelado: {{.Event.Title}}",
+			Text:    "'{{.Event.Title}}' del {{localtime .Event.StartTime}} fue cancelado.",
+			HTML:    "<p><strong>{{.Event.Title}}</strong> del {{localtime .Event.StartTime}} fue cancelado.</p>",
+		},
+	},
+	TmplEventInvite: {
+		"en": {
+			Subject: "You're Invited!",
+			Text:    "You have been invited to join '{{.Event.Title}}'. Log in to CampusSync to register.",
+			HTML:    "<p>You have been invited to join <strong>{{.Event.Title}}</strong> on {{localtime .Event.StartTime}}. Log in to CampusSync to register.</p>",
+		},
+		"es": {
+			Subject: "¡Estás invitado!",
+			Text:    "Te invitaron a '{{.Event.Title}}'. Inicia sesión en CampusSync para inscribirte.",
+			HTML:    "<p>Te invitaron a <strong>{{.Event.Title}}</strong> el {{localtime .Event.StartTime}}. Inicia sesión en CampusSync para inscribirte.</p>",
+		},
+	},
+	TmplEventReminder: {
+		"en": {
+			Subject: "Reminder: {{.Event.Title}}",
+			Text:    "Reminder: {{.Event.Title}} starts {{localtime .Event.StartTime}} at {{.Event.Location}}.{{if .Note}} Note from the organizer: {{.Note}}{{end}}",
+			HTML:    "<p><strong>{{.Event.Title}}</strong> starts {{localtime .Event.StartTime}} at {{.Event.Location}}.</p>{{if .Note}}<p>Note from the organizer: {{.Note}}</p>{{end}}",
+		},
+		"es": {
+			Subject: "Recordatorio: {{.Event.Title}}",
+			Text:    "Recordatorio: {{.Event.Title}} comienza el {{localtime .Event.StartTime}} en {{.Event.Location}}.{{if .Note}} Nota del organizador: {{.Note}}{{end}}",
+			HTML:    "<p><strong>{{.Event.Title}}</strong> comienza el {{localtime .Event.StartTime}} en {{.Event.Location}}.</p>{{if .Note}}<p>Nota del organizador: {{.Note}}</p>{{end}}",
+		},
+	},
+	TmplCommentAdded: {
+		"en": {
+			Subject: "New comment on {{.Event.Title}}",
+			Text:    "New comment on {{.Event.Title}}: \"{{.Note}}\"",
+			HTML:    "<p>New comment on <strong>{{.Event.Title}}</strong>:</p><blockquote>{{.Note}}</blockquote>",
+		},
+		"es": {
+			Subject: "Nuevo comentario en {{.Event.Title}}",
+			Text:    "Nuevo comentario en {{.Event.Title}}: \"{{.Note}}\"",
+			HTML:    "<p>Nuevo comentario en <strong>{{.Event.Title}}</strong>:</p><blockquote>{{.Note}}</blockquote>",
+		},
+	},
+	TmplEventPublished: {
+		"en": {
+			Subject: "New event: {{.Event.Title}}",
+			Text:    "{{.Note}} posted a new event: '{{.Event.Title}}' on {{localtime .Event.StartTime}} at {{.Event.Location}}.",
+			HTML:    "<p>{{.Note}} posted a new event: <strong>{{.Event.Title}}</strong> on {{localtime .Event.StartTime}} at {{.Event.Location}}.</p>",
+		},
+		"es": {
+			Subject: "Nuevo evento: {{.Event.Title}}",
+			Text:    "{{.Note}} publicó un nuevo evento: '{{.Event.Title}}' el {{localtime .Event.StartTime}} en {{.Event.Location}}.",
+			HTML:    "<p>{{.Note}} publicó un nuevo evento: <strong>{{.Event.Title}}</strong> el {{localtime .Event.StartTime}} en {{.Event.Location}}.</p>",
+		},
+	},
+	TmplDigest: {
+		"en": {
+			Subject: "Your {{.Digest.Period}} CampusSync digest",
+			Text:    "Here is what happened since your last {{.Digest.Period}} digest:\n{{range .Digest.Items}}\n- {{.Subject}}: {{.Text}}{{end}}",
+			HTML:    "<p>Here is what happened since your last {{.Digest.Period}} digest:</p><ul>{{range .Digest.Items}}<li><strong>{{.Subject}}</strong>: {{.Text}}</li>{{end}}</ul>",
+		},
+		"es": {
+			Subject: "Tu resumen {{if eq .Digest.Period \"weekly\"}}semanal{{else}}diario{{end}} de CampusSync",
+			Text:    "Esto es lo que pasó desde tu último resumen:\n{{range .Digest.Items}}\n- {{.Subject}}: {{.Text}}{{end}}",
+			HTML:    "<p>Esto es lo que pasó desde tu último resumen:</p><ul>{{range .Digest.Items}}<li><strong>{{.Subject}}</strong>: {{.Text}}</li>{{end}}</ul>",
+		},
+	},
+	TmplAnnouncement: {
+		"en": {
+			Subject: "[{{.Event.Title}}] {{.Announcement.Subject}}",
+			Text:    "{{.Event.Title}}: {{.Announcement.Subject}}\n\n{{.Announcement.Body}}",
+			HTML:    "<p><strong>{{.Event.Title}}</strong>: {{.Announcement.Subject}}</p><p>{{.Announcement.Body}}</p>",
+		},
+		"es": {
+			Subject: "[{{.Event.Title}}] {{.Announcement.Subject}}",
+			Text:    "{{.Event.Title}}: {{.Announcement.Subject}}\n\n{{.Announcement.Body}}",
+			HTML:    "<p><strong>{{.Event.Title}}</strong>: {{.Announcement.Subject}}</p><p>{{.Announcement.Body}}</p>",
+		},
+	},
+}
@@ -0,0 +1,208 @@
+package notifications
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Message is a single outgoing email.
+type Message struct {
+	To       string
+	Subject  string
+	Body     string
+	HTMLBody string
+
+	// UnsubscribeURL, when set, is sent as a one-click List-Unsubscribe
+	// header (RFC 8058).
+	UnsubscribeURL string
+}
+
+// Transport delivers a message. Implementations must be safe for
+// concurrent use.
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewTransportFromEnv picks a transport based on EMAIL_TRANSPORT:
+// "smtp", "file" (maildir under EMAIL_FILE_DIR) or "noop" (the default,
+// which only logs).
+func NewTransportFromEnv() Transport {
+	switch strings.ToLower(os.Getenv("EMAIL_TRANSPORT")) {
+	case "smtp":
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return &SMTPTransport{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     fromAddress(),
+		}
+	case "file":
+		dir := os.Getenv("EMAIL_FILE_DIR")
+		if dir == "" {
+			dir = "./mail"
+		}
+		return &FileTransport{Dir: dir, From: fromAddress()}
+	default:
+		return &NoopTransport{}
+	}
+}
+
+func fromAddress() string {
+	if from := os.Getenv("EMAIL_FROM"); from != "" {
+		return from
+	}
+	return "CampusSync <no-reply@campussync.local>"
+}
+
+// formatMessage renders msg as an RFC 5322 message.
+func formatMessage(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	if msg.UnsubscribeURL != "" {
+		b.WriteString("List-Unsubscribe: <" + msg.UnsubscribeURL + ">\r\n")
+		b.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	if msg.HTMLBody == "" {
+		b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		b.WriteString("\r\n")
+		b.WriteString(msg.Body)
+		b.WriteString("\r\n")
+		return []byte(b.String())
+	}
+
+	boundary := fmt.Sprintf("campussync-%d", time.Now().UnixNano())
+	b.WriteString("Content-Type: multipart/alternative; boundary=\"" + boundary + "\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString("--" + boundary + "\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.Body + "\r\n")
+	b.WriteString("--" + boundary + "\r\n")
+	b.WriteString("Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.HTMLBody + "\r\n")
+	b.WriteString("--" + boundary + "--\r\n")
+	return []byte(b.String())
+}
+
+// DefaultSMTPTimeout bounds one SMTP conversation, well inside the lease
+// the email dispatcher holds on a claimed message.
+const DefaultSMTPTimeout = 30 * time.Second
+
+// SMTPTransport sends through an SMTP relay. Authentication is skipped when
+// Username is empty.
+type SMTPTransport struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	// Timeout bounds the whole conversation with the relay, dial included;
+	// zero means DefaultSMTPTimeout.
+	Timeout time.Duration
+}
+
+// Send is smtp.SendMail with a deadline: a relay that stops answering, or a
+// cancelled ctx, ends the attempt instead of hanging the dispatcher past
+// its lease.
+func (t *SMTPTransport) Send(ctx context.Context, msg Message) error {
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSMTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(t.Host, t.Port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	// Cancelling ctx early unblocks any read or write in progress.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, t.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: t.Host}); err != nil {
+			return err
+		}
+	}
+	if t.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", t.Username, t.Password, t.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(t.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(formatMessage(t.From, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// FileTransport writes each message into a maildir (Dir/new), which local
+// mail clients and tests can read without a mail server.
+type FileTransport struct {
+	Dir  string
+	From string
+}
+
+var fileSeq atomic.Int64
+
+func (t *FileTransport) Send(ctx context.Context, msg Message) error {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(t.Dir, sub), 0o755); err != nil {
+			return err
+		}
+	}
+
+	name := fmt.Sprintf("%d.%d.campussync", time.Now().UnixNano(), fileSeq.Add(1))
+	tmp := filepath.Join(t.Dir, "tmp", name)
+	if err := os.WriteFile(tmp, formatMessage(t.From, msg), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(t.Dir, "new", name))
+}
+
+// NoopTransport drops messages after logging them.
+type NoopTransport struct{}
+
+func (t *NoopTransport) Send(ctx context.Context, msg Message) error {
+	log.Printf(" [EMAIL SENT] To: %s | Subject: %s | Body: %s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
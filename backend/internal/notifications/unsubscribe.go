@@ -0,0 +1,117 @@
+package notifications
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUnsubscribeTTL is how long an unsubscribe link keeps working.
+const DefaultUnsubscribeTTL = 90 * 24 * time.Hour
+
+var warnNoSecret sync.Once
+
+// unsubscribeConfig reads UNSUBSCRIBE_SECRET and PUBLIC_BASE_URL. Without a
+// secret, one-click unsubscribe is turned off: emails carry no link and
+// every token is rejected, since anyone could sign tokens with a known key.
+func unsubscribeConfig() ([]byte, string) {
+	secret := os.Getenv("UNSUBSCRIBE_SECRET")
+	if secret == "" {
+		warnNoSecret.Do(func() {
+			log.Println("⚠️ WARNING: UNSUBSCRIBE_SECRET not set. Emails are sent without unsubscribe links.")
+		})
+		return nil, ""
+	}
+	base := os.Getenv("PUBLIC_BASE_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return []byte(secret), strings.TrimRight(base, "/")
+}
+
+func (s *Service) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.unsubscribeSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// UnsubscribeToken returns a token that turns off email for one category
+// (or "all") for a user. It needs no login, so it works from any mail client,
+// and expires after UnsubscribeTTL. It is "" when unsubscribe is turned off.
+func (s *Service) UnsubscribeToken(userID int64, category string) string {
+	if len(s.unsubscribeSecret) == 0 {
+		return ""
+	}
+	expires := time.Now().Add(s.UnsubscribeTTL).Unix()
+	payload := strconv.FormatInt(userID, 10) + "." + category + "." + strconv.FormatInt(expires, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.sign(payload)
+}
+
+// UnsubscribeURL is the one-click link included in every email, or "" when
+// unsubscribe is turned off.
+func (s *Service) UnsubscribeURL(userID int64, category string) string {
+	if len(s.unsubscribeSecret) == 0 {
+		return ""
+	}
+	return s.baseURL + "/api/unsubscribe?token=" + url.QueryEscape(s.UnsubscribeToken(userID, category))
+}
+
+// ParseUnsubscribeToken verifies a token and returns who and what it is for.
+func (s *Service) ParseUnsubscribeToken(token string) (int64, string, error) {
+	invalid := errors.New("invalid unsubscribe token")
+	if len(s.unsubscribeSecret) == 0 {
+		return 0, "", errors.New("unsubscribe links are turned off")
+	}
+
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", invalid
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, "", invalid
+	}
+	payload := string(raw)
+	if !hmac.Equal([]byte(sig), []byte(s.sign(payload))) {
+		return 0, "", invalid
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 3 {
+		return 0, "", invalid
+	}
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", invalid
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, "", invalid
+	}
+	if time.Now().Unix() > expires {
+		return 0, "", errors.New("unsubscribe link has expired; change your notification preferences instead")
+	}
+	return userID, parts[1], nil
+}
+
+// withUnsubscribe appends the unsubscribe link to both email parts. An
+// empty link (unsubscribe turned off) leaves the message as is.
+func withUnsubscribe(msg Message, link string) Message {
+	if link == "" {
+		return msg
+	}
+	msg.UnsubscribeURL = link
+	msg.Body += "\n\n--\nUnsubscribe: " + link
+	if msg.HTMLBody != "" {
+		msg.HTMLBody += `<p style="font-size:12px;color:#888"><a href="` + link + `">Unsubscribe</a></p>`
+	}
+	return msg
+}
@@ -0,0 +1,325 @@
+package organizations
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+type Handler struct {
+	Repo     *store.OrganizationRepository
+	UserRepo *store.UserRepository
+}
+
+func (h *Handler) currentUser(w http.ResponseWriter, r *http.Request) (*store.User, bool) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return nil, false
+	}
+	return user, true
+}
+
+// access loads the organization named by organization_id and the caller's
+// role in it. Users with org.manage.any act as its owner.
+func (h *Handler) access(w http.ResponseWriter, r *http.Request, user *store.User, orgID int64) (string, bool) {
+	org, err := h.Repo.Get(r.Context(), orgID, user.ID)
+	if errors.Is(err, store.ErrOrgNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return "", false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return "", false
+	}
+	if authz.Can(user.Role, authz.OrgManageAny) {
+		return store.OrgOwner, true
+	}
+	return org.MyRole, true
+}
+
+func forbidden(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+func orgID(r *http.Request) int64 {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("organization_id"), 10, 64)
+	return id
+}
+
+func (h *Handler) HandleListOrganizations(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := h.Repo.List(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleCreateOrganization starts an organization owned by the caller.
+func (h *Handler) HandleCreateOrganization(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	org := &store.Organization{Name: req.Name, Description: req.Description}
+	if err := h.Repo.Create(r.Context(), org, user.ID); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			http.Error(w, "An organization with this name already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(org)
+}
+
+// HandleListMembers shows the roster to the organization's members.
+func (h *Handler) HandleListMembers(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	id := orgID(r)
+	role, ok := h.access(w, r, user, id)
+	if !ok {
+		return
+	}
+	if role == "" {
+		forbidden(w, "Forbidden: Only members can see the roster.")
+		return
+	}
+	members, err := h.Repo.ListMembers(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+// HandleSetMember adds a user or changes their role. Owners appoint
+// officers; officers may only add plain members.
+func (h *Handler) HandleSetMember(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		OrganizationID int64  `json:"organization_id"`
+		UserID         int64  `json:"user_id"`
+		Role           string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		req.Role = store.OrgMember
+	}
+	role, ok := h.access(w, r, user, req.OrganizationID)
+	if !ok {
+		return
+	}
+	if !store.IsManager(role) {
+		forbidden(w, "Forbidden: Only owners and officers can manage members.")
+		return
+	}
+	if role == store.OrgOfficer {
+		current, err := h.Repo.MemberRole(r.Context(), req.OrganizationID, req.UserID)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if req.Role != store.OrgMember || store.IsManager(current) {
+			forbidden(w, "Forbidden: Only the owner can appoint or change officers.")
+			return
+		}
+	}
+
+	if err := h.Repo.SetMember(r.Context(), req.OrganizationID, req.UserID, req.Role); err != nil {
+		if errors.Is(err, store.ErrOrgOwner) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if strings.Contains(err.Error(), "foreign key") {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleRemoveMember takes a user out of an organization. Anyone may leave;
+// removing others needs a manager, and only the owner removes officers.
+func (h *Handler) HandleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	id := orgID(r)
+	targetID, _ := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	role, ok := h.access(w, r, user, id)
+	if !ok {
+		return
+	}
+	if targetID != user.ID {
+		current, err := h.Repo.MemberRole(r.Context(), id, targetID)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if !store.IsManager(role) || (role == store.OrgOfficer && store.IsManager(current)) {
+			forbidden(w, "Forbidden: You cannot remove this member.")
+			return
+		}
+	}
+
+	if err := h.Repo.RemoveMember(r.Context(), id, targetID); err != nil {
+		if errors.Is(err, store.ErrOrgOwner) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleTransferOwnership hands an organization to another user. The owner
+// may do it, and so may admins when the owner has left campus.
+func (h *Handler) HandleTransferOwnership(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		OrganizationID int64 `json:"organization_id"`
+		UserID         int64 `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	role, ok := h.access(w, r, user, req.OrganizationID)
+	if !ok {
+		return
+	}
+	if role != store.OrgOwner {
+		forbidden(w, "Forbidden: Only the owner can transfer the organization.")
+		return
+	}
+	if _, err := h.UserRepo.GetByID(r.Context(), req.UserID); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	from, err := h.Repo.TransferOwnership(r.Context(), req.OrganizationID, req.UserID, user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("🏛️ Organization %d transferred from user %d to user %d by user %d", req.OrganizationID, from, req.UserID, user.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleListEvents lists an organization's events. Members also see the
+// members-only ones.
+func (h *Handler) HandleListEvents(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	id := orgID(r)
+	role, ok := h.access(w, r, user, id)
+	if !ok {
+		return
+	}
+	list, err := h.Repo.Events(r.Context(), id, role != "")
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// canSeeAnalytics allows the organization's owner and officers, and anyone
+// who may see site-wide analytics.
+func (h *Handler) canSeeAnalytics(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return 0, false
+	}
+	id := orgID(r)
+	role, ok := h.access(w, r, user, id)
+	if !ok {
+		return 0, false
+	}
+	if !store.IsManager(role) && !authz.Can(user.Role, authz.AnalyticsView) {
+		forbidden(w, "Forbidden: Only owners and officers can see organization analytics.")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) HandleAnalytics(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.canSeeAnalytics(w, r)
+	if !ok {
+		return
+	}
+	stats, err := h.Repo.Stats(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// HandleExport downloads the registrations of the organization's events as
+// CSV, in the same layout as the site-wide export.
+func (h *Handler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.canSeeAnalytics(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=organization_export.csv")
+	if err := h.Repo.Export(r.Context(), id, w); err != nil {
+		http.Error(w, "Export failed", http.StatusInternalServerError)
+	}
+}
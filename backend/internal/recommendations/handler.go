@@ -0,0 +1,47 @@
+package recommendations
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+type Handler struct {
+	Service  *Service
+	UserRepo *store.UserRepository
+}
+
+// HandleRecommendations returns up to ?limit= (default 10, max 50) upcoming
+// events for the caller. ?ai=true lets the model re-rank the results.
+func (h *Handler) HandleRecommendations(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, 50)
+	}
+	rerank, _ := strconv.ParseBool(r.URL.Query().Get("ai"))
+
+	recs, err := h.Service.Recommend(r.Context(), user.ID, user.Email, limit, rerank)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recs)
+}
@@ -0,0 +1,173 @@
+package recommendations
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Weights of the baseline score components, each normalised to 0..1.
+const (
+	weightCategory   = 3.0
+	weightCoAttended = 2.0
+	weightPopularity = 1.0
+)
+
+// PastEvent is an event the user registered for or attended.
+type PastEvent struct {
+	ID       int64
+	Title    string
+	Category string
+}
+
+// Candidate is an upcoming event the user can register for.
+type Candidate struct {
+	ID         int64     `json:"id"`
+	Title      string    `json:"title"`
+	Category   string    `json:"category"`
+	Location   string    `json:"location"`
+	StartTime  time.Time `json:"start_time"`
+	Registered int       `json:"registered"`
+}
+
+// CoAttendance counts people who shared SourceID with the user and are
+// registered for a candidate.
+type CoAttendance struct {
+	SourceID int64
+	People   int
+}
+
+type Recommendation struct {
+	Event  Candidate `json:"event"`
+	Score  float64   `json:"score"`
+	Reason string    `json:"reason"`
+}
+
+// Rank scores candidates on category affinity, co-attendance and
+// popularity, and explains each by its strongest signal. It is
+// deterministic: ties go to the earlier event.
+func Rank(history []PastEvent, candidates []Candidate, co map[int64][]CoAttendance, limit int) []Recommendation {
+	titles := map[int64]string{}
+	categories := map[string]int{}
+	for _, h := range history {
+		titles[h.ID] = h.Title
+		categories[h.Category]++
+	}
+
+	maxCo, maxReg := 0, 0
+	for _, c := range candidates {
+		if n := totalPeople(co[c.ID]); n > maxCo {
+			maxCo = n
+		}
+		if c.Registered > maxReg {
+			maxReg = c.Registered
+		}
+	}
+
+	recs := make([]Recommendation, 0, len(candidates))
+	for _, c := range candidates {
+		var category, coAttended, popularity float64
+		if len(history) > 0 {
+			category = float64(categories[c.Category]) / float64(len(history))
+		}
+		if maxCo > 0 {
+			coAttended = float64(totalPeople(co[c.ID])) / float64(maxCo)
+		}
+		if maxReg > 0 {
+			popularity = float64(c.Registered) / float64(maxReg)
+		}
+
+		parts := []struct {
+			score  float64
+			reason func() string
+		}{
+			{weightCategory * category, func() string {
+				return fmt.Sprintf("You've joined %d %s event%s", categories[c.Category], c.Category, plural(categories[c.Category]))
+			}},
+			{weightCoAttended * coAttended, func() string {
+				src := topSource(co[c.ID])
+				return fmt.Sprintf("People who attended %s also signed up", titles[src.SourceID])
+			}},
+			{weightPopularity * popularity, func() string {
+				return fmt.Sprintf("Popular: %d %s registered", c.Registered, people(c.Registered))
+			}},
+		}
+
+		rec := Recommendation{Event: c, Reason: "Coming up on " + c.StartTime.Format("Jan 02")}
+		best := 0.0
+		for _, p := range parts {
+			rec.Score += p.score
+			if p.score > best {
+				best = p.score
+				rec.Reason = p.reason()
+			}
+		}
+		recs = append(recs, rec)
+	}
+
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		return recs[i].Event.StartTime.Before(recs[j].Event.StartTime)
+	})
+	if limit > 0 && len(recs) > limit {
+		recs = recs[:limit]
+	}
+	return recs
+}
+
+// Reorder puts recs in the order of ids. Unknown ids are ignored and
+// anything not mentioned keeps its relative order at the end.
+func Reorder(recs []Recommendation, ids []int64) []Recommendation {
+	byID := map[int64]Recommendation{}
+	for _, r := range recs {
+		byID[r.Event.ID] = r
+	}
+	out := make([]Recommendation, 0, len(recs))
+	used := map[int64]bool{}
+	for _, id := range ids {
+		if r, ok := byID[id]; ok && !used[id] {
+			out = append(out, r)
+			used[id] = true
+		}
+	}
+	for _, r := range recs {
+		if !used[r.Event.ID] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func totalPeople(co []CoAttendance) int {
+	n := 0
+	for _, c := range co {
+		n += c.People
+	}
+	return n
+}
+
+func topSource(co []CoAttendance) CoAttendance {
+	var top CoAttendance
+	for _, c := range co {
+		if c.People > top.People || (c.People == top.People && c.SourceID < top.SourceID) {
+			top = c
+		}
+	}
+	return top
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+func people(n int) string {
+	if n == 1 {
+		return "person"
+	}
+	return "people"
+}
@@ -0,0 +1,163 @@
+package recommendations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+)
+
+// rerankPool is how many baseline results the model may re-order.
+const rerankPool = 20
+
+type Service struct {
+	DB *sql.DB
+	AI *ai.Service
+}
+
+func NewService(db *sql.DB, aiService *ai.Service) *Service {
+	return &Service{DB: db, AI: aiService}
+}
+
+// Recommend ranks upcoming events for a user. Private events they are not
+// invited to, their own events and events they already registered,
+// applied or waitlisted for are never returned. With rerank set and a
+// model configured, the top of the baseline ranking is re-ordered by the
+// model; any failure falls back to the baseline.
+func (s *Service) Recommend(ctx context.Context, userID int64, email string, limit int, rerank bool) ([]Recommendation, error) {
+	history, err := s.history(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := s.candidates(ctx, userID, email)
+	if err != nil {
+		return nil, err
+	}
+	co, err := s.coAttendance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := limit
+	if rerank && pool < rerankPool {
+		pool = rerankPool
+	}
+	recs := Rank(history, candidates, co, pool)
+	if rerank && s.AI.Available() && len(recs) > 1 {
+		aiCtx := ai.WithSubject(ctx, ai.UserSubject(userID))
+		ids, err := s.AI.GetRecommendations(aiCtx, describeHistory(history), describeCandidates(recs))
+		if err != nil {
+			log.Printf("Recommendation re-rank failed, using baseline: %v", err)
+		} else {
+			recs = Reorder(recs, ids)
+		}
+	}
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+	return recs, nil
+}
+
+func (s *Service) history(ctx context.Context, userID int64) ([]PastEvent, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT e.id, e.title, e.category FROM registrations r JOIN events e ON e.id = r.event_id
+		WHERE r.user_id = $1 AND r.status IN ('REGISTERED', 'ATTENDED')
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []PastEvent
+	for rows.Next() {
+		var p PastEvent
+		if err := rows.Scan(&p.ID, &p.Title, &p.Category); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (s *Service) candidates(ctx context.Context, userID int64, email string) ([]Candidate, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT e.id, e.title, e.category, e.location, e.start_time,
+		       (SELECT COUNT(*) FROM registrations r WHERE r.event_id = e.id AND r.status IN ('REGISTERED', 'ATTENDED'))
+		FROM events e
+		WHERE e.status = 'UPCOMING' AND e.start_time > NOW()
+		  AND e.organizer_id <> $1
+		  AND (e.visibility = 'PUBLIC' OR EXISTS (SELECT 1 FROM invitations i WHERE i.event_id = e.id AND i.email = $2)
+		       OR (e.visibility = 'MEMBERS' AND EXISTS (SELECT 1 FROM organization_members m WHERE m.organization_id = e.organization_id AND m.user_id = $1)))
+		  AND NOT EXISTS (SELECT 1 FROM registrations r WHERE r.event_id = e.id AND r.user_id = $1 AND r.status <> 'CANCELLED')
+		  AND NOT EXISTS (SELECT 1 FROM waitlist w WHERE w.event_id = e.id AND w.user_id = $1)
+		ORDER BY e.start_time
+	`, userID, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Candidate
+	for rows.Next() {
+		var c Candidate
+		if err := rows.Scan(&c.ID, &c.Title, &c.Category, &c.Location, &c.StartTime, &c.Registered); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// coAttendance finds, for each other event, how many people who shared one
+// of the user's events are registered for it, per shared event.
+func (s *Service) coAttendance(ctx context.Context, userID int64) (map[int64][]CoAttendance, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT theirs.event_id, mine.event_id, COUNT(DISTINCT peer.user_id)
+		FROM registrations mine
+		JOIN registrations peer ON peer.event_id = mine.event_id AND peer.user_id <> mine.user_id
+		                        AND peer.status IN ('REGISTERED', 'ATTENDED')
+		JOIN registrations theirs ON theirs.user_id = peer.user_id AND theirs.event_id <> mine.event_id
+		                          AND theirs.status IN ('REGISTERED', 'ATTENDED')
+		WHERE mine.user_id = $1 AND mine.status IN ('REGISTERED', 'ATTENDED')
+		GROUP BY theirs.event_id, mine.event_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	co := map[int64][]CoAttendance{}
+	for rows.Next() {
+		var target int64
+		var c CoAttendance
+		if err := rows.Scan(&target, &c.SourceID, &c.People); err != nil {
+			return nil, err
+		}
+		co[target] = append(co[target], c)
+	}
+	return co, rows.Err()
+}
+
+func describeHistory(history []PastEvent) string {
+	if len(history) == 0 {
+		return "(none)"
+	}
+	var b strings.Builder
+	for _, h := range history {
+		fmt.Fprintf(&b, "- %s (Category: %s)\n", h.Title, h.Category)
+	}
+	return b.String()
+}
+
+func describeCandidates(recs []Recommendation) string {
+	var b strings.Builder
+	for _, r := range recs {
+		e := r.Event
+		fmt.Fprintf(&b, "- ID: %d, Title: %s, Category: %s, Time: %s, Registered: %d\n",
+			e.ID, e.Title, e.Category, e.StartTime.Format("Jan 02 15:04"), e.Registered)
+	}
+	return b.String()
+}
@@ -0,0 +1,133 @@
+package registration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/webhooks"
+)
+
+const (
+	DecisionApprove  = "APPROVE"
+	DecisionReject   = "REJECT"
+	DecisionWaitlist = "WAITLIST"
+)
+
+// DecisionResult reports what happened to a single applicant in a bulk decision.
+type DecisionResult struct {
+	UserID  int64  `json:"user_id"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// DecideApplications applies one decision to a batch of PENDING applications.
+// Applicants that are no longer pending, or that cannot be approved because
+// the event is full, are skipped and reported in the results.
+func (s *Service) DecideApplications(ctx context.Context, deciderID, eventID int64, userIDs []int64, decision string) ([]*DecisionResult, error) {
+	if decision != DecisionApprove && decision != DecisionReject && decision != DecisionWaitlist {
+		return nil, errors.New("invalid decision (must be APPROVE, REJECT or WAITLIST)")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the event row so concurrent approvals cannot oversell seats.
+	var capacity int
+	err = tx.QueryRowContext(ctx, "SELECT capacity FROM events WHERE id=$1 FOR UPDATE", eventID).Scan(&capacity)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("event not found")
+	} else if err != nil {
+		return nil, err
+	}
+	event, err := eventVars(ctx, tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	var registered int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM registrations WHERE event_id=$1 AND status='REGISTERED'", eventID).Scan(&registered)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*DecisionResult
+	now := time.Now()
+
+	for _, userID := range userIDs {
+		var status string
+		err := tx.QueryRowContext(ctx,
+			"SELECT CAST(status AS TEXT) FROM registrations WHERE event_id=$1 AND user_id=$2 FOR UPDATE",
+			eventID, userID).Scan(&status)
+		if err == sql.ErrNoRows || (err == nil && status != "PENDING") {
+			results = append(results, &DecisionResult{UserID: userID, Status: "SKIPPED", Message: "no pending application"})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var template, newStatus string
+		switch decision {
+		case DecisionApprove:
+			if registered >= capacity {
+				results = append(results, &DecisionResult{UserID: userID, Status: "SKIPPED", Message: "event is full"})
+				continue
+			}
+			_, err = tx.ExecContext(ctx,
+				"UPDATE registrations SET status='REGISTERED', decided_by=$1, decided_at=$2, updated_at=$2 WHERE event_id=$3 AND user_id=$4",
+				deciderID, now, eventID, userID)
+			registered++
+			newStatus = "REGISTERED"
+			template = notifications.TmplApplicationApproved
+		case DecisionReject:
+			_, err = tx.ExecContext(ctx,
+				"UPDATE registrations SET status='REJECTED', decided_by=$1, decided_at=$2, updated_at=$2 WHERE event_id=$3 AND user_id=$4",
+				deciderID, now, eventID, userID)
+			newStatus = "REJECTED"
+			template = notifications.TmplApplicationRejected
+		case DecisionWaitlist:
+			// The answers move with the applicant so they are restored on
+			// promotion.
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO waitlist (user_id, event_id, form_responses, created_at)
+				SELECT user_id, event_id, COALESCE(form_responses, '{}'), $3 FROM registrations WHERE event_id=$1 AND user_id=$2
+				ON CONFLICT DO NOTHING`,
+				eventID, userID, now)
+			if err == nil {
+				_, err = tx.ExecContext(ctx, "DELETE FROM registrations WHERE event_id=$1 AND user_id=$2", eventID, userID)
+			}
+			newStatus = "WAITLISTED"
+			template = notifications.TmplApplicationWaitlisted
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rcpt, err := recipientFor(ctx, tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Notifications.Notify(ctx, tx, rcpt, template, notifications.TemplateData{Event: event}); err != nil {
+			return nil, err
+		}
+		if newStatus == "REGISTERED" {
+			hook := webhooks.RegistrationPayload{EventID: eventID, UserID: userID, UserEmail: rcpt.Email, Status: newStatus}
+			if err := s.Webhooks.Emit(ctx, tx, webhooks.RegistrationCreated, eventID, hook); err != nil {
+				return nil, err
+			}
+		}
+
+		results = append(results, &DecisionResult{UserID: userID, Status: newStatus})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -2,9 +2,11 @@ package registration
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"strconv"
 
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
@@ -14,6 +16,7 @@ type Handler struct {
 	Service   *Service
 	UserRepo  *store.UserRepository
 	EventRepo *store.EventRepository
+	Orgs      *store.OrganizationRepository
 }
 
 func (h *Handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
@@ -37,7 +40,16 @@ func (h *Handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.Service.RegisterUserForEvent(r.Context(), user.ID, eventID)
+	// The body is optional: events with custom fields send the answers here.
+	var req struct {
+		FormResponses map[string]string `json:"form_responses"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.Service.RegisterWithResponses(r.Context(), user.ID, eventID, req.FormResponses)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
@@ -90,3 +102,80 @@ func (h *Handler) HandleListMyRegistrations(w http.ResponseWriter, r *http.Reque
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(events)
 }
+
+// authorizeEventManager loads the caller and the event, and checks that the
+// caller may manage the event (see authz.CanManage).
+func (h *Handler) authorizeEventManager(w http.ResponseWriter, r *http.Request, eventID int64) (*store.User, bool) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	event, err := h.EventRepo.GetEventByID(r.Context(), eventID)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return nil, false
+	}
+	if !authz.CanManage(r.Context(), h.Orgs, user, event) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: You can only review applications for your own events."})
+		return nil, false
+	}
+	return user, true
+}
+
+func (h *Handler) HandleListApplications(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := h.authorizeEventManager(w, r, eventID); !ok {
+		return
+	}
+
+	apps, err := h.EventRepo.GetApplications(r.Context(), eventID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(apps)
+}
+
+func (h *Handler) HandleDecideApplications(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		EventID  int64   `json:"event_id"`
+		UserIDs  []int64 `json:"user_ids"`
+		Decision string  `json:"decision"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if len(req.UserIDs) == 0 {
+		http.Error(w, "user_ids is required", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := h.authorizeEventManager(w, r, req.EventID)
+	if !ok {
+		return
+	}
+
+	results, err := h.Service.DecideApplications(r.Context(), user.ID, req.EventID, req.UserIDs, req.Decision)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -3,15 +3,18 @@ package registration
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"time"
 
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/webhooks"
 )
 
 type Service struct {
 	DB            *sql.DB
 	Notifications *notifications.Service
+	Webhooks      *webhooks.Service
 }
 
 type RegisterResult struct {
@@ -20,35 +23,63 @@ type RegisterResult struct {
 }
 
 func (s *Service) RegisterUserForEvent(ctx context.Context, userID, eventID int64) (*RegisterResult, error) {
+	return s.RegisterWithResponses(ctx, userID, eventID, nil)
+}
+
+// RegisterWithResponses registers a user and stores their answers to the
+// event's custom registration fields. Events that require approval get a
+// PENDING application instead of a seat.
+func (s *Service) RegisterWithResponses(ctx context.Context, userID, eventID int64, formResponses map[string]string) (*RegisterResult, error) {
 	tx, err := s.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	var exists bool
-	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM registrations WHERE user_id=$1 AND event_id=$2 AND status='REGISTERED')", userID, eventID).Scan(&exists)
-	if err != nil {
+	var existing string
+	err = tx.QueryRowContext(ctx, "SELECT status FROM registrations WHERE user_id=$1 AND event_id=$2", userID, eventID).Scan(&existing)
+	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
-	if exists {
+	switch existing {
+	case "REGISTERED":
 		return nil, errors.New("user already registered")
+	case "PENDING":
+		return nil, errors.New("your application is already pending review")
+	case "REJECTED":
+		return nil, errors.New("your application for this event was not accepted")
+	}
+
+	// Someone already on the waitlist (including applicants an organizer
+	// waitlisted) keeps their place instead of applying again.
+	var waitlisted bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM waitlist WHERE user_id=$1 AND event_id=$2)", userID, eventID).Scan(&waitlisted)
+	if err != nil {
+		return nil, err
+	}
+	if waitlisted {
+		return &RegisterResult{Status: "WAITLISTED", Message: "You are already on the waitlist for this event."}, nil
 	}
 
 	var capacity int
-	var eventTitle string
-	var userEmail string
 	var visibility string
+	var requiresApproval bool
+	var organizationID int64
 
-	err = tx.QueryRowContext(ctx, "SELECT title, capacity, visibility FROM events WHERE id=$1", eventID).Scan(&eventTitle, &capacity, &visibility)
+	err = tx.QueryRowContext(ctx, "SELECT capacity, visibility, requires_approval, COALESCE(organization_id, 0) FROM events WHERE id=$1", eventID).Scan(&capacity, &visibility, &requiresApproval, &organizationID)
 	if err != nil {
 		return nil, errors.New("event not found")
 	}
+	event, err := eventVars(ctx, tx, eventID)
+	if err != nil {
+		return nil, err
+	}
 
-	err = tx.QueryRowContext(ctx, "SELECT email FROM users WHERE id=$1", userID).Scan(&userEmail)
+	rcpt, err := recipientFor(ctx, tx, userID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
+	userEmail := rcpt.Email
 
 	if visibility == "PRIVATE" {
 		var isInvited bool
@@ -60,6 +91,44 @@ func (s *Service) RegisterUserForEvent(ctx context.Context, userID, eventID int6
 			return nil, errors.New("this event is private and you are not invited")
 		}
 	}
+	if visibility == "MEMBERS" {
+		var isMember bool
+		err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM organization_members WHERE organization_id=$1 AND user_id=$2)", organizationID, userID).Scan(&isMember)
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			return nil, errors.New("this event is for members of the organization only")
+		}
+	}
+
+	responses := "{}"
+	if len(formResponses) > 0 {
+		b, err := json.Marshal(formResponses)
+		if err != nil {
+			return nil, err
+		}
+		responses = string(b)
+	}
+
+	if requiresApproval {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO registrations (user_id, event_id, status, form_responses, created_at, updated_at) VALUES ($1, $2, 'PENDING', $3, $4, $4)",
+			userID, eventID, responses, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.Notifications.Notify(ctx, tx, rcpt, notifications.TmplApplicationReceived, notifications.TemplateData{Event: event}); err != nil {
+			return nil, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+
+		return &RegisterResult{Status: "PENDING", Message: "Your application has been submitted for review."}, nil
+	}
 
 	var currentCount int
 	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM registrations WHERE event_id=$1 AND status='REGISTERED'", eventID).Scan(&currentCount)
@@ -69,15 +138,17 @@ func (s *Service) RegisterUserForEvent(ctx context.Context, userID, eventID int6
 
 	if currentCount < capacity {
 		_, err = tx.ExecContext(ctx,
-			"INSERT INTO registrations (user_id, event_id, status, created_at, updated_at) VALUES ($1, $2, 'REGISTERED', $3, $3)",
-			userID, eventID, time.Now())
+			"INSERT INTO registrations (user_id, event_id, status, form_responses, created_at, updated_at) VALUES ($1, $2, 'REGISTERED', $3, $4, $4)",
+			userID, eventID, responses, time.Now())
 		if err != nil {
 			return nil, err
 		}
 
-		msg := "Registration Confirmed! You are going to " + eventTitle
-		_, err = tx.ExecContext(ctx, "INSERT INTO notifications (user_id, message) VALUES ($1, $2)", userID, msg)
-		if err != nil {
+		if err := s.Notifications.Notify(ctx, tx, rcpt, notifications.TmplRegistrationConfirmed, notifications.TemplateData{Event: event}); err != nil {
+			return nil, err
+		}
+		hook := webhooks.RegistrationPayload{EventID: eventID, UserID: userID, UserEmail: userEmail, Status: "REGISTERED"}
+		if err := s.Webhooks.Emit(ctx, tx, webhooks.RegistrationCreated, eventID, hook); err != nil {
 			return nil, err
 		}
 
@@ -85,21 +156,17 @@ func (s *Service) RegisterUserForEvent(ctx context.Context, userID, eventID int6
 			return nil, err
 		}
 
-		s.Notifications.SendRegistrationEmail(userEmail, eventTitle)
-
 		return &RegisterResult{Status: "REGISTERED", Message: "You have successfully registered!"}, nil
 
 	} else {
 		_, err = tx.ExecContext(ctx,
-			"INSERT INTO waitlist (user_id, event_id, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
-			userID, eventID, time.Now())
+			"INSERT INTO waitlist (user_id, event_id, form_responses, created_at) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
+			userID, eventID, responses, time.Now())
 		if err != nil {
 			return nil, err
 		}
 
-		msg := "You are on the waitlist for " + eventTitle
-		_, err = tx.ExecContext(ctx, "INSERT INTO notifications (user_id, message) VALUES ($1, $2)", userID, msg)
-		if err != nil {
+		if err := s.Notifications.Notify(ctx, tx, rcpt, notifications.TmplRegistrationWaitlist, notifications.TemplateData{Event: event}); err != nil {
 			return nil, err
 		}
 
@@ -107,8 +174,6 @@ func (s *Service) RegisterUserForEvent(ctx context.Context, userID, eventID int6
 			return nil, err
 		}
 
-		s.Notifications.SendWaitlistEmail(userEmail, eventTitle)
-
 		return &RegisterResult{Status: "WAITLISTED", Message: "Event is full. You have been added to the waitlist."}, nil
 	}
 }
@@ -139,10 +204,27 @@ func (s *Service) CancelRegistration(ctx context.Context, userID, eventID int64)
 	}
 
 	if status == "REGISTERED" {
+		var email string
+		if err := tx.QueryRowContext(ctx, "SELECT email FROM users WHERE id=$1", userID).Scan(&email); err != nil {
+			return err
+		}
+		hook := webhooks.RegistrationPayload{EventID: eventID, UserID: userID, UserEmail: email, Status: "CANCELLED"}
+		if err := s.Webhooks.Emit(ctx, tx, webhooks.RegistrationCancelled, eventID, hook); err != nil {
+			return err
+		}
+	}
+
+	if status == "REGISTERED" {
+		// Skip anyone who somehow has a registration row already; promoting
+		// them would collide with it.
 		var nextUserID int64
-		err := tx.QueryRowContext(ctx,
-			"SELECT user_id FROM waitlist WHERE event_id=$1 ORDER BY created_at ASC LIMIT 1",
-			eventID).Scan(&nextUserID)
+		var responses string
+		err := tx.QueryRowContext(ctx, `
+			SELECT w.user_id, w.form_responses FROM waitlist w
+			WHERE w.event_id=$1
+			  AND NOT EXISTS (SELECT 1 FROM registrations r WHERE r.event_id = w.event_id AND r.user_id = w.user_id)
+			ORDER BY w.created_at ASC LIMIT 1`,
+			eventID).Scan(&nextUserID, &responses)
 
 		if err == nil {
 			_, err = tx.ExecContext(ctx, "DELETE FROM waitlist WHERE user_id=$1 AND event_id=$2", nextUserID, eventID)
@@ -151,23 +233,28 @@ func (s *Service) CancelRegistration(ctx context.Context, userID, eventID int64)
 			}
 
 			_, err = tx.ExecContext(ctx,
-				"INSERT INTO registrations (user_id, event_id, status, created_at, updated_at) VALUES ($1, $2, 'REGISTERED', $3, $3)",
-				nextUserID, eventID, time.Now())
+				"INSERT INTO registrations (user_id, event_id, status, form_responses, created_at, updated_at) VALUES ($1, $2, 'REGISTERED', $3, $4, $4)",
+				nextUserID, eventID, responses, time.Now())
 			if err != nil {
 				return err
 			}
 
-			var email, title string
-			tx.QueryRowContext(ctx, "SELECT email FROM users WHERE id=$1", nextUserID).Scan(&email)
-			tx.QueryRowContext(ctx, "SELECT title FROM events WHERE id=$1", eventID).Scan(&title)
-
-			msg := "Good news! You have been promoted off the waitlist for " + title
-			_, err = tx.ExecContext(ctx, "INSERT INTO notifications (user_id, message) VALUES ($1, $2)", nextUserID, msg)
+			rcpt, err := recipientFor(ctx, tx, nextUserID)
+			if err != nil {
+				return err
+			}
+			event, err := eventVars(ctx, tx, eventID)
 			if err != nil {
 				return err
 			}
 
-			s.Notifications.SendRegistrationEmail(email, title+" (Moved off Waitlist!)")
+			if err := s.Notifications.Notify(ctx, tx, rcpt, notifications.TmplWaitlistPromoted, notifications.TemplateData{Event: event}); err != nil {
+				return err
+			}
+			hook := webhooks.RegistrationPayload{EventID: eventID, UserID: nextUserID, UserEmail: rcpt.Email, Status: "REGISTERED"}
+			if err := s.Webhooks.Emit(ctx, tx, webhooks.WaitlistPromoted, eventID, hook); err != nil {
+				return err
+			}
 		} else if err != sql.ErrNoRows {
 			return err
 		}
@@ -175,3 +262,18 @@ func (s *Service) CancelRegistration(ctx context.Context, userID, eventID int64)
 
 	return tx.Commit()
 }
+
+// recipientFor loads the address, locale and time zone used to render a
+// user's notifications.
+func recipientFor(ctx context.Context, tx *sql.Tx, userID int64) (notifications.Recipient, error) {
+	rcpt := notifications.Recipient{UserID: userID}
+	err := tx.QueryRowContext(ctx, "SELECT email, locale, timezone FROM users WHERE id=$1", userID).Scan(&rcpt.Email, &rcpt.Locale, &rcpt.Timezone)
+	return rcpt, err
+}
+
+// eventVars loads the event fields that notification templates can use.
+func eventVars(ctx context.Context, tx *sql.Tx, eventID int64) (notifications.EventVars, error) {
+	ev := notifications.EventVars{ID: eventID}
+	err := tx.QueryRowContext(ctx, "SELECT title, location, start_time, end_time FROM events WHERE id=$1", eventID).Scan(&ev.Title, &ev.Location, &ev.StartTime, &ev.EndTime)
+	return ev, err
+}
@@ -0,0 +1,119 @@
+package rolemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+// Rule grants a role, an organization membership or both to users whose
+// token matches it. A rule matches on a claim value (the claim may be a
+// string or a list, e.g. Auth0 "permissions" or a namespaced roles or
+// groups claim) or on the domain of the user's email.
+type Rule struct {
+	Claim       string `json:"claim,omitempty"`
+	Value       string `json:"value,omitempty"`
+	EmailDomain string `json:"email_domain,omitempty"`
+
+	Role         string `json:"role,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	OrgRole      string `json:"org_role,omitempty"`
+}
+
+// Grants is what the matching rules give a user.
+type Grants struct {
+	// Role is the strongest role granted, empty if no rule grants one.
+	Role string
+	// Orgs maps organization names to the strongest role granted in them.
+	Orgs map[string]string
+}
+
+// ParseRules reads a JSON list of rules and checks each of them.
+func ParseRules(data []byte) ([]Rule, error) {
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		r := &rules[i]
+		if (r.Claim == "") == (r.EmailDomain == "") {
+			return nil, fmt.Errorf("rule %d: give either claim or email_domain", i+1)
+		}
+		if r.Claim != "" && r.Value == "" {
+			return nil, fmt.Errorf("rule %d: a claim rule needs a value", i+1)
+		}
+		if r.Role == "" && r.Organization == "" {
+			return nil, fmt.Errorf("rule %d: grants neither a role nor an organization", i+1)
+		}
+		if r.Role != "" && !authz.ValidRole(r.Role) {
+			return nil, fmt.Errorf("rule %d: unknown role %q", i+1, r.Role)
+		}
+		if r.Organization != "" {
+			if r.OrgRole == "" {
+				r.OrgRole = store.OrgMember
+			}
+			if r.OrgRole != store.OrgOfficer && r.OrgRole != store.OrgMember {
+				return nil, fmt.Errorf("rule %d: org_role must be OFFICER or MEMBER", i+1)
+			}
+		}
+		r.EmailDomain = strings.ToLower(strings.TrimPrefix(r.EmailDomain, "@"))
+	}
+	return rules, nil
+}
+
+// RulesFromEnv reads the rules from ROLE_MAPPING_RULES. Invalid rules are
+// logged and ignored, so a typo cannot grant anything.
+func RulesFromEnv() []Rule {
+	v := os.Getenv("ROLE_MAPPING_RULES")
+	if v == "" {
+		return nil
+	}
+	rules, err := ParseRules([]byte(v))
+	if err != nil {
+		log.Printf("⚠️ Warning: invalid ROLE_MAPPING_RULES, mapping no roles: %v", err)
+		return nil
+	}
+	return rules
+}
+
+// Evaluate applies the rules to a token's claims and verified email.
+func Evaluate(rules []Rule, claims map[string]any, email string) Grants {
+	g := Grants{Orgs: map[string]string{}}
+	for _, r := range rules {
+		if !r.matches(claims, email) {
+			continue
+		}
+		if r.Role != "" {
+			g.Role = authz.Strongest(g.Role, r.Role)
+		}
+		if r.Organization != "" && g.Orgs[r.Organization] != store.OrgOfficer {
+			g.Orgs[r.Organization] = r.OrgRole
+		}
+	}
+	return g
+}
+
+func (r Rule) matches(claims map[string]any, email string) bool {
+	if r.EmailDomain != "" {
+		_, domain, ok := strings.Cut(strings.ToLower(email), "@")
+		return ok && domain == r.EmailDomain
+	}
+	switch v := claims[r.Claim].(type) {
+	case string:
+		// Space-separated claims such as "scope" match any of their words.
+		return v == r.Value || slices.Contains(strings.Fields(v), r.Value)
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == r.Value {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+package rolemap
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/auth"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+// Kinds of change a sync reports.
+const (
+	ChangeRole         = "role"
+	ChangeOrganization = "organization"
+)
+
+// Change is one thing a sync changed, e.g. the role going from Member to
+// Organizer or a club membership being added (From empty) or removed (To
+// empty).
+type Change struct {
+	Kind         string `json:"kind"`
+	Organization string `json:"organization,omitempty"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+}
+
+// LogEntry is the report of one login that changed something.
+type LogEntry struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Changes   []Change  `json:"changes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Service applies the rules on every sync. Roles and memberships granted
+// here are kept apart from the ones assigned by hand: a claim can raise a
+// user above their manual role but never lowers it, and only memberships
+// with source CLAIM are added or removed. A lost claim never demotes a
+// protected account or the last active admin.
+type Service struct {
+	DB    *sql.DB
+	Users *store.UserRepository
+	Rules []Rule
+}
+
+func NewServiceFromEnv(db *sql.DB) *Service {
+	return &Service{DB: db, Users: store.NewUserRepository(db), Rules: RulesFromEnv()}
+}
+
+// Apply brings the user's claim role and claim memberships in line with
+// their token, logs what changed and updates user.Role. Email domain rules
+// match the token's verified email, not the one stored for the user.
+func (s *Service) Apply(ctx context.Context, user *store.User, claims map[string]any) ([]Change, error) {
+	grants := Evaluate(s.Rules, claims, auth.VerifiedEmail(claims))
+	changes := []Change{}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var role, manual, claimRole string
+	var protected bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT role, COALESCE(manual_role, role), COALESCE(claim_role, ''), is_protected FROM users WHERE id = $1",
+		user.ID).Scan(&role, &manual, &claimRole, &protected)
+	if err != nil {
+		return nil, err
+	}
+	effective := authz.Strongest(manual, grants.Role)
+	if protected {
+		effective = authz.Strongest(effective, role)
+	}
+	if claimRole != grants.Role || role != effective {
+		err := s.Users.SetClaimRole(ctx, tx, user.ID, grants.Role, effective)
+		if errors.Is(err, store.ErrLastAdmin) {
+			log.Printf("⚠️ Warning: role mapping kept %s as the last active admin", user.Email)
+			effective = role
+			err = s.Users.SetClaimRole(ctx, tx, user.ID, grants.Role, effective)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if role != effective {
+		changes = append(changes, Change{Kind: ChangeRole, From: role, To: effective})
+	}
+
+	orgChanges, err := s.syncMemberships(ctx, tx, user.ID, grants.Orgs)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, orgChanges...)
+
+	if len(changes) > 0 {
+		data, _ := json.Marshal(changes)
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO identity_sync_log (user_id, changes) VALUES ($1, $2)", user.ID, data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	user.Role, user.ManualRole, user.ClaimRole = effective, manual, grants.Role
+	return changes, nil
+}
+
+// syncMemberships adds, updates and removes the user's CLAIM memberships.
+// Memberships added by hand, and ownership, are left alone.
+func (s *Service) syncMemberships(ctx context.Context, tx *sql.Tx, userID int64, granted map[string]string) ([]Change, error) {
+	type membership struct {
+		orgID  int64
+		role   string
+		source string
+	}
+	current := map[string]membership{}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT o.name, m.organization_id, m.role, m.source
+		FROM organization_members m JOIN organizations o ON o.id = m.organization_id
+		WHERE m.user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var name string
+		var m membership
+		if err := rows.Scan(&name, &m.orgID, &m.role, &m.source); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		current[name] = m
+	}
+	rows.Close()
+
+	var changes []Change
+	for name, role := range granted {
+		m, ok := current[name]
+		switch {
+		case ok && m.source != "CLAIM":
+			continue
+		case ok && m.role == role:
+			continue
+		case ok:
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE organization_members SET role = $1 WHERE organization_id = $2 AND user_id = $3",
+				role, m.orgID, userID); err != nil {
+				return nil, err
+			}
+		default:
+			res, err := tx.ExecContext(ctx, `
+				INSERT INTO organization_members (organization_id, user_id, role, source)
+				SELECT id, $2, $3, 'CLAIM' FROM organizations WHERE name = $1
+			`, name, userID, role)
+			if err != nil {
+				return nil, err
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				log.Printf("⚠️ Warning: role mapping grants unknown organization %q", name)
+				continue
+			}
+		}
+		changes = append(changes, Change{Kind: ChangeOrganization, Organization: name, From: m.role, To: role})
+	}
+	for name, m := range current {
+		if _, ok := granted[name]; ok || m.source != "CLAIM" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx,
+			"DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2 AND source = 'CLAIM'",
+			m.orgID, userID); err != nil {
+			return nil, err
+		}
+		changes = append(changes, Change{Kind: ChangeOrganization, Organization: name, From: m.role})
+	}
+	return changes, nil
+}
+
+// Log returns the latest sync reports, for one user or (userID 0) everyone.
+func (s *Service) Log(ctx context.Context, userID int64, limit int) ([]*LogEntry, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, user_id, changes, created_at FROM identity_sync_log
+		WHERE $1 = 0 OR user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []*LogEntry{}
+	for rows.Next() {
+		var e LogEntry
+		var data []byte
+		if err := rows.Scan(&e.ID, &e.UserID, &data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &e.Changes); err != nil {
+			return nil, err
+		}
+		list = append(list, &e)
+	}
+	return list, rows.Err()
+}
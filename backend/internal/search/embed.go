@@ -0,0 +1,242 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+)
+
+// Embedder turns text into vectors whose cosine similarity reflects how
+// related the texts are. Name identifies the model; vectors from different
+// models are never compared. Implementations must be safe for concurrent
+// use.
+type Embedder interface {
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedderFromEnv picks an embedder based on EMBEDDING_PROVIDER: "hash"
+// (the default, local and deterministic) or "openai" (any OpenAI-compatible
+// /embeddings endpoint at EMBEDDING_BASE_URL). Without an API key the
+// openai embedder falls back to hash.
+func NewEmbedderFromEnv() Embedder {
+	if strings.ToLower(os.Getenv("EMBEDDING_PROVIDER")) != "openai" {
+		return &HashEmbedder{Dims: 256}
+	}
+	key := os.Getenv("EMBEDDING_API_KEY")
+	if key == "" {
+		key = os.Getenv("AI_API_KEY")
+	}
+	if key == "" {
+		log.Println("⚠️ Warning: EMBEDDING_PROVIDER=openai without an API key, using the hash embedder.")
+		return &HashEmbedder{Dims: 256}
+	}
+	return &OpenAIEmbedder{
+		BaseURL: envOr("EMBEDDING_BASE_URL", "https://api.openai.com/v1"),
+		Model:   envOr("EMBEDDING_MODEL", "text-embedding-3-small"),
+		APIKey:  key,
+	}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// HashEmbedder hashes words, and the concepts they belong to, into a fixed
+// number of dimensions. It needs no model, so it is used in tests and
+// offline, and its concept groups let "chill music" find a "jazz lounge".
+type HashEmbedder struct {
+	Dims int
+}
+
+func (h *HashEmbedder) Name() string { return fmt.Sprintf("hash-%d", h.Dims) }
+
+func (h *HashEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = h.vector(t)
+	}
+	return out, nil
+}
+
+func (h *HashEmbedder) vector(text string) []float32 {
+	v := make([]float32, h.Dims)
+	add := func(feature string, weight float32) {
+		f := fnv.New64a()
+		f.Write([]byte(feature))
+		sum := f.Sum64()
+		sign := float32(1)
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		v[sum%uint64(h.Dims)] += sign * weight
+	}
+	for _, tok := range Tokens(text) {
+		add(tok, 1)
+		for _, c := range concepts[tok] {
+			add("#"+c, 1)
+		}
+	}
+
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm > 0 {
+		n := float32(math.Sqrt(norm))
+		for i := range v {
+			v[i] /= n
+		}
+	}
+	return v
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "at": true, "be": true, "for": true, "from": true,
+	"i": true, "in": true, "is": true, "it": true, "me": true, "my": true, "of": true, "on": true,
+	"or": true, "something": true, "some": true, "the": true, "this": true, "to": true, "want": true,
+	"we": true, "what": true, "with": true, "anything": true, "event": true, "events": true,
+}
+
+// Tokens lowercases text, splits it into words and drops stopwords and
+// plural endings.
+func Tokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := words[:0]
+	for _, w := range words {
+		if stopwords[w] {
+			continue
+		}
+		switch {
+		case len(w) > 4 && strings.HasSuffix(w, "ies"):
+			w = strings.TrimSuffix(w, "ies") + "y"
+		case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss"):
+			w = strings.TrimSuffix(w, "s")
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// conceptGroups lists words (after Tokens) that mean roughly the same thing
+// when students look for events.
+var conceptGroups = map[string][]string{
+	"music":     {"music", "musical", "concert", "band", "jazz", "dj", "acoustic", "song", "sing", "singing", "karaoke", "choir", "orchestra", "gig", "rock", "rap", "mic", "live"},
+	"chill":     {"chill", "relax", "relaxed", "relaxing", "calm", "casual", "cozy", "cosy", "laid", "mellow", "quiet", "unwind", "lounge", "meditation", "yoga", "hangout"},
+	"party":     {"party", "social", "mixer", "celebration", "dance", "dancing", "fest", "festival", "gala", "fun"},
+	"food":      {"food", "pizza", "snack", "dinner", "lunch", "breakfast", "brunch", "cook", "cooking", "bake", "baking", "potluck", "tasting", "coffee", "tea", "eat"},
+	"sport":     {"sport", "game", "match", "tournament", "basketball", "soccer", "football", "tennis", "run", "running", "fitness", "workout", "gym", "athletic"},
+	"tech":      {"tech", "technology", "coding", "code", "programming", "hackathon", "software", "ai", "robotic", "robotics", "computer", "data", "python", "rust", "web", "developer"},
+	"learn":     {"workshop", "talk", "lecture", "seminar", "class", "tutorial", "course", "study", "learn", "learning", "training", "panel"},
+	"art":       {"art", "painting", "drawing", "gallery", "exhibition", "photography", "film", "movie", "theater", "theatre", "poetry", "craft"},
+	"career":    {"career", "job", "internship", "resume", "networking", "recruiter", "interview", "fair", "employer"},
+	"outdoor":   {"outdoor", "outdoors", "outside", "park", "garden", "picnic", "nature", "hike", "hiking", "camping", "trail"},
+	"volunteer": {"volunteer", "volunteering", "charity", "community", "service", "fundraiser", "donation", "donate"},
+	"weekend":   {"weekend", "saturday", "sunday"},
+	"evening":   {"evening", "tonight", "night", "late"},
+	"morning":   {"morning", "early", "breakfast"},
+}
+
+var concepts = func() map[string][]string {
+	m := map[string][]string{}
+	for c, words := range conceptGroups {
+		for _, w := range words {
+			m[w] = append(m[w], c)
+		}
+	}
+	return m
+}()
+
+// OpenAIEmbedder calls an OpenAI-compatible /embeddings endpoint.
+type OpenAIEmbedder struct {
+	BaseURL string
+	Model   string
+	APIKey  string
+	HTTP    *http.Client
+}
+
+func (p *OpenAIEmbedder) Name() string { return "openai:" + p.Model }
+
+func (p *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{"model": p.Model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(p.BaseURL, "/")+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+
+	client := p.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &ai.StatusError{Code: resp.StatusCode, Body: string(msg)}
+	}
+
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	vecs := make([][]float32, len(texts))
+	for _, d := range out.Data {
+		if d.Index >= 0 && d.Index < len(vecs) {
+			vecs[d.Index] = d.Embedding
+		}
+	}
+	for i, v := range vecs {
+		if v == nil {
+			return nil, fmt.Errorf("search: no embedding for input %d", i)
+		}
+	}
+	return vecs, nil
+}
+
+// Cosine is the cosine similarity of two vectors, 0 if their lengths
+// differ or either is zero.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
@@ -0,0 +1,135 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Index keeps one embedding per event in event_embeddings, tagged with the
+// embedder's name and a hash of the text it was built from, so edited
+// events and a change of model are picked up by IndexBatch.
+type Index struct {
+	DB       *sql.DB
+	Embedder Embedder
+	// Location is the campus time zone, used to describe when events are
+	// ("friday evening").
+	Location *time.Location
+}
+
+// NewIndexFromEnv uses NewEmbedderFromEnv and CAMPUS_TIMEZONE (default
+// UTC).
+func NewIndexFromEnv(db *sql.DB) *Index {
+	idx := &Index{DB: db, Embedder: NewEmbedderFromEnv(), Location: time.UTC}
+	if tz := os.Getenv("CAMPUS_TIMEZONE"); tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			idx.Location = loc
+		} else {
+			log.Printf("⚠️ Warning: invalid CAMPUS_TIMEZONE %q, using UTC", tz)
+		}
+	}
+	return idx
+}
+
+// sourceHash fingerprints the columns EventText reads.
+const sourceHash = `MD5(CONCAT_WS('|', e.title, e.description, e.category, e.location, ARRAY_TO_STRING(e.tags, ','), e.start_time::text))`
+
+// EventText is what gets embedded for an event.
+func (idx *Index) EventText(title, description, category, location string, tags []string, start time.Time) string {
+	loc := idx.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	start = start.In(loc)
+	when := strings.ToLower(start.Weekday().String())
+	switch h := start.Hour(); {
+	case h < 12:
+		when += " morning"
+	case h < 17:
+		when += " afternoon"
+	default:
+		when += " evening"
+	}
+	return strings.Join([]string{title, category, strings.Join(tags, " "), description, location, when}, "\n")
+}
+
+// IndexBatch embeds up to limit events that have no embedding from the
+// current embedder or whose text changed, and returns how many it stored.
+func (idx *Index) IndexBatch(ctx context.Context, limit int) (int, error) {
+	model := idx.Embedder.Name()
+	rows, err := idx.DB.QueryContext(ctx, `
+		SELECT e.id, e.title, COALESCE(e.description, ''), COALESCE(e.category, ''), COALESCE(e.location, ''), e.tags, e.start_time,
+		       `+sourceHash+`
+		FROM events e
+		LEFT JOIN event_embeddings ee ON ee.event_id = e.id
+		WHERE ee.event_id IS NULL OR ee.model <> $1 OR ee.source_hash <> `+sourceHash+`
+		ORDER BY e.id
+		LIMIT $2
+	`, model, limit)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	var hashes, texts []string
+	for rows.Next() {
+		var id int64
+		var title, desc, category, location, hash string
+		var tags []string
+		var start time.Time
+		if err := rows.Scan(&id, &title, &desc, &category, &location, pq.Array(&tags), &start, &hash); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		hashes = append(hashes, hash)
+		texts = append(texts, idx.EventText(title, desc, category, location, tags, start))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	vecs, err := idx.Embedder.Embed(ctx, texts)
+	if err != nil {
+		return 0, fmt.Errorf("embed events: %w", err)
+	}
+	for i, id := range ids {
+		_, err := idx.DB.ExecContext(ctx, `
+			INSERT INTO event_embeddings (event_id, model, source_hash, embedding) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (event_id) DO UPDATE
+			SET model = EXCLUDED.model, source_hash = EXCLUDED.source_hash, embedding = EXCLUDED.embedding, updated_at = NOW()
+		`, id, model, hashes[i], pq.Array(vecs[i]))
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+// Vectors returns the stored embeddings of the given events made by the
+// current embedder. Events not indexed yet are missing from the map.
+func (idx *Index) Vectors(ctx context.Context, ids []int64) (map[int64][]float32, error) {
+	rows, err := idx.DB.QueryContext(ctx,
+		"SELECT event_id, embedding FROM event_embeddings WHERE event_id = ANY($1) AND model = $2",
+		pq.Array(ids), idx.Embedder.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[int64][]float32, len(ids))
+	for rows.Next() {
+		var id int64
+		var v []float32
+		if err := rows.Scan(&id, pq.Array(&v)); err != nil {
+			return nil, err
+		}
+		out[id] = v
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,105 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+// Search modes. Keyword search is EventRepository.Search and is not
+// handled here.
+const (
+	ModeKeyword  = "keyword"
+	ModeSemantic = "semantic"
+	ModeHybrid   = "hybrid"
+)
+
+var ErrInvalidMode = errors.New("mode must be keyword, semantic or hybrid")
+
+const (
+	// hybridWeight is the share of the score that comes from embeddings;
+	// the rest is the fraction of query words found in the event.
+	hybridWeight = 0.7
+	minScore     = 0.15
+	maxResults   = 50
+)
+
+// Result is an event with its search score.
+type Result struct {
+	*store.Event
+	Score float64 `json:"search_score"`
+}
+
+type Service struct {
+	Repo  *store.EventRepository
+	Index *Index
+}
+
+// Search ranks the events that pass the location and category filters by
+// similarity to q. Events not indexed yet have no semantic score: hybrid
+// search still matches them on keywords, semantic search skips them.
+func (s *Service) Search(ctx context.Context, q, location, category, mode string) ([]*Result, error) {
+	if mode != ModeSemantic && mode != ModeHybrid {
+		return nil, ErrInvalidMode
+	}
+	results := []*Result{}
+	if strings.TrimSpace(q) == "" {
+		return results, nil
+	}
+
+	candidates, err := s.Repo.Search(ctx, "", location, category)
+	if err != nil || len(candidates) == 0 {
+		return results, err
+	}
+	qvecs, err := s.Index.Embedder.Embed(ctx, []string{q})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(candidates))
+	for i, e := range candidates {
+		ids[i] = e.ID
+	}
+	vecs, err := s.Index.Vectors(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	qtokens := Tokens(q)
+	for _, e := range candidates {
+		semantic := Cosine(qvecs[0], vecs[e.ID])
+		score := semantic
+		if mode == ModeHybrid {
+			text := s.Index.EventText(e.Title, e.Description, e.Category, e.Location, e.Tags, e.StartTime)
+			score = hybridWeight*semantic + (1-hybridWeight)*keywordScore(qtokens, text)
+		}
+		if score >= minScore {
+			results = append(results, &Result{Event: e, Score: score})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	return results, nil
+}
+
+// keywordScore is the fraction of query words that appear in text.
+func keywordScore(query []string, text string) float64 {
+	if len(query) == 0 {
+		return 0
+	}
+	words := map[string]bool{}
+	for _, t := range Tokens(text) {
+		words[t] = true
+	}
+	found := 0
+	for _, t := range query {
+		if words[t] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(query))
+}
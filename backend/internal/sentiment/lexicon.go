@@ -0,0 +1,76 @@
+package sentiment
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Labels match what the model is asked to return.
+const (
+	Positive = "POSITIVE"
+	Neutral  = "NEUTRAL"
+	Negative = "NEGATIVE"
+)
+
+var positiveWords = wordSet(`amazing awesome beautiful best brilliant clear cool delicious engaging enjoy enjoyed
+	enjoyable excellent exciting fantastic fun glad good great helpful informative insightful inspiring interesting
+	love loved lovely nice organized perfect pleasant recommend smooth superb thanks thank useful valuable
+	welcoming well wonderful worth`)
+
+var negativeWords = wordSet(`annoying awful bad boring broken cancelled chaotic cold confusing crowded
+	disappointed disappointing disorganized dull hate hated horrible late long loud mess messy noisy poor
+	rude slow terrible tired uncomfortable unclear unhelpful useless waste worse worst wrong`)
+
+var negations = wordSet(`not no never nothing hardly barely isnt wasnt dont didnt doesnt cant couldnt wont`)
+
+// Lexicon scores text by counting positive and negative words, flipping a
+// word that follows a negation ("not good"). It is the fallback when no
+// model is configured, so it only has to be roughly right.
+func Lexicon(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(strings.ReplaceAll(text, "'", "")), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	score := 0
+	for i, w := range words {
+		v := 0
+		switch {
+		case positiveWords[w]:
+			v = 1
+		case negativeWords[w]:
+			v = -1
+		default:
+			continue
+		}
+		if negated(words, i) {
+			v = -v
+		}
+		score += v
+	}
+
+	switch {
+	case score > 0:
+		return Positive
+	case score < 0:
+		return Negative
+	}
+	return Neutral
+}
+
+// negated reports whether one of the two words before words[i] negates it.
+func negated(words []string, i int) bool {
+	for j := i - 1; j >= 0 && j >= i-2; j-- {
+		if negations[words[j]] {
+			return true
+		}
+	}
+	return false
+}
+
+func wordSet(list string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(list) {
+		set[w] = true
+	}
+	return set
+}
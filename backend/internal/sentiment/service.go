@@ -0,0 +1,62 @@
+package sentiment
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+)
+
+// Sources record who produced a label, so lexicon labels can be re-scored
+// once a model is configured.
+const (
+	SourceAI      = "ai"
+	SourceLexicon = "lexicon"
+)
+
+// Scorer labels comments with the model when one is configured, and with
+// the lexicon otherwise.
+type Scorer struct {
+	AI *ai.Service
+}
+
+// Score returns the label and its source. Model errors are returned as is;
+// the caller decides whether to retry or use Fallback.
+func (s *Scorer) Score(ctx context.Context, text string) (string, string, error) {
+	if strings.TrimSpace(text) == "" {
+		return Neutral, SourceLexicon, nil
+	}
+	if !s.AI.Available() {
+		return s.Fallback(text)
+	}
+	label, err := s.AI.ClassifySentiment(ctx, text)
+	if err != nil {
+		return "", "", err
+	}
+	return label, SourceAI, nil
+}
+
+// Fallback scores with the lexicon.
+func (s *Scorer) Fallback(text string) (string, string, error) {
+	return Lexicon(text), SourceLexicon, nil
+}
+
+// Requeue marks historical feedback for re-scoring by the background
+// scorer. By default only feedback without a model label is requeued (the
+// lexicon's, and feedback from before scoring was queued, which got
+// NEUTRAL when no key was set); all requeues everything. eventID 0 means
+// every event.
+func Requeue(ctx context.Context, db *sql.DB, eventID int64, all bool) (int64, error) {
+	res, err := db.ExecContext(ctx, `
+		UPDATE event_feedback
+		SET sentiment_status = 'PENDING', sentiment_attempts = 0, sentiment_error = NULL, sentiment_next_attempt_at = NOW()
+		WHERE ($1 = 0 OR event_id = $1)
+		  AND ($2 OR sentiment_source IS DISTINCT FROM 'ai')
+		  AND sentiment_status <> 'PENDING'
+	`, eventID, all)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
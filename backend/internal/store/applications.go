@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Application is a PENDING registration awaiting an organizer decision.
+type Application struct {
+	UserID        int64             `json:"user_id"`
+	Email         string            `json:"email"`
+	Status        string            `json:"status"`
+	FormResponses map[string]string `json:"form_responses"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// GetApplications returns the review queue for an event, oldest first.
+func (r *EventRepository) GetApplications(ctx context.Context, eventID int64) ([]*Application, error) {
+	query := `
+        SELECT u.id, u.email, CAST(r.status AS TEXT), COALESCE(r.form_responses, '{}'), r.created_at
+        FROM registrations r
+        JOIN users u ON r.user_id = u.id
+        WHERE r.event_id = $1 AND r.status = 'PENDING'
+        ORDER BY r.created_at ASC
+    `
+	rows, err := r.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apps []*Application
+	for rows.Next() {
+		var a Application
+		var responses string
+		if err := rows.Scan(&a.UserID, &a.Email, &a.Status, &responses, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(responses), &a.FormResponses)
+		apps = append(apps, &a)
+	}
+	return apps, nil
+}
@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Event link kinds. A DUPLICATE link says two events are the same thing
+// posted twice; MERGED points from a cancelled event to the one its
+// attendees were moved to.
+const (
+	LinkDuplicate = "DUPLICATE"
+	LinkMerged    = "MERGED"
+)
+
+var ErrMergeConflict = errors.New("only upcoming or in-progress events can be merged")
+
+// EventSummary is the part of an event shown next to possible duplicates
+// and links.
+type EventSummary struct {
+	ID          int64     `json:"id"`
+	Title       string    `json:"title"`
+	Location    string    `json:"location"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	OrganizerID int64     `json:"organizer_id"`
+	Status      string    `json:"status"`
+	Visibility  string    `json:"visibility"`
+}
+
+// LinkedEvent is the other side of an event link.
+type LinkedEvent struct {
+	EventSummary
+	Kind string `json:"kind"`
+	// MergedInto is true when this event is where the asked-about event
+	// was merged to.
+	MergedInto bool `json:"merged_into"`
+}
+
+// DuplicateCandidates returns active events whose time window overlaps
+// [start-slack, end+slack], excluding excludeID. Private events are only
+// included for their organizer (viewerID) and Admins.
+func (r *EventRepository) DuplicateCandidates(ctx context.Context, start, end time.Time, slack time.Duration, excludeID, viewerID int64, admin bool) ([]*EventSummary, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, title, COALESCE(location, ''), start_time, end_time, organizer_id, status, visibility
+		FROM events
+		WHERE status IN ('UPCOMING', 'IN_PROGRESS')
+		  AND start_time < $2 AND end_time > $1
+		  AND id <> $3
+		  AND (visibility = 'PUBLIC' OR organizer_id = $4 OR $5)
+		ORDER BY start_time
+		LIMIT 100
+	`, start.Add(-slack), end.Add(slack), excludeID, viewerID, admin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*EventSummary
+	for rows.Next() {
+		var e EventSummary
+		if err := rows.Scan(&e.ID, &e.Title, &e.Location, &e.StartTime, &e.EndTime, &e.OrganizerID, &e.Status, &e.Visibility); err != nil {
+			return nil, err
+		}
+		list = append(list, &e)
+	}
+	return list, rows.Err()
+}
+
+// LinkEvents records that two events are duplicates of each other.
+func (r *EventRepository) LinkEvents(ctx context.Context, a, b, userID int64) error {
+	if a > b {
+		a, b = b, a
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO event_links (event_id, linked_event_id, kind, created_by) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_id, linked_event_id) DO NOTHING
+	`, a, b, LinkDuplicate, userID)
+	return err
+}
+
+// GetLinkedEvents returns the events linked to eventID.
+func (r *EventRepository) GetLinkedEvents(ctx context.Context, eventID int64) ([]*LinkedEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT e.id, e.title, COALESCE(e.location, ''), e.start_time, e.end_time, e.organizer_id, e.status, e.visibility,
+		       l.kind, l.kind = 'MERGED' AND l.event_id = $1
+		FROM event_links l
+		JOIN events e ON e.id = CASE WHEN l.event_id = $1 THEN l.linked_event_id ELSE l.event_id END
+		WHERE l.event_id = $1 OR l.linked_event_id = $1
+		ORDER BY e.start_time
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []*LinkedEvent{}
+	for rows.Next() {
+		var e LinkedEvent
+		if err := rows.Scan(&e.ID, &e.Title, &e.Location, &e.StartTime, &e.EndTime, &e.OrganizerID, &e.Status, &e.Visibility,
+			&e.Kind, &e.MergedInto); err != nil {
+			return nil, err
+		}
+		list = append(list, &e)
+	}
+	return list, rows.Err()
+}
+
+// MergeEvents moves the registrations, applications and waitlist of source
+// to target, cancels source and links it to target. Users already on the
+// target keep their place there. The target's capacity grows if needed so
+// nobody loses a confirmed seat. It returns the users who were moved.
+func (r *EventRepository) MergeEvents(ctx context.Context, sourceID, targetID, userID int64) ([]int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var active int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT id FROM events WHERE id IN ($1, $2) AND status IN ('UPCOMING', 'IN_PROGRESS') ORDER BY id FOR UPDATE
+		) locked
+	`, sourceID, targetID).Scan(&active)
+	if err != nil {
+		return nil, err
+	}
+	if active != 2 {
+		return nil, ErrMergeConflict
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		INSERT INTO registrations (user_id, event_id, status, form_responses, created_at, updated_at)
+		SELECT user_id, $2, status, form_responses, created_at, NOW()
+		FROM registrations WHERE event_id = $1 AND status IN ('REGISTERED', 'PENDING')
+		ON CONFLICT (user_id, event_id) DO UPDATE SET status = EXCLUDED.status, updated_at = NOW()
+		WHERE registrations.status = 'CANCELLED'
+		RETURNING user_id
+	`, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	moved, err := scanIDs(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, `
+		INSERT INTO waitlist (user_id, event_id, form_responses, created_at)
+		SELECT w.user_id, $2, w.form_responses, w.created_at FROM waitlist w
+		WHERE w.event_id = $1
+		  AND NOT EXISTS (SELECT 1 FROM registrations r WHERE r.event_id = $2 AND r.user_id = w.user_id AND r.status <> 'CANCELLED')
+		ON CONFLICT (user_id, event_id) DO NOTHING
+		RETURNING user_id
+	`, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	waitlisted, err := scanIDs(rows)
+	if err != nil {
+		return nil, err
+	}
+	moved = append(moved, waitlisted...)
+
+	steps := []struct {
+		query string
+		args  []any
+	}{
+		{`UPDATE events SET capacity = GREATEST(capacity,
+			(SELECT COUNT(*) FROM registrations WHERE event_id = $1 AND status = 'REGISTERED')), updated_at = NOW()
+		  WHERE id = $1`, []any{targetID}},
+		{`UPDATE registrations SET status = 'CANCELLED', updated_at = NOW() WHERE event_id = $1 AND status IN ('REGISTERED', 'PENDING')`, []any{sourceID}},
+		{`DELETE FROM waitlist WHERE event_id = $1`, []any{sourceID}},
+		{`UPDATE events SET status = 'CANCELLED', updated_at = NOW() WHERE id = $1`, []any{sourceID}},
+		{`DELETE FROM event_links WHERE (event_id = $1 AND linked_event_id = $2) OR (event_id = $2 AND linked_event_id = $1)`, []any{sourceID, targetID}},
+	}
+	for _, step := range steps {
+		if _, err := tx.ExecContext(ctx, step.query, step.args...); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO event_links (event_id, linked_event_id, kind, created_by) VALUES ($1, $2, $3, $4)",
+		sourceID, targetID, LinkMerged, userID); err != nil {
+		return nil, err
+	}
+	return moved, tx.Commit()
+}
+
+func scanIDs(rows *sql.Rows) ([]int64, error) {
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
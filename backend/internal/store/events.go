@@ -9,11 +9,14 @@ import (
 	"fmt"
 	"io"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type CustomField struct {
-	Label string `json:"label"`
-	Type  string `json:"type"`
+	Label    string `json:"label"`
+	Type     string `json:"type"` // text, number, boolean
+	Required bool   `json:"required"`
 }
 
 type TicketDef struct {
@@ -33,14 +36,21 @@ type Event struct {
 	Status          string    `json:"status"`
 	Visibility      string    `json:"visibility"`
 	Category        string    `json:"category"`
+	Tags            []string  `json:"tags"`
 	RegisteredCount int       `json:"registered_count"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 
 	// 👇 NEW FIELDS for Advanced Tools
-	IsRecurring  bool          `json:"is_recurring"`
-	CustomFields []CustomField `json:"custom_fields"`
-	TicketTypes  []TicketDef   `json:"ticket_types"`
+	IsRecurring      bool          `json:"is_recurring"`
+	CustomFields     []CustomField `json:"custom_fields"`
+	TicketTypes      []TicketDef   `json:"ticket_types"`
+	RequiresApproval bool          `json:"requires_approval"`
+	ReminderNote     string        `json:"reminder_note"`
+
+	// OrganizationID is the club or department that owns the event, 0 for
+	// events that belong to their organizer alone.
+	OrganizationID int64 `json:"organization_id,omitempty"`
 
 	// Internal fields for DB marshaling (not exposed to JSON API directly usually, but kept for clarity)
 	CustomFieldsJSON string `json:"-"`
@@ -55,6 +65,9 @@ type Feedback struct {
 	Comment   string    `json:"comment"`
 	Sentiment string    `json:"sentiment"`
 	CreatedAt time.Time `json:"created_at"`
+	// Status is the moderation status; empty means PUBLISHED.
+	Status       string `json:"status"`
+	StatusReason string `json:"-"`
 }
 
 type SystemStats struct {
@@ -86,6 +99,9 @@ type Comment struct {
 	UserEmail string    `json:"user_email"` // Helper field for UI
 	Text      string    `json:"text"`
 	CreatedAt time.Time `json:"created_at"`
+	// Status is the moderation status; empty means PUBLISHED.
+	Status       string `json:"status"`
+	StatusReason string `json:"-"`
 }
 
 type Photo struct {
@@ -93,6 +109,9 @@ type Photo struct {
 	EventID   int64     `json:"event_id"`
 	URL       string    `json:"url"`
 	CreatedAt time.Time `json:"created_at"`
+	// Status is the moderation status; empty means PUBLISHED.
+	Status       string `json:"status"`
+	StatusReason string `json:"-"`
 }
 
 func NewEventRepository(db *sql.DB) *EventRepository {
@@ -108,6 +127,14 @@ func toJSON(v interface{}) string {
 	return string(b)
 }
 
+// tagsOrEmpty keeps NULL out of the NOT NULL tags column.
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
 func (r *EventRepository) Create(ctx context.Context, e *Event) error {
 	// 1. Prepare JSON fields
 	e.CustomFieldsJSON = toJSON(e.CustomFields)
@@ -118,9 +145,9 @@ func (r *EventRepository) Create(ctx context.Context, e *Event) error {
            title, description, location, start_time, end_time, capacity, organizer_id, 
            status, visibility, category, 
            is_recurring, custom_fields_schema, ticket_types_schema, -- New Columns
-           created_at, updated_at
+           requires_approval, reminder_note, created_at, updated_at, tags, organization_id
        )
-       VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+       VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, NULLIF($19, 0))
        RETURNING id, created_at, updated_at
     `
 	now := time.Now()
@@ -128,7 +155,7 @@ func (r *EventRepository) Create(ctx context.Context, e *Event) error {
 		e.Title, e.Description, e.Location, e.StartTime, e.EndTime, e.Capacity, e.OrganizerID,
 		e.Status, e.Visibility, e.Category,
 		e.IsRecurring, e.CustomFieldsJSON, e.TicketTypesJSON, // New Values
-		now, now,
+		e.RequiresApproval, e.ReminderNote, now, now, pq.Array(tagsOrEmpty(e.Tags)), e.OrganizationID,
 	).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
 }
 
@@ -142,27 +169,41 @@ func (r *EventRepository) Update(ctx context.Context, e *Event) error {
        SET title=$1, description=$2, location=$3, start_time=$4, end_time=$5, capacity=$6, 
            visibility=$7, category=$8, 
            is_recurring=$9, custom_fields_schema=$10, ticket_types_schema=$11, -- New Columns
-           updated_at=NOW()
-       WHERE id=$12
+           requires_approval=$12, reminder_note=$13, tags=$15, organization_id=NULLIF($16, 0), updated_at=NOW()
+       WHERE id=$14
     `
 	_, err := r.db.ExecContext(ctx, query,
 		e.Title, e.Description, e.Location, e.StartTime, e.EndTime, e.Capacity,
 		e.Visibility, e.Category,
 		e.IsRecurring, e.CustomFieldsJSON, e.TicketTypesJSON, // New Values
-		e.ID,
+		e.RequiresApproval, e.ReminderNote, e.ID, pq.Array(tagsOrEmpty(e.Tags)), e.OrganizationID,
 	)
 	return err
 }
 
+// CancelEvent marks an upcoming or running event as cancelled.
+func (r *EventRepository) CancelEvent(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE events SET status='CANCELLED', updated_at=NOW() WHERE id=$1 AND status IN ('UPCOMING', 'IN_PROGRESS')", id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New("event is already completed or cancelled")
+	}
+	return nil
+}
+
 func (r *EventRepository) Search(ctx context.Context, query, location, category string) ([]*Event, error) {
 	// Updated SELECT to include new columns
 	sqlQuery := `
        SELECT e.id, e.title, e.description, e.location, e.start_time, e.end_time, 
               e.capacity, e.organizer_id, e.status, e.visibility, e.category,
               e.is_recurring, e.custom_fields_schema, e.ticket_types_schema, -- New Columns
+              e.requires_approval, e.reminder_note, e.tags, COALESCE(e.organization_id, 0),
               (SELECT COUNT(*) FROM registrations WHERE event_id = e.id AND status = 'REGISTERED') as registered_count
        FROM events e
-       WHERE 1=1
+       WHERE e.visibility <> 'MEMBERS'
     `
 	args := []interface{}{}
 	argId := 1
@@ -199,6 +240,7 @@ func (r *EventRepository) Search(ctx context.Context, query, location, category
 			&e.ID, &e.Title, &e.Description, &e.Location, &e.StartTime, &e.EndTime,
 			&e.Capacity, &e.OrganizerID, &e.Status, &e.Visibility, &e.Category,
 			&e.IsRecurring, &cf, &tt, // Scan new columns
+			&e.RequiresApproval, &e.ReminderNote, pq.Array(&e.Tags), &e.OrganizationID,
 			&e.RegisteredCount,
 		); err != nil {
 			return nil, err
@@ -218,6 +260,7 @@ func (r *EventRepository) GetEventByID(ctx context.Context, id int64) (*Event, e
        SELECT e.id, e.title, e.description, e.location, e.start_time, e.end_time, 
               e.capacity, e.organizer_id, e.status, e.visibility, e.category,
               e.is_recurring, e.custom_fields_schema, e.ticket_types_schema, -- New Columns
+              e.requires_approval, e.reminder_note, e.tags, COALESCE(e.organization_id, 0),
               (SELECT COUNT(*) FROM registrations WHERE event_id = e.id AND status = 'REGISTERED') as registered_count
        FROM events e
        WHERE e.id = $1
@@ -228,6 +271,7 @@ func (r *EventRepository) GetEventByID(ctx context.Context, id int64) (*Event, e
 		&e.ID, &e.Title, &e.Description, &e.Location, &e.StartTime, &e.EndTime,
 		&e.Capacity, &e.OrganizerID, &e.Status, &e.Visibility, &e.Category,
 		&e.IsRecurring, &cf, &tt, // Scan new columns
+		&e.RequiresApproval, &e.ReminderNote, pq.Array(&e.Tags), &e.OrganizationID,
 		&e.RegisteredCount,
 	)
 	if err != nil {
@@ -240,17 +284,34 @@ func (r *EventRepository) GetEventByID(ctx context.Context, id int64) (*Event, e
 	return &e, nil
 }
 
+// AddFeedback saves (or replaces) a user's feedback. With Sentiment set it
+// is stored as final; otherwise it is queued for the background scorer and
+// reads as NEUTRAL until then. Feedback a moderator hid stays hidden when
+// it is resubmitted; f.Status is set to the stored status.
 func (r *EventRepository) AddFeedback(ctx context.Context, f *Feedback) error {
+	status := "DONE"
+	if f.Sentiment == "" {
+		f.Sentiment, status = "NEUTRAL", "PENDING"
+	}
+	if f.Status == "" {
+		f.Status = "PUBLISHED"
+	}
 	query := `
-       INSERT INTO event_feedback (event_id, user_id, rating, comment, created_at)
-       VALUES ($1, $2, $3, $4, $5)
+       INSERT INTO event_feedback (event_id, user_id, rating, comment, sentiment, sentiment_status, created_at, moderation_status, moderation_reason)
+       VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''))
        ON CONFLICT (event_id, user_id) DO UPDATE
-       SET rating = EXCLUDED.rating, comment = EXCLUDED.comment
-       RETURNING id
+       SET rating = EXCLUDED.rating, comment = EXCLUDED.comment,
+           sentiment = EXCLUDED.sentiment, sentiment_status = EXCLUDED.sentiment_status,
+           sentiment_source = NULL, sentiment_attempts = 0, sentiment_error = NULL, sentiment_next_attempt_at = NOW(),
+           moderation_status = CASE WHEN event_feedback.moderation_status = 'HIDDEN'
+                                    THEN event_feedback.moderation_status ELSE EXCLUDED.moderation_status END,
+           moderation_reason = CASE WHEN event_feedback.moderation_status = 'HIDDEN'
+                                    THEN event_feedback.moderation_reason ELSE EXCLUDED.moderation_reason END
+       RETURNING id, moderation_status
     `
 	return r.db.QueryRowContext(ctx, query,
-		f.EventID, f.UserID, f.Rating, f.Comment, time.Now(),
-	).Scan(&f.ID)
+		f.EventID, f.UserID, f.Rating, f.Comment, f.Sentiment, status, time.Now(), f.Status, f.StatusReason,
+	).Scan(&f.ID, &f.Status)
 }
 
 func (r *EventRepository) GetSystemStats(ctx context.Context) (*SystemStats, error) {
@@ -538,14 +599,21 @@ func (r *EventRepository) GetAnalytics(ctx context.Context) (*AnalyticsSummary,
 
 // ExportAllData generates a CSV of ALL registrations in the system
 func (r *EventRepository) ExportAllData(ctx context.Context, w io.Writer) error {
+	return exportRegistrations(ctx, r.db, w, "")
+}
+
+// exportRegistrations writes registrations as CSV, filtered by where (on
+// events e, registrations r and users u).
+func exportRegistrations(ctx context.Context, db *sql.DB, w io.Writer, where string, args ...any) error {
 	query := `
         SELECT e.title, e.start_time, u.email, u.role, r.status, r.created_at
         FROM registrations r
         JOIN events e ON r.event_id = e.id
         JOIN users u ON r.user_id = u.id
+        ` + where + `
         ORDER BY e.start_time DESC
     `
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -575,7 +643,13 @@ func (r *EventRepository) ExportAllData(ctx context.Context, w io.Writer) error
 	return nil
 }
 
-func (r *EventRepository) SelfCheckInUser(ctx context.Context, email string) error {
+// CheckIn identifies one registration marked as attended.
+type CheckIn struct {
+	EventID int64
+	UserID  int64
+}
+
+func (r *EventRepository) SelfCheckInUser(ctx context.Context, email string) ([]CheckIn, error) {
 
 	query := `
         UPDATE registrations
@@ -586,22 +660,36 @@ func (r *EventRepository) SelfCheckInUser(ctx context.Context, email string) err
             SELECT id FROM events 
             WHERE DATE(start_time) = CURRENT_DATE
         )
-        RETURNING event_id
+        RETURNING event_id, user_id
     `
 
-	var eventID int
-	err := r.db.QueryRowContext(ctx, query, email).Scan(&eventID)
-
+	rows, err := r.db.QueryContext(ctx, query, email)
 	if err != nil {
-		return errors.New("no active registration found for today")
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	var checkins []CheckIn
+	for rows.Next() {
+		var c CheckIn
+		if err := rows.Scan(&c.EventID, &c.UserID); err != nil {
+			return nil, err
+		}
+		checkins = append(checkins, c)
+	}
+	if len(checkins) == 0 {
+		return nil, errors.New("no active registration found for today")
+	}
+
+	return checkins, nil
 }
 
 func (r *EventRepository) AddComment(ctx context.Context, c *Comment) error {
-	query := `INSERT INTO comments (event_id, user_id, text) VALUES ($1, $2, $3) RETURNING id, created_at`
-	return r.db.QueryRowContext(ctx, query, c.EventID, c.UserID, c.Text).Scan(&c.ID, &c.CreatedAt)
+	if c.Status == "" {
+		c.Status = "PUBLISHED"
+	}
+	query := `INSERT INTO comments (event_id, user_id, text, moderation_status, moderation_reason) VALUES ($1, $2, $3, $4, NULLIF($5, '')) RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, c.EventID, c.UserID, c.Text, c.Status, c.StatusReason).Scan(&c.ID, &c.CreatedAt)
 }
 
 func (r *EventRepository) GetComments(ctx context.Context, eventID int64) ([]*Comment, error) {
@@ -609,7 +697,7 @@ func (r *EventRepository) GetComments(ctx context.Context, eventID int64) ([]*Co
         SELECT c.id, c.event_id, c.user_id, u.email, c.text, c.created_at
         FROM comments c
         JOIN users u ON c.user_id = u.id
-        WHERE c.event_id = $1
+        WHERE c.event_id = $1 AND c.moderation_status = 'PUBLISHED'
         ORDER BY c.created_at ASC
     `
 	rows, err := r.db.QueryContext(ctx, query, eventID)
@@ -624,18 +712,22 @@ func (r *EventRepository) GetComments(ctx context.Context, eventID int64) ([]*Co
 		if err := rows.Scan(&c.ID, &c.EventID, &c.UserID, &c.UserEmail, &c.Text, &c.CreatedAt); err != nil {
 			return nil, err
 		}
+		c.Status = "PUBLISHED"
 		comments = append(comments, &c)
 	}
 	return comments, nil
 }
 
 func (r *EventRepository) AddPhoto(ctx context.Context, p *Photo, userID int64) error {
-	query := `INSERT INTO event_photos (event_id, url, uploaded_by) VALUES ($1, $2, $3) RETURNING id, created_at`
-	return r.db.QueryRowContext(ctx, query, p.EventID, p.URL, userID).Scan(&p.ID, &p.CreatedAt)
+	if p.Status == "" {
+		p.Status = "PUBLISHED"
+	}
+	query := `INSERT INTO event_photos (event_id, url, uploaded_by, moderation_status, moderation_reason) VALUES ($1, $2, $3, $4, NULLIF($5, '')) RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, p.EventID, p.URL, userID, p.Status, p.StatusReason).Scan(&p.ID, &p.CreatedAt)
 }
 
 func (r *EventRepository) GetPhotos(ctx context.Context, eventID int64) ([]*Photo, error) {
-	query := `SELECT id, event_id, url, created_at FROM event_photos WHERE event_id = $1 ORDER BY created_at DESC`
+	query := `SELECT id, event_id, url, created_at FROM event_photos WHERE event_id = $1 AND moderation_status = 'PUBLISHED' ORDER BY created_at DESC`
 	rows, err := r.db.QueryContext(ctx, query, eventID)
 	if err != nil {
 		return nil, err
@@ -648,6 +740,7 @@ func (r *EventRepository) GetPhotos(ctx context.Context, eventID int64) ([]*Phot
 		if err := rows.Scan(&p.ID, &p.EventID, &p.URL, &p.CreatedAt); err != nil {
 			return nil, err
 		}
+		p.Status = "PUBLISHED"
 		photos = append(photos, &p)
 	}
 	return photos, nil
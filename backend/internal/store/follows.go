@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Kinds of things a user can follow.
+const (
+	FollowOrganizer    = "organizer"
+	FollowOrganization = "organization"
+)
+
+var ErrFollowSelf = errors.New("you cannot follow yourself")
+
+// Followed is an organizer or organization a user follows. Only
+// organizations have a Name: like their profile, organizers are listed
+// without their email address.
+type Followed struct {
+	Kind          string `json:"kind"`
+	ID            int64  `json:"id"`
+	Name          string `json:"name,omitempty"`
+	FollowerCount int    `json:"follower_count"`
+}
+
+// isOrganizerSQL returns the condition on users u for someone who can be
+// followed and has a public profile: a user whose role is in the array
+// parameter rolesParam (e.g. "$2"), or anyone who has organized an event.
+func isOrganizerSQL(rolesParam string) string {
+	return `(u.role = ANY(` + rolesParam + `) OR EXISTS (SELECT 1 FROM events ev WHERE ev.organizer_id = u.id))`
+}
+
+// OrganizerProfile is what other users see of an organizer. It leaves out
+// the email address.
+type OrganizerProfile struct {
+	ID             int64           `json:"id"`
+	FollowerCount  int             `json:"follower_count"`
+	Following      bool            `json:"following"`
+	UpcomingEvents []*EventSummary `json:"upcoming_events"`
+}
+
+type FollowRepository struct {
+	db             *sql.DB
+	organizerRoles []string
+}
+
+// NewFollowRepository returns a repository in which users whose role is in
+// organizerRoles count as organizers, along with anyone who has organized
+// an event. Callers derive the roles from a permission, e.g.
+// authz.RolesWith(authz.EventCreate).
+func NewFollowRepository(db *sql.DB, organizerRoles []string) *FollowRepository {
+	return &FollowRepository{db: db, organizerRoles: organizerRoles}
+}
+
+// Follow starts following an organizer or organization. Following twice is
+// not an error. Following a user who is not an organizer returns
+// sql.ErrNoRows.
+func (r *FollowRepository) Follow(ctx context.Context, followerID int64, kind string, id int64) error {
+	var query string
+	switch kind {
+	case FollowOrganizer:
+		if id == followerID {
+			return ErrFollowSelf
+		}
+		var ok bool
+		if err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users u WHERE u.id = $1 AND "+isOrganizerSQL("$2")+")", id, pq.Array(r.organizerRoles)).Scan(&ok); err != nil {
+			return err
+		}
+		if !ok {
+			return sql.ErrNoRows
+		}
+		query = "INSERT INTO organizer_follows (follower_id, organizer_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	case FollowOrganization:
+		query = "INSERT INTO organization_follows (follower_id, organization_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	default:
+		return errors.New("kind must be organizer or organization")
+	}
+	_, err := r.db.ExecContext(ctx, query, followerID, id)
+	return err
+}
+
+func (r *FollowRepository) Unfollow(ctx context.Context, followerID int64, kind string, id int64) error {
+	var query string
+	switch kind {
+	case FollowOrganizer:
+		query = "DELETE FROM organizer_follows WHERE follower_id = $1 AND organizer_id = $2"
+	case FollowOrganization:
+		query = "DELETE FROM organization_follows WHERE follower_id = $1 AND organization_id = $2"
+	default:
+		return errors.New("kind must be organizer or organization")
+	}
+	_, err := r.db.ExecContext(ctx, query, followerID, id)
+	return err
+}
+
+// Following lists what a user follows, organizers first.
+func (r *FollowRepository) Following(ctx context.Context, userID int64) ([]*Followed, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT 'organizer', f.organizer_id, '', (SELECT COUNT(*) FROM organizer_follows c WHERE c.organizer_id = f.organizer_id)
+		FROM organizer_follows f
+		WHERE f.follower_id = $1
+		UNION ALL
+		SELECT 'organization', o.id, o.name, (SELECT COUNT(*) FROM organization_follows c WHERE c.organization_id = o.id)
+		FROM organization_follows f JOIN organizations o ON o.id = f.organization_id
+		WHERE f.follower_id = $1
+		ORDER BY 1 DESC, 3, 2
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []*Followed{}
+	for rows.Next() {
+		var f Followed
+		if err := rows.Scan(&f.Kind, &f.ID, &f.Name, &f.FollowerCount); err != nil {
+			return nil, err
+		}
+		list = append(list, &f)
+	}
+	return list, rows.Err()
+}
+
+// Feed returns the upcoming events of everything a user follows, soonest
+// first. Members-only events are included only for the organization's
+// members; private events never are.
+func (r *FollowRepository) Feed(ctx context.Context, userID int64, limit int) ([]*EventSummary, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT e.id, e.title, COALESCE(e.location, ''), e.start_time, e.end_time, e.organizer_id, e.status, e.visibility
+		FROM events e
+		WHERE e.status = 'UPCOMING' AND e.start_time > NOW()
+		  AND (e.organizer_id IN (SELECT organizer_id FROM organizer_follows WHERE follower_id = $1)
+		       OR e.organization_id IN (SELECT organization_id FROM organization_follows WHERE follower_id = $1))
+		  AND (e.visibility = 'PUBLIC'
+		       OR (e.visibility = 'MEMBERS' AND EXISTS (
+		           SELECT 1 FROM organization_members m WHERE m.organization_id = e.organization_id AND m.user_id = $1)))
+		ORDER BY e.start_time
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []*EventSummary{}
+	for rows.Next() {
+		var e EventSummary
+		if err := rows.Scan(&e.ID, &e.Title, &e.Location, &e.StartTime, &e.EndTime, &e.OrganizerID, &e.Status, &e.Visibility); err != nil {
+			return nil, err
+		}
+		list = append(list, &e)
+	}
+	return list, rows.Err()
+}
+
+// OrganizerProfile returns an organizer's follower count and upcoming
+// public events, and whether viewerID follows them. Users who are not
+// organizers have no profile (sql.ErrNoRows).
+func (r *FollowRepository) OrganizerProfile(ctx context.Context, organizerID, viewerID int64) (*OrganizerProfile, error) {
+	p := &OrganizerProfile{ID: organizerID}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM organizer_follows WHERE organizer_id = u.id),
+		       EXISTS (SELECT 1 FROM organizer_follows WHERE organizer_id = u.id AND follower_id = $2)
+		FROM users u WHERE u.id = $1 AND `+isOrganizerSQL("$3"), organizerID, viewerID, pq.Array(r.organizerRoles)).Scan(&p.FollowerCount, &p.Following)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, title, COALESCE(location, ''), start_time, end_time, organizer_id, status, visibility
+		FROM events
+		WHERE organizer_id = $1 AND visibility = 'PUBLIC' AND status = 'UPCOMING' AND start_time > NOW()
+		ORDER BY start_time
+	`, organizerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	p.UpcomingEvents = []*EventSummary{}
+	for rows.Next() {
+		var e EventSummary
+		if err := rows.Scan(&e.ID, &e.Title, &e.Location, &e.StartTime, &e.EndTime, &e.OrganizerID, &e.Status, &e.Visibility); err != nil {
+			return nil, err
+		}
+		p.UpcomingEvents = append(p.UpcomingEvents, &e)
+	}
+	return p, rows.Err()
+}
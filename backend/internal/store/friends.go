@@ -0,0 +1,195 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Attendance visibility settings.
+const (
+	AttendanceFriends = "FRIENDS"
+	AttendanceHidden  = "HIDDEN"
+)
+
+// SensitiveCategories are event categories whose attendance is hidden from
+// friends unless the attendee shows it for that event.
+var SensitiveCategories = []string{"Health & Wellness", "Support Group"}
+
+var (
+	ErrFriendSelf    = errors.New("you cannot add yourself as a friend")
+	ErrFriendRequest = errors.New("no pending friend request from this user")
+	ErrNotRegistered = errors.New("you are not registered for this event")
+	ErrEventNotFound = errors.New("event not found")
+)
+
+// Friend is another user in a friendship or friend request.
+type Friend struct {
+	UserID int64     `json:"user_id"`
+	Email  string    `json:"email"`
+	Since  time.Time `json:"since"`
+}
+
+// FriendLists are a user's friends and pending requests.
+type FriendLists struct {
+	Friends  []*Friend `json:"friends"`
+	Incoming []*Friend `json:"incoming"`
+	Outgoing []*Friend `json:"outgoing"`
+}
+
+type FriendRepository struct {
+	db *sql.DB
+}
+
+func NewFriendRepository(db *sql.DB) *FriendRepository {
+	return &FriendRepository{db: db}
+}
+
+// Request asks addresseeID to be friends. If they already asked the
+// requester, that request is accepted instead. It reports whether the two
+// are now friends.
+func (r *FriendRepository) Request(ctx context.Context, requesterID, addresseeID int64) (bool, error) {
+	if requesterID == addresseeID {
+		return false, ErrFriendSelf
+	}
+	accepted, err := r.Respond(ctx, addresseeID, requesterID, true)
+	if err == nil {
+		return accepted, nil
+	}
+	if !errors.Is(err, ErrFriendRequest) {
+		return false, err
+	}
+
+	var status string
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO friendships (requester_id, addressee_id) VALUES ($1, $2)
+		ON CONFLICT (LEAST(requester_id, addressee_id), GREATEST(requester_id, addressee_id))
+		DO UPDATE SET status = friendships.status
+		RETURNING status
+	`, requesterID, addresseeID).Scan(&status)
+	return status == "ACCEPTED", err
+}
+
+// Respond accepts or declines the pending request requesterID sent to
+// addresseeID. It reports whether the two are now friends.
+func (r *FriendRepository) Respond(ctx context.Context, requesterID, addresseeID int64, accept bool) (bool, error) {
+	query := "DELETE FROM friendships WHERE requester_id = $1 AND addressee_id = $2 AND status = 'PENDING'"
+	if accept {
+		query = "UPDATE friendships SET status = 'ACCEPTED', accepted_at = NOW() WHERE requester_id = $1 AND addressee_id = $2 AND status = 'PENDING'"
+	}
+	res, err := r.db.ExecContext(ctx, query, requesterID, addresseeID)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, ErrFriendRequest
+	}
+	return accept, nil
+}
+
+// Remove ends a friendship, or withdraws or declines a request, between two
+// users.
+func (r *FriendRepository) Remove(ctx context.Context, a, b int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM friendships
+		WHERE (requester_id = $1 AND addressee_id = $2) OR (requester_id = $2 AND addressee_id = $1)
+	`, a, b)
+	return err
+}
+
+// List returns a user's friends and pending requests.
+func (r *FriendRepository) List(ctx context.Context, userID int64) (*FriendLists, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT CASE WHEN f.status = 'ACCEPTED' THEN 'friend'
+		            WHEN f.addressee_id = $1 THEN 'incoming' ELSE 'outgoing' END,
+		       u.id, u.email, COALESCE(f.accepted_at, f.created_at)
+		FROM friendships f
+		JOIN users u ON u.id = CASE WHEN f.requester_id = $1 THEN f.addressee_id ELSE f.requester_id END
+		WHERE f.requester_id = $1 OR f.addressee_id = $1
+		ORDER BY u.email
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lists := &FriendLists{Friends: []*Friend{}, Incoming: []*Friend{}, Outgoing: []*Friend{}}
+	for rows.Next() {
+		var kind string
+		var f Friend
+		if err := rows.Scan(&kind, &f.UserID, &f.Email, &f.Since); err != nil {
+			return nil, err
+		}
+		switch kind {
+		case "friend":
+			lists.Friends = append(lists.Friends, &f)
+		case "incoming":
+			lists.Incoming = append(lists.Incoming, &f)
+		default:
+			lists.Outgoing = append(lists.Outgoing, &f)
+		}
+	}
+	return lists, rows.Err()
+}
+
+// Going returns the viewer's friends registered for an event who let
+// friends see it. It returns ErrEventNotFound if the viewer cannot see the
+// event.
+func (r *FriendRepository) Going(ctx context.Context, eventID, viewerID int64, viewerEmail string) ([]*Friend, error) {
+	var visible bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT e.visibility = 'PUBLIC'
+		    OR e.organizer_id = $2
+		    OR (e.visibility = 'MEMBERS' AND EXISTS (SELECT 1 FROM organization_members m WHERE m.organization_id = e.organization_id AND m.user_id = $2))
+		    OR EXISTS (SELECT 1 FROM invitations i WHERE i.event_id = e.id AND i.email = $3)
+		    OR EXISTS (SELECT 1 FROM registrations r WHERE r.event_id = e.id AND r.user_id = $2)
+		FROM events e WHERE e.id = $1
+	`, eventID, viewerID, viewerEmail).Scan(&visible)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !visible) {
+		return nil, ErrEventNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id, u.email, r.created_at
+		FROM registrations r
+		JOIN events e ON e.id = r.event_id
+		JOIN users u ON u.id = r.user_id
+		JOIN friendships f ON f.status = 'ACCEPTED'
+		     AND ((f.requester_id = $2 AND f.addressee_id = u.id) OR (f.addressee_id = $2 AND f.requester_id = u.id))
+		WHERE r.event_id = $1 AND r.status IN ('REGISTERED', 'ATTENDED')
+		  AND COALESCE(r.show_to_friends, u.attendance_visibility = 'FRIENDS' AND NOT e.category = ANY($3))
+		ORDER BY r.created_at
+	`, eventID, viewerID, pq.Array(SensitiveCategories))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []*Friend{}
+	for rows.Next() {
+		var f Friend
+		if err := rows.Scan(&f.UserID, &f.Email, &f.Since); err != nil {
+			return nil, err
+		}
+		list = append(list, &f)
+	}
+	return list, rows.Err()
+}
+
+// ShowAttendance sets whether friends see the user's registration for one
+// event. A nil show goes back to the user's setting.
+func (r *FriendRepository) ShowAttendance(ctx context.Context, userID, eventID int64, show *bool) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE registrations SET show_to_friends = $3 WHERE user_id = $1 AND event_id = $2",
+		userID, eventID, show)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotRegistered
+	}
+	return nil
+}
@@ -2,52 +2,187 @@ package store
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"strconv"
 	"time"
 )
 
 type Notification struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Message   string    `json:"message"`
-	IsRead    bool      `json:"is_read"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int64             `json:"id"`
+	UserID     int64             `json:"user_id"`
+	Message    string            `json:"message"`
+	IsRead     bool              `json:"is_read"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Category   string            `json:"category"`
+	Link       *NotificationLink `json:"link"`
+	ArchivedAt *time.Time        `json:"archived_at"`
 }
 
+// NotificationLink tells the client where a notification should take the
+// user, e.g. {event_id: 5, action: "view_event"}.
+type NotificationLink struct {
+	EventID *int64 `json:"event_id"`
+	Action  string `json:"action"`
+}
+
+// NotificationFilter selects a page of the inbox. Status is "" (everything
+// not archived), "unread" or "archived". Cursor is the id of the last item
+// of the previous page.
+type NotificationFilter struct {
+	Category string
+	Status   string
+	Cursor   int64
+	Limit    int
+}
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
 func (r *EventRepository) CreateNotification(ctx context.Context, userID int64, message string) error {
 	_, err := r.db.ExecContext(ctx, "INSERT INTO notifications (user_id, message) VALUES ($1, $2)", userID, message)
 	return err
 }
 
+// GetNotifications returns every notification in the user's inbox (not
+// archived), newest first, with no page limit.
 func (r *EventRepository) GetNotifications(ctx context.Context, userID int64) ([]*Notification, error) {
-	rows, err := r.db.QueryContext(ctx, "SELECT id, user_id, message, is_read, created_at FROM notifications WHERE user_id = $1 ORDER BY created_at DESC", userID)
+	notes, _, err := r.listNotifications(ctx, userID, NotificationFilter{})
+	return notes, err
+}
+
+// ListNotifications returns one page of the inbox, newest first, and the
+// cursor for the next page (0 when there are no more).
+func (r *EventRepository) ListNotifications(ctx context.Context, userID int64, f NotificationFilter) ([]*Notification, int64, error) {
+	if f.Limit <= 0 || f.Limit > 100 {
+		f.Limit = 50
+	}
+	return r.listNotifications(ctx, userID, f)
+}
+
+// listNotifications runs the inbox query; a zero f.Limit returns all rows.
+func (r *EventRepository) listNotifications(ctx context.Context, userID int64, f NotificationFilter) ([]*Notification, int64, error) {
+	query := `
+		SELECT id, user_id, message, COALESCE(is_read, FALSE), created_at, category, event_id, COALESCE(action, ''), archived_at
+		FROM notifications
+		WHERE user_id = $1`
+	args := []any{userID}
+
+	switch f.Status {
+	case "archived":
+		query += " AND archived_at IS NOT NULL"
+	case "unread":
+		query += " AND archived_at IS NULL AND is_read = FALSE"
+	default:
+		query += " AND archived_at IS NULL"
+	}
+	if f.Category != "" {
+		args = append(args, f.Category)
+		query += " AND category = $" + strconv.Itoa(len(args))
+	}
+	if f.Cursor > 0 {
+		args = append(args, f.Cursor)
+		query += " AND id < $" + strconv.Itoa(len(args))
+	}
+	query += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		// Fetch one extra row to know whether another page exists.
+		args = append(args, f.Limit+1)
+		query += " LIMIT $" + strconv.Itoa(len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var notes []*Notification
+	notes := []*Notification{}
 	for rows.Next() {
 		var n Notification
-		if err := rows.Scan(&n.ID, &n.UserID, &n.Message, &n.IsRead, &n.CreatedAt); err != nil {
-			return nil, err
+		var eventID sql.NullInt64
+		var action string
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Message, &n.IsRead, &n.CreatedAt, &n.Category, &eventID, &action, &n.ArchivedAt); err != nil {
+			return nil, 0, err
+		}
+		if eventID.Valid || action != "" {
+			n.Link = &NotificationLink{Action: action}
+			if eventID.Valid {
+				n.Link.EventID = &eventID.Int64
+			}
 		}
 		notes = append(notes, &n)
 	}
-	return notes, nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var next int64
+	if f.Limit > 0 && len(notes) > f.Limit {
+		notes = notes[:f.Limit]
+		next = notes[len(notes)-1].ID
+	}
+	return notes, next, nil
 }
 
-func (r *EventRepository) MarkNotificationsRead(ctx context.Context, userID int64) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE notifications SET is_read = TRUE WHERE user_id = $1", userID)
-	return err
+// UnreadNotificationCounts returns the number of unread, unarchived
+// notifications per category.
+func (r *EventRepository) UnreadNotificationCounts(ctx context.Context, userID int64) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT category, COUNT(*) FROM notifications
+		WHERE user_id = $1 AND is_read = FALSE AND archived_at IS NULL
+		GROUP BY category
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var category string
+		var n int
+		if err := rows.Scan(&category, &n); err != nil {
+			return nil, err
+		}
+		counts[category] = n
+	}
+	return counts, rows.Err()
 }
 
-func (r *EventRepository) NotifyAllAttendees(ctx context.Context, eventID int64, message string) error {
-	query := `
-		INSERT INTO notifications (user_id, message)
-		SELECT user_id, $1 FROM registrations WHERE event_id = $2
-		UNION
-		SELECT user_id, $1 FROM waitlist WHERE event_id = $2
-	`
-	_, err := r.db.ExecContext(ctx, query, message, eventID)
+// MarkNotificationsRead marks all of the user's notifications read, or only
+// those in category when it is not empty.
+func (r *EventRepository) MarkNotificationsRead(ctx context.Context, userID int64, category string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE notifications SET is_read = TRUE WHERE user_id = $1 AND ($2 = '' OR category = $2)", userID, category)
 	return err
 }
+
+func (r *EventRepository) SetNotificationRead(ctx context.Context, userID, id int64, read bool) error {
+	return expectNotification(r.db.ExecContext(ctx,
+		"UPDATE notifications SET is_read = $1 WHERE id = $2 AND user_id = $3", read, id, userID))
+}
+
+// SetNotificationArchived archives or restores a notification. Archiving
+// also marks it read.
+func (r *EventRepository) SetNotificationArchived(ctx context.Context, userID, id int64, archived bool) error {
+	if archived {
+		return expectNotification(r.db.ExecContext(ctx,
+			"UPDATE notifications SET archived_at = COALESCE(archived_at, NOW()), is_read = TRUE WHERE id = $1 AND user_id = $2", id, userID))
+	}
+	return expectNotification(r.db.ExecContext(ctx,
+		"UPDATE notifications SET archived_at = NULL WHERE id = $1 AND user_id = $2", id, userID))
+}
+
+func (r *EventRepository) DeleteNotification(ctx context.Context, userID, id int64) error {
+	return expectNotification(r.db.ExecContext(ctx, "DELETE FROM notifications WHERE id = $1 AND user_id = $2", id, userID))
+}
+
+func expectNotification(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
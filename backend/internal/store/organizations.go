@@ -0,0 +1,314 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"time"
+)
+
+// Organization roles. Owners and officers manage the organization's
+// events; each organization has exactly one owner.
+const (
+	OrgOwner   = "OWNER"
+	OrgOfficer = "OFFICER"
+	OrgMember  = "MEMBER"
+)
+
+var (
+	ErrOrgNotFound = errors.New("organization not found")
+	ErrOrgOwner    = errors.New("the owner cannot be removed or demoted; transfer ownership first")
+)
+
+type Organization struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	MemberCount   int       `json:"member_count"`
+	FollowerCount int       `json:"follower_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	// MyRole is the caller's role in the organization, empty if they are
+	// not a member.
+	MyRole string `json:"my_role,omitempty"`
+	// Following reports whether the caller follows the organization.
+	Following bool `json:"following"`
+}
+
+type OrgMembership struct {
+	UserID   int64     `json:"user_id"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// OrgStats are the analytics of one organization's events.
+type OrgStats struct {
+	TotalEvents        int     `json:"total_events"`
+	UpcomingEvents     int     `json:"upcoming_events"`
+	TotalRegistrations int     `json:"total_registrations"`
+	TotalAttended      int     `json:"total_attended"`
+	AttendanceRate     float64 `json:"attendance_rate"`
+	AvgRating          float64 `json:"avg_rating"`
+}
+
+type OrganizationRepository struct {
+	db *sql.DB
+}
+
+func NewOrganizationRepository(db *sql.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// IsManager reports whether role may manage an organization's events.
+func IsManager(role string) bool {
+	return role == OrgOwner || role == OrgOfficer
+}
+
+// Create saves a new organization owned by ownerID.
+func (r *OrganizationRepository) Create(ctx context.Context, o *Organization, ownerID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO organizations (name, description) VALUES ($1, $2) RETURNING id, created_at",
+		o.Name, o.Description).Scan(&o.ID, &o.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)",
+		o.ID, ownerID, OrgOwner); err != nil {
+		return err
+	}
+	o.MemberCount, o.MyRole = 1, OrgOwner
+	return tx.Commit()
+}
+
+const orgColumns = `
+	o.id, o.name, o.description, o.created_at,
+	(SELECT COUNT(*) FROM organization_members m WHERE m.organization_id = o.id),
+	(SELECT COUNT(*) FROM organization_follows f WHERE f.organization_id = o.id),
+	COALESCE((SELECT m.role FROM organization_members m WHERE m.organization_id = o.id AND m.user_id = $1), ''),
+	EXISTS (SELECT 1 FROM organization_follows f WHERE f.organization_id = o.id AND f.follower_id = $1)`
+
+// List returns every organization, with the viewer's role in each.
+func (r *OrganizationRepository) List(ctx context.Context, viewerID int64) ([]*Organization, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+orgColumns+" FROM organizations o ORDER BY o.name", viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []*Organization{}
+	for rows.Next() {
+		var o Organization
+		if err := rows.Scan(&o.ID, &o.Name, &o.Description, &o.CreatedAt, &o.MemberCount, &o.FollowerCount, &o.MyRole, &o.Following); err != nil {
+			return nil, err
+		}
+		list = append(list, &o)
+	}
+	return list, rows.Err()
+}
+
+// Get returns one organization, with the viewer's role in it.
+func (r *OrganizationRepository) Get(ctx context.Context, id, viewerID int64) (*Organization, error) {
+	var o Organization
+	err := r.db.QueryRowContext(ctx, "SELECT "+orgColumns+" FROM organizations o WHERE o.id = $2", viewerID, id).
+		Scan(&o.ID, &o.Name, &o.Description, &o.CreatedAt, &o.MemberCount, &o.FollowerCount, &o.MyRole, &o.Following)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrgNotFound
+	}
+	return &o, err
+}
+
+// MemberRole returns the user's role in an organization, empty if they are
+// not a member. A nil repository knows no memberships.
+func (r *OrganizationRepository) MemberRole(ctx context.Context, orgID, userID int64) (string, error) {
+	if r == nil || orgID == 0 {
+		return "", nil
+	}
+	var role string
+	err := r.db.QueryRowContext(ctx,
+		"SELECT role FROM organization_members WHERE organization_id = $1 AND user_id = $2", orgID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
+
+// ManagesEventSQL returns an SQL condition that holds when the user whose
+// id is in parameter userParam (e.g. "$2") organizes the event aliased e or
+// is an owner or officer of its organization: authz.CanManage without the
+// site-wide permission, for queries that filter many events.
+func ManagesEventSQL(userParam string) string {
+	return `(e.organizer_id = ` + userParam + ` OR EXISTS (
+		SELECT 1 FROM organization_members om
+		WHERE om.organization_id = e.organization_id AND om.user_id = ` + userParam + ` AND om.role IN ('` + OrgOwner + `', '` + OrgOfficer + `')))`
+}
+
+// ManagesAny reports whether the user is an owner or officer of at least
+// one organization. A nil repository knows no memberships.
+func (r *OrganizationRepository) ManagesAny(ctx context.Context, userID int64) (bool, error) {
+	if r == nil {
+		return false, nil
+	}
+	var ok bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM organization_members WHERE user_id = $1 AND role IN ($2, $3))",
+		userID, OrgOwner, OrgOfficer).Scan(&ok)
+	return ok, err
+}
+
+func (r *OrganizationRepository) ListMembers(ctx context.Context, orgID int64) ([]*OrgMembership, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT m.user_id, u.email, m.role, m.joined_at
+		FROM organization_members m JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1
+		ORDER BY CASE m.role WHEN 'OWNER' THEN 0 WHEN 'OFFICER' THEN 1 ELSE 2 END, u.email
+	`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []*OrgMembership{}
+	for rows.Next() {
+		var m OrgMembership
+		if err := rows.Scan(&m.UserID, &m.Email, &m.Role, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &m)
+	}
+	return list, rows.Err()
+}
+
+// SetMember adds a user as an OFFICER or MEMBER, or changes their role.
+// The owner's role only changes through TransferOwnership. A membership
+// set here is MANUAL, so role mapping no longer manages it.
+func (r *OrganizationRepository) SetMember(ctx context.Context, orgID, userID int64, role string) error {
+	if role != OrgOfficer && role != OrgMember {
+		return errors.New("role must be OFFICER or MEMBER")
+	}
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET role = EXCLUDED.role, source = 'MANUAL'
+		WHERE organization_members.role <> 'OWNER'
+	`, orgID, userID, role)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrOrgOwner
+	}
+	return nil
+}
+
+// RemoveMember takes a user out of an organization. The owner cannot be
+// removed.
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID, userID int64) error {
+	var role string
+	err := r.db.QueryRowContext(ctx,
+		"DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2 AND role <> 'OWNER' RETURNING role",
+		orgID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		if current, _ := r.MemberRole(ctx, orgID, userID); current == OrgOwner {
+			return ErrOrgOwner
+		}
+		return nil
+	}
+	return err
+}
+
+// TransferOwnership makes toUserID the owner, adding them as a member if
+// needed. The previous owner stays on as an officer. It returns the
+// previous owner's ID.
+func (r *OrganizationRepository) TransferOwnership(ctx context.Context, orgID, toUserID, byUserID int64) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT id FROM organizations WHERE id = $1 FOR UPDATE", orgID); err != nil {
+		return 0, err
+	}
+	var from sql.NullInt64
+	err = tx.QueryRowContext(ctx,
+		"UPDATE organization_members SET role = 'OFFICER' WHERE organization_id = $1 AND role = 'OWNER' RETURNING user_id",
+		orgID).Scan(&from)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO organization_members (organization_id, user_id, role)
+		SELECT id, $2, 'OWNER' FROM organizations WHERE id = $1
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET role = 'OWNER', source = 'MANUAL'
+	`, orgID, toUserID)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, ErrOrgNotFound
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO organization_transfers (organization_id, from_user_id, to_user_id, transferred_by) VALUES ($1, $2, $3, $4)",
+		orgID, from, toUserID, byUserID); err != nil {
+		return 0, err
+	}
+	return from.Int64, tx.Commit()
+}
+
+// Events returns an organization's events, leaving out members-only ones
+// unless withMembersOnly is set.
+func (r *OrganizationRepository) Events(ctx context.Context, orgID int64, withMembersOnly bool) ([]*EventSummary, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, title, COALESCE(location, ''), start_time, end_time, organizer_id, status, visibility
+		FROM events
+		WHERE organization_id = $1 AND (visibility <> 'MEMBERS' OR $2)
+		ORDER BY start_time DESC
+	`, orgID, withMembersOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []*EventSummary{}
+	for rows.Next() {
+		var e EventSummary
+		if err := rows.Scan(&e.ID, &e.Title, &e.Location, &e.StartTime, &e.EndTime, &e.OrganizerID, &e.Status, &e.Visibility); err != nil {
+			return nil, err
+		}
+		list = append(list, &e)
+	}
+	return list, rows.Err()
+}
+
+// Stats summarizes registrations, attendance and ratings across an
+// organization's events.
+func (r *OrganizationRepository) Stats(ctx context.Context, orgID int64) (*OrgStats, error) {
+	var s OrgStats
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM events WHERE organization_id = $1),
+			(SELECT COUNT(*) FROM events WHERE organization_id = $1 AND status = 'UPCOMING'),
+			(SELECT COUNT(*) FROM registrations r JOIN events e ON e.id = r.event_id
+			  WHERE e.organization_id = $1 AND r.status IN ('REGISTERED', 'ATTENDED')),
+			(SELECT COUNT(*) FROM registrations r JOIN events e ON e.id = r.event_id
+			  WHERE e.organization_id = $1 AND r.status = 'ATTENDED'),
+			(SELECT COALESCE(AVG(f.rating), 0) FROM event_feedback f JOIN events e ON e.id = f.event_id
+			  WHERE e.organization_id = $1)
+	`, orgID).Scan(&s.TotalEvents, &s.UpcomingEvents, &s.TotalRegistrations, &s.TotalAttended, &s.AvgRating)
+	if err != nil {
+		return nil, err
+	}
+	if s.TotalRegistrations > 0 {
+		s.AttendanceRate = float64(s.TotalAttended) / float64(s.TotalRegistrations) * 100
+	}
+	return &s, nil
+}
+
+// Export writes a CSV of the registrations for an organization's events.
+func (r *OrganizationRepository) Export(ctx context.Context, orgID int64, w io.Writer) error {
+	return exportRegistrations(ctx, r.db, w, "WHERE e.organization_id = $1", orgID)
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type OutboxMessage struct {
+	ID            int64      `json:"id"`
+	ToEmail       string     `json:"to_email"`
+	Subject       string     `json:"subject"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at"`
+}
+
+// ListOutbox returns the most recent outbox messages with the given status
+// (PENDING, SENT or DEAD).
+func (r *EventRepository) ListOutbox(ctx context.Context, status string) ([]*OutboxMessage, error) {
+	query := `
+        SELECT id, to_email, subject, status, attempts, COALESCE(last_error, ''), next_attempt_at, created_at, sent_at
+        FROM email_outbox
+        WHERE status = $1
+        ORDER BY created_at DESC
+        LIMIT 200
+    `
+	rows, err := r.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []*OutboxMessage
+	for rows.Next() {
+		var m OutboxMessage
+		if err := rows.Scan(&m.ID, &m.ToEmail, &m.Subject, &m.Status, &m.Attempts, &m.LastError, &m.NextAttemptAt, &m.CreatedAt, &m.SentAt); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, &m)
+	}
+	return msgs, nil
+}
+
+// RequeueOutboxMessage moves a dead-lettered message back into the queue
+// with a fresh retry budget.
+func (r *EventRepository) RequeueOutboxMessage(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE email_outbox SET status='PENDING', attempts=0, next_attempt_at=NOW() WHERE id=$1 AND status='DEAD'", id)
+	if err != nil {
+		return err
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return errors.New("dead-lettered message not found")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// StreamEvent is one entry in a user's real-time feed (see stream_events).
+type StreamEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// StreamLag is how long readers look back for events that became visible
+// late. Ids come from a sequence when a row is inserted, but rows become
+// visible when their transaction commits, so a slow transaction can commit
+// an id below one a reader has already passed. Such rows are still
+// delivered if their transaction took less than StreamLag.
+const StreamLag = time.Minute
+
+// GetStreamEventsAfter returns the user's events with an id greater than
+// afterID, oldest first.
+func (r *EventRepository) GetStreamEventsAfter(ctx context.Context, userID, afterID int64, limit int) ([]*StreamEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, type, payload, created_at FROM stream_events
+		WHERE user_id = $1 AND id > $2
+		ORDER BY id ASC
+		LIMIT $3
+	`, userID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*StreamEvent
+	for rows.Next() {
+		var e StreamEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.Payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, nil
+}
+
+// GetLateStreamEvents returns the user's events with an id up to upToID
+// created within StreamLag, oldest first: rows that may have committed after
+// a reader moved past their id. Readers skip the ones they already sent.
+func (r *EventRepository) GetLateStreamEvents(ctx context.Context, userID, upToID int64) ([]*StreamEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, type, payload, created_at FROM stream_events
+		WHERE user_id = $1 AND id <= $2 AND created_at > NOW() - $3 * INTERVAL '1 second'
+		ORDER BY id ASC
+	`, userID, upToID, int(StreamLag.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*StreamEvent
+	for rows.Next() {
+		var e StreamEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.Payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// LatestStreamEventID is where a fresh connection (no Last-Event-ID) starts.
+func (r *EventRepository) LatestStreamEventID(ctx context.Context, userID int64) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM stream_events WHERE user_id = $1", userID).Scan(&id)
+	return id, err
+}
+
+// PruneStreamEvents drops events older than the replay window.
+func (r *EventRepository) PruneStreamEvents(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM stream_events WHERE created_at < $1", time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -3,9 +3,15 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// ErrLastAdmin is returned by changes that would leave no active admin.
+var ErrLastAdmin = errors.New("cannot demote or deactivate the last active admin")
+
 type User struct {
 	ID             int64      `json:"id"`
 	Email          string     `json:"email"`
@@ -17,6 +23,21 @@ type User struct {
 	Points         int        `json:"points"`
 	CurrentStreak  int        `json:"current_streak"`
 	LastAttendedAt *time.Time `json:"last_attended_at"`
+	Locale         string     `json:"locale"`
+	Timezone       string     `json:"timezone"`
+
+	// AttendanceVisibility is FRIENDS or HIDDEN: whether friends see which
+	// events the user registered for.
+	AttendanceVisibility string `json:"attendance_visibility"`
+
+	// Role is the stronger of ManualRole, assigned by an admin, and
+	// ClaimRole, granted by identity-provider claims (empty if none).
+	ManualRole string `json:"manual_role"`
+	ClaimRole  string `json:"claim_role,omitempty"`
+
+	// IsProtected accounts cannot be demoted or deactivated through the
+	// API.
+	IsProtected bool `json:"is_protected"`
 }
 
 type Badge struct {
@@ -36,8 +57,8 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 
 func (r *UserRepository) Create(ctx context.Context, user *User) error {
 	query := `
-       INSERT INTO users (email, oidc_id, role, created_at, updated_at, is_active, points)
-       VALUES ($1, $2, $3, $4, $5, true, $6)
+       INSERT INTO users (email, oidc_id, role, manual_role, created_at, updated_at, is_active, points)
+       VALUES ($1, $2, $3, $3, $4, $5, true, $6)
        ON CONFLICT (email) DO UPDATE
        SET updated_at = $5
        RETURNING id, role, created_at, updated_at, is_active, points
@@ -57,7 +78,8 @@ func (r *UserRepository) Create(ctx context.Context, user *User) error {
 }
 
 func (r *UserRepository) GetByOIDCID(ctx context.Context, oidcID string) (*User, error) {
-	query := `SELECT id, email, oidc_id, role, created_at, updated_at FROM users WHERE oidc_id = $1`
+	query := `SELECT id, email, oidc_id, role, created_at, updated_at, locale, timezone, attendance_visibility,
+		COALESCE(manual_role, role), COALESCE(claim_role, '') FROM users WHERE oidc_id = $1`
 
 	var user User
 	err := r.db.QueryRowContext(ctx, query, oidcID).Scan(
@@ -67,6 +89,11 @@ func (r *UserRepository) GetByOIDCID(ctx context.Context, oidcID string) (*User,
 		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.Locale,
+		&user.Timezone,
+		&user.AttendanceVisibility,
+		&user.ManualRole,
+		&user.ClaimRole,
 	)
 
 	if err != nil {
@@ -75,8 +102,134 @@ func (r *UserRepository) GetByOIDCID(ctx context.Context, oidcID string) (*User,
 	return &user, nil
 }
 
+// UpdateRole assigns a role by hand, for users without a claim role.
 func (r *UserRepository) UpdateRole(ctx context.Context, userID int64, role string) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE users SET role=$1, updated_at=NOW() WHERE id=$2", role, userID)
+	return r.SetRoles(ctx, userID, role, role)
+}
+
+// SetRoles stores the role assigned by hand and the effective role, which
+// callers work out from it and the user's claim role. It returns
+// ErrLastAdmin instead of demoting the last active admin.
+func (r *UserRepository) SetRoles(ctx context.Context, userID int64, manual, effective string) error {
+	return r.keepAnAdmin(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE users SET manual_role=$1, role=$2, updated_at=NOW() WHERE id=$3", manual, effective, userID)
+		return err
+	})
+}
+
+// SetClaimRole stores the role granted by claims and the effective role
+// inside tx, with the same last-admin guard as SetRoles. On ErrLastAdmin
+// the update is undone and tx can still be used.
+func (r *UserRepository) SetClaimRole(ctx context.Context, tx *sql.Tx, userID int64, claimRole, effective string) error {
+	return guardAdmins(ctx, tx, func() error {
+		_, err := tx.ExecContext(ctx,
+			"UPDATE users SET claim_role = NULLIF($1, ''), role = $2, updated_at = NOW() WHERE id = $3",
+			claimRole, effective, userID)
+		return err
+	})
+}
+
+// keepAnAdmin runs update in its own transaction under guardAdmins.
+func (r *UserRepository) keepAnAdmin(ctx context.Context, update func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := guardAdmins(ctx, tx, func() error { return update(tx) }); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// guardAdmins runs update in tx and undoes it with ErrLastAdmin if it
+// leaves no active admin where there was one. The active admins are locked
+// first, so two admins demoting each other at the same time cannot both
+// succeed.
+func guardAdmins(ctx context.Context, tx *sql.Tx, update func() error) error {
+	var before, after int
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM (SELECT id FROM users WHERE role = 'Admin' AND is_active FOR UPDATE) a").Scan(&before); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT keep_an_admin"); err != nil {
+		return err
+	}
+	if err := update(); err != nil {
+		return err
+	}
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM users WHERE role = 'Admin' AND is_active").Scan(&after); err != nil {
+		return err
+	}
+	if before > 0 && after == 0 {
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT keep_an_admin"); err != nil {
+			return err
+		}
+		return ErrLastAdmin
+	}
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT keep_an_admin")
+	return err
+}
+
+// RestoreAdmin makes the user with this identity-provider subject
+// (oidc_id) an active, protected admin. It is the break-glass path for when
+// no one can log in as an admin.
+func (r *UserRepository) RestoreAdmin(ctx context.Context, oidcID string) (*User, error) {
+	var u User
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE users
+		SET manual_role = 'Admin', role = 'Admin', is_active = TRUE, is_protected = TRUE, updated_at = NOW()
+		WHERE oidc_id = $1
+		RETURNING id, email, role, is_active, is_protected
+	`, oidcID).Scan(&u.ID, &u.Email, &u.Role, &u.IsActive, &u.IsProtected)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// ProtectSubjects flags the accounts with these identity-provider subjects
+// as protected and returns how many changed. Subjects with no account yet
+// are skipped.
+func (r *UserRepository) ProtectSubjects(ctx context.Context, oidcIDs []string) (int64, error) {
+	if len(oidcIDs) == 0 {
+		return 0, nil
+	}
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE users SET is_protected = TRUE, updated_at = NOW() WHERE oidc_id = ANY($1) AND NOT is_protected", pq.Array(oidcIDs))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// SetProtected protects the account with this identity-provider subject
+// from being demoted or deactivated, or lifts that protection.
+func (r *UserRepository) SetProtected(ctx context.Context, oidcID string, protected bool) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE users SET is_protected = $1, updated_at = NOW() WHERE oidc_id = $2", protected, oidcID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateSettings stores the locale and IANA time zone used to render the
+// user's notifications.
+func (r *UserRepository) UpdateSettings(ctx context.Context, userID int64, locale, timezone string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET locale=$1, timezone=$2, updated_at=NOW() WHERE id=$3", locale, timezone, userID)
+	return err
+}
+
+// UpdateAttendanceVisibility sets whether friends see the user's
+// registrations.
+func (r *UserRepository) UpdateAttendanceVisibility(ctx context.Context, userID int64, visibility string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET attendance_visibility=$1, updated_at=NOW() WHERE id=$2", visibility, userID)
 	return err
 }
 
@@ -91,7 +244,8 @@ func (r *UserRepository) AddBadge(ctx context.Context, userID int64, name, icon
 }
 
 func (r *UserRepository) ListAll(ctx context.Context) ([]*User, error) {
-	query := "SELECT id, email, oidc_id, role, created_at, updated_at, is_active FROM users ORDER BY id ASC"
+	query := `SELECT id, email, oidc_id, role, created_at, updated_at, is_active, COALESCE(manual_role, role), COALESCE(claim_role, ''),
+		is_protected FROM users ORDER BY id ASC`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -102,7 +256,7 @@ func (r *UserRepository) ListAll(ctx context.Context) ([]*User, error) {
 	var users []*User
 	for rows.Next() {
 		var u User
-		if err := rows.Scan(&u.ID, &u.Email, &u.OIDCID, &u.Role, &u.CreatedAt, &u.UpdatedAt, &u.IsActive); err != nil {
+		if err := rows.Scan(&u.ID, &u.Email, &u.OIDCID, &u.Role, &u.CreatedAt, &u.UpdatedAt, &u.IsActive, &u.ManualRole, &u.ClaimRole, &u.IsProtected); err != nil {
 			return nil, err
 		}
 		users = append(users, &u)
@@ -111,10 +265,12 @@ func (r *UserRepository) ListAll(ctx context.Context) ([]*User, error) {
 }
 
 func (r *UserRepository) GetByID(ctx context.Context, id int64) (*User, error) {
-	query := `SELECT id, email, oidc_id, role, created_at, updated_at FROM users WHERE id = $1`
+	query := `SELECT id, email, oidc_id, role, locale, timezone, created_at, updated_at, attendance_visibility,
+		COALESCE(manual_role, role), COALESCE(claim_role, ''), is_active, is_protected FROM users WHERE id = $1`
 	var user User
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID, &user.Email, &user.OIDCID, &user.Role, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Email, &user.OIDCID, &user.Role, &user.Locale, &user.Timezone, &user.CreatedAt, &user.UpdatedAt,
+		&user.AttendanceVisibility, &user.ManualRole, &user.ClaimRole, &user.IsActive, &user.IsProtected,
 	)
 	if err != nil {
 		return nil, err
@@ -122,10 +278,14 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*User, error) {
 	return &user, nil
 }
 
+// ToggleActive activates or deactivates a user. It returns ErrLastAdmin
+// instead of deactivating the last active admin.
 func (r *UserRepository) ToggleActive(ctx context.Context, userID int64, isActive bool) error {
-	query := "UPDATE users SET is_active=$1, updated_at=NOW() WHERE id=$2"
-	_, err := r.db.ExecContext(ctx, query, isActive, userID)
-	return err
+	return r.keepAnAdmin(ctx, func(tx *sql.Tx) error {
+		query := "UPDATE users SET is_active=$1, updated_at=NOW() WHERE id=$2"
+		_, err := tx.ExecContext(ctx, query, isActive, userID)
+		return err
+	})
 }
 
 func (r *UserRepository) GetLeaderboard(ctx context.Context) ([]*User, error) {
@@ -2,18 +2,93 @@ package users
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/auth"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/rolemap"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
 )
 
 type Handler struct {
 	Repo *store.UserRepository
+	// RoleMap maps identity-provider claims to roles and memberships on
+	// each sync; nil maps nothing.
+	RoleMap *rolemap.Service
+	// ProtectedSubjects are the identity-provider subjects (oidc_id) of
+	// accounts no admin can demote or deactivate, on top of those flagged
+	// is_protected in the database. Subjects, unlike emails, cannot be
+	// chosen by the user.
+	ProtectedSubjects []string
+}
+
+// ProtectedSubjectsFromEnv reads the comma-separated
+// PROTECTED_ADMIN_SUBJECTS, e.g. "auth0|64f1c2...".
+func ProtectedSubjectsFromEnv() []string {
+	var subjects []string
+	for _, s := range strings.Split(os.Getenv("PROTECTED_ADMIN_SUBJECTS"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			subjects = append(subjects, s)
+		}
+	}
+	return subjects
+}
+
+func (h *Handler) isProtected(u *store.User) bool {
+	return u.IsProtected || slices.Contains(h.ProtectedSubjects, u.OIDCID)
+}
+
+// markProtected flags a configured protected account in the database on
+// sync, so role mapping, which reads is_protected, never lowers it either.
+func (h *Handler) markProtected(r *http.Request, u *store.User) {
+	if u.IsProtected || !slices.Contains(h.ProtectedSubjects, u.OIDCID) {
+		return
+	}
+	if err := h.Repo.SetProtected(r.Context(), u.OIDCID, true); err != nil {
+		log.Printf("Failed to protect user %d: %v", u.ID, err)
+		return
+	}
+	u.IsProtected = true
+}
+
+func writeJSONError(w http.ResponseWriter, status int, body map[string]any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// syncResponse is the user plus what role mapping changed on this login.
+type syncResponse struct {
+	*store.User
+	Changes []rolemap.Change `json:"changes"`
+}
+
+// applyRoleMapping updates the user from their token's claims. A failure
+// is logged and the login goes on with the roles the user already had.
+func (h *Handler) applyRoleMapping(r *http.Request, user *store.User, claims *validator.ValidatedClaims) []rolemap.Change {
+	if h.RoleMap == nil {
+		return []rolemap.Change{}
+	}
+	changes, err := h.RoleMap.Apply(r.Context(), user, auth.ClaimsFrom(claims))
+	if err != nil {
+		log.Printf("Role mapping failed for user %d: %v", user.ID, err)
+		return []rolemap.Change{}
+	}
+	for _, c := range changes {
+		log.Printf("🔑 Role mapping for %s: %s %s %q -> %q", user.Email, c.Kind, c.Organization, c.From, c.To)
+	}
+	return changes
 }
 
 type Badge struct {
@@ -38,27 +113,30 @@ func (h *Handler) HandleSyncUser(w http.ResponseWriter, r *http.Request) {
 
 	auth0ID := validatedClaims.RegisteredClaims.Subject
 
-	var req struct {
-		Email string `json:"email"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Bad Request", http.StatusBadRequest)
-		return
-	}
-
 	// 1. Check if user already exists
 	existing, err := h.Repo.GetByOIDCID(r.Context(), auth0ID)
 	if err == nil {
-		// User exists -> Return them immediately
-		json.NewEncoder(w).Encode(existing)
+		// User exists -> Refresh claim roles and return them
+		h.markProtected(r, existing)
+		changes := h.applyRoleMapping(r, existing, validatedClaims)
+		json.NewEncoder(w).Encode(syncResponse{User: existing, Changes: changes})
 		return
 	}
 
-	// 2. NEW USER DETECTED -> Initialize with Points
+	// 2. NEW USER DETECTED -> Initialize with Points. The email comes from
+	// the token, never the request body, because role mapping and account
+	// protection trust it.
+	email := auth.VerifiedEmail(auth.ClaimsFrom(validatedClaims))
+	if email == "" {
+		writeJSONError(w, http.StatusForbidden, map[string]any{
+			"message": "Forbidden: The token has no verified email.",
+		})
+		return
+	}
 	newUser := &store.User{
-		Email:  req.Email,
+		Email:  email,
 		OIDCID: auth0ID,
-		Role:   "Member",
+		Role:   authz.RoleMember,
 		Points: 50, // 👈 Initialize with 50 points
 	}
 
@@ -78,8 +156,11 @@ func (h *Handler) HandleSyncUser(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Failed to award welcome badge: %v", err)
 	}
 
-	// 5. Return the new user object
-	json.NewEncoder(w).Encode(newUser)
+	// 5. Return the new user object, with any roles their claims grant
+	newUser.ManualRole = newUser.Role
+	h.markProtected(r, newUser)
+	changes := h.applyRoleMapping(r, newUser, validatedClaims)
+	json.NewEncoder(w).Encode(syncResponse{User: newUser, Changes: changes})
 }
 
 func (h *Handler) HandleUpdateRole(w http.ResponseWriter, r *http.Request) {
@@ -92,7 +173,7 @@ func (h *Handler) HandleUpdateRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if requester.Role != "Admin" {
+	if !authz.Can(requester.Role, authz.UsersManage) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -102,55 +183,106 @@ func (h *Handler) HandleUpdateRole(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		UserID int64  `json:"user_id"`
-		Role   string `json:"role"`
+		UserID  int64  `json:"user_id"`
+		Role    string `json:"role"`
+		Confirm bool   `json:"confirm"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid body", http.StatusBadRequest)
 		return
 	}
 
-	const SuperAdminEmail = "devanshukejriwal24@gmail.com"
-
 	targetUser, err := h.Repo.GetByID(r.Context(), req.UserID)
 	if err != nil {
 		http.Error(w, "Target user not found", http.StatusNotFound)
 		return
 	}
 
-	if targetUser.Email == SuperAdminEmail {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusForbidden)
-		json.NewEncoder(w).Encode(map[string]string{
-			"message": "Forbidden: Cannot modify the Super Admin account.",
+	if h.isProtected(targetUser) {
+		writeJSONError(w, http.StatusForbidden, map[string]any{
+			"message": "Forbidden: Cannot modify a protected account.",
 		})
 		return
 	}
 
-	if req.Role != "Admin" && req.Role != "Organizer" && req.Role != "Member" {
+	if !authz.ValidRole(req.Role) {
 		http.Error(w, "Invalid role", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.Repo.UpdateRole(r.Context(), req.UserID, req.Role); err != nil {
+	// A role granted by the identity provider still applies on top of the
+	// one assigned here; it goes away only when the claim does.
+	effective := authz.Strongest(req.Role, targetUser.ClaimRole)
+
+	// Admins lose access to this page by demoting themselves, so they have
+	// to say they mean it.
+	if targetUser.ID == requester.ID && effective != requester.Role && !req.Confirm {
+		writeJSONError(w, http.StatusConflict, map[string]any{
+			"message":          "You are removing your own admin access. Send confirm: true to go ahead.",
+			"confirm_required": true,
+		})
+		return
+	}
+
+	if err := h.Repo.SetRoles(r.Context(), req.UserID, req.Role, effective); err != nil {
+		if errors.Is(err, store.ErrLastAdmin) {
+			writeJSONError(w, http.StatusConflict, map[string]any{"message": "Cannot demote the last active admin."})
+			return
+		}
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{"message": "Role updated successfully"})
+	json.NewEncoder(w).Encode(map[string]string{"message": "Role updated successfully", "role": effective})
 }
 
 func (h *Handler) HandleListUsers(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	requester, err := h.Repo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "Requester not found", http.StatusUnauthorized)
+		return
+	}
+	if !authz.Can(requester.Role, authz.UsersManage) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Forbidden: Only Admins can list users.",
+		})
+		return
+	}
+
 	users, err := h.Repo.ListAll(r.Context())
 	if err != nil {
 		http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
 		return
 	}
+	for _, u := range users {
+		u.IsProtected = h.isProtected(u)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(users)
 }
 
+// HandleSyncLog lists what role mapping changed on recent logins, for one
+// user with ?user_id= or for everyone.
+func (h *Handler) HandleSyncLog(w http.ResponseWriter, r *http.Request) {
+	if h.RoleMap == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+		return
+	}
+	userID, _ := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	entries, err := h.RoleMap.Log(r.Context(), userID, 200)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
 func (h *Handler) HandleToggleActive(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
 	requesterID := claims.RegisteredClaims.Subject
@@ -161,7 +293,7 @@ func (h *Handler) HandleToggleActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if requester.Role != "Admin" {
+	if !authz.Can(requester.Role, authz.UsersManage) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -178,24 +310,24 @@ func (h *Handler) HandleToggleActive(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid body", http.StatusBadRequest)
 		return
 	}
-	const SuperAdminEmail = "devanshukejriwal24@gmail.com"
-
 	targetUser, err := h.Repo.GetByID(r.Context(), req.UserID)
 	if err != nil {
 		http.Error(w, "Target user not found", http.StatusNotFound)
 		return
 	}
 
-	if targetUser.Email == SuperAdminEmail {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusForbidden)
-		json.NewEncoder(w).Encode(map[string]string{
-			"message": "Forbidden: Cannot deactivate the Super Admin account.",
+	if h.isProtected(targetUser) {
+		writeJSONError(w, http.StatusForbidden, map[string]any{
+			"message": "Forbidden: Cannot deactivate a protected account.",
 		})
 		return
 	}
 
 	if err := h.Repo.ToggleActive(r.Context(), req.UserID, req.IsActive); err != nil {
+		if errors.Is(err, store.ErrLastAdmin) {
+			writeJSONError(w, http.StatusConflict, map[string]any{"message": "Cannot deactivate the last active admin."})
+			return
+		}
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
@@ -229,3 +361,65 @@ func (h *Handler) HandleGetMyBadges(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewEncoder(w).Encode(badges)
 }
+
+func (h *Handler) HandleUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.Repo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Locale   string `json:"locale"`
+		Timezone string `json:"timezone"`
+
+		// AttendanceVisibility is FRIENDS or HIDDEN; empty keeps it.
+		AttendanceVisibility string `json:"attendance_visibility"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Locale == "" {
+		req.Locale = user.Locale
+	}
+	if req.Timezone == "" {
+		req.Timezone = user.Timezone
+	}
+
+	supported := false
+	for _, l := range notifications.Locales {
+		if l == req.Locale {
+			supported = true
+		}
+	}
+	if !supported {
+		http.Error(w, "Unsupported locale", http.StatusBadRequest)
+		return
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		http.Error(w, "Invalid timezone", http.StatusBadRequest)
+		return
+	}
+	if req.AttendanceVisibility == "" {
+		req.AttendanceVisibility = user.AttendanceVisibility
+	}
+	if req.AttendanceVisibility != store.AttendanceFriends && req.AttendanceVisibility != store.AttendanceHidden {
+		http.Error(w, "Invalid attendance visibility (must be FRIENDS or HIDDEN)", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Repo.UpdateSettings(r.Context(), user.ID, req.Locale, req.Timezone); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.Repo.UpdateAttendanceVisibility(r.Context(), user.ID, req.AttendanceVisibility); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Settings updated"})
+}
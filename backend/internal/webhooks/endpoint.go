@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ErrLocalAddress is returned for endpoints on loopback, link-local,
+// private or unspecified addresses, which organizers must not be able to
+// reach through the dispatcher.
+var ErrLocalAddress = errors.New("webhook endpoints must be on a public address")
+
+// AllowLocalFromEnv reads WEBHOOKS_ALLOW_LOCAL, which lets webhooks use
+// http:// and local addresses. It is for development only.
+func AllowLocalFromEnv() bool {
+	return os.Getenv("WEBHOOKS_ALLOW_LOCAL") == "true"
+}
+
+// publicIP reports whether ip is safe to deliver to.
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast())
+}
+
+// checkEndpoint validates an endpoint URL. Unless allowLocal, it must be
+// https and every address its host resolves to must be public.
+func checkEndpoint(ctx context.Context, rawURL string, allowLocal bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+	if allowLocal {
+		return nil
+	}
+	if u.Scheme != "https" {
+		return errors.New("url must use https")
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("cannot resolve %s", u.Hostname())
+	}
+	for _, a := range addrs {
+		if !publicIP(a.IP) {
+			return ErrLocalAddress
+		}
+	}
+	return nil
+}
+
+// NewHTTPClient returns the client deliveries are sent with. Unless
+// allowLocal, it refuses to connect to non-public addresses, checked on
+// the resolved address at dial time so DNS changes and redirects cannot
+// get around the check made at registration.
+func NewHTTPClient(allowLocal bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowLocal {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return ErrLocalAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: 10 * time.Second, Transport: transport}
+}
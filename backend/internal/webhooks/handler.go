@@ -0,0 +1,209 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+type Handler struct {
+	Service  *Service
+	UserRepo *store.UserRepository
+}
+
+// requireManager allows Organizers and Admins.
+func (h *Handler) requireManager(w http.ResponseWriter, r *http.Request) (*store.User, bool) {
+	claims := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	user, err := h.UserRepo.GetByOIDCID(r.Context(), claims.RegisteredClaims.Subject)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return nil, false
+	}
+	if !authz.Can(user.Role, authz.WebhooksManage) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil, false
+	}
+	return user, true
+}
+
+// authorizeWebhook checks that the user owns the webhook (or is an Admin).
+func (h *Handler) authorizeWebhook(w http.ResponseWriter, r *http.Request, user *store.User, webhookID int64) bool {
+	owner, err := h.Service.OwnerOf(r.Context(), webhookID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	if owner != user.ID && !authz.Can(user.Role, authz.WebhooksManageAny) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (h *Handler) HandleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	owner := user.ID
+	if authz.Can(user.Role, authz.WebhooksManageAny) {
+		owner = 0
+	}
+	list, err := h.Service.List(r.Context(), owner)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleCreateWebhook registers an endpoint. The response is the only time
+// the signing secret is shown.
+func (h *Handler) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	hook, err := h.Service.Create(r.Context(), user.ID, req.URL, req.EventTypes)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hook)
+}
+
+func (h *Handler) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+	if !h.authorizeWebhook(w, r, user, id) {
+		return
+	}
+
+	if err := h.Service.Delete(r.Context(), id); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) HandleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("webhook_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook_id", http.StatusBadRequest)
+		return
+	}
+	if !h.authorizeWebhook(w, r, user, id) {
+		return
+	}
+
+	list, err := h.Service.Deliveries(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *Handler) HandleRedeliver(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		DeliveryID int64 `json:"delivery_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	webhookID, err := h.Service.WebhookOfDelivery(r.Context(), req.DeliveryID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if !h.authorizeWebhook(w, r, user, webhookID) {
+		return
+	}
+
+	newID, err := h.Service.Redeliver(r.Context(), req.DeliveryID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Delivery re-queued", "delivery_id": newID})
+}
+
+func (h *Handler) HandlePing(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if !h.authorizeWebhook(w, r, user, req.ID) {
+		return
+	}
+
+	if err := h.Service.Ping(r.Context(), req.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Ping queued"})
+}
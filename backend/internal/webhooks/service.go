@@ -0,0 +1,271 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	"github.com/lib/pq"
+)
+
+// Event types a webhook can subscribe to.
+const (
+	EventCreated          = "event.created"
+	EventUpdated          = "event.updated"
+	EventCancelled        = "event.cancelled"
+	RegistrationCreated   = "registration.created"
+	RegistrationCancelled = "registration.cancelled"
+	WaitlistPromoted      = "waitlist.promoted"
+	CheckinRecorded       = "checkin.recorded"
+
+	// Ping is sent on demand to check an endpoint; it is not subscribable.
+	Ping = "ping"
+)
+
+var EventTypes = []string{
+	EventCreated,
+	EventUpdated,
+	EventCancelled,
+	RegistrationCreated,
+	RegistrationCancelled,
+	WaitlistPromoted,
+	CheckinRecorded,
+}
+
+var ErrNotFound = errors.New("webhook not found")
+
+// Execer is satisfied by both *sql.DB and *sql.Tx, so deliveries can be
+// queued in the same transaction as the change they describe.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type Webhook struct {
+	ID         int64     `json:"id"`
+	OwnerID    int64     `json:"owner_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Delivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      string          `json:"last_error"`
+	RedeliveryOf   *int64          `json:"redelivery_of"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
+
+// Envelope is the JSON body POSTed to endpoints. ID is shared by every
+// webhook that receives the same occurrence, so receivers can de-duplicate.
+type Envelope struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Service queues deliveries in webhook_deliveries; the background
+// WebhookDispatcher sends them.
+type Service struct {
+	DB *sql.DB
+	// AllowLocal accepts http:// and local endpoints, for development.
+	AllowLocal bool
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{DB: db, AllowLocal: AllowLocalFromEnv()}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Emit queues eventType for every active webhook subscribed to it whose
+// owner may see the event: owners with EventEditAny receive everything,
+// others activity on events they manage (see authz.CanManage), including
+// those of organizations they are an owner or officer of. A nil Service emits nothing, so callers
+// that do not use webhooks need not configure one.
+func (s *Service) Emit(ctx context.Context, tx Execer, eventType string, eventID int64, data any) error {
+	if s == nil {
+		return nil
+	}
+	if tx == nil {
+		tx = s.DB
+	}
+	payload, err := json.Marshal(Envelope{ID: randomHex(16), Type: eventType, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+		SELECT w.id, $1, $2
+		FROM webhooks w
+		JOIN users u ON u.id = w.owner_id
+		WHERE w.active AND $1 = ANY(w.event_types)
+		  AND (u.role = ANY($4) OR EXISTS (SELECT 1 FROM events e WHERE e.id = $3 AND `+store.ManagesEventSQL("w.owner_id")+`))
+	`, eventType, payload, eventID, pq.Array(authz.RolesWith(authz.EventEditAny)))
+	return err
+}
+
+func (s *Service) validate(ctx context.Context, rawURL string, types []string) error {
+	if err := checkEndpoint(ctx, rawURL, s.AllowLocal); err != nil {
+		return err
+	}
+	if len(types) == 0 {
+		return errors.New("at least one event type is required")
+	}
+	for _, t := range types {
+		known := false
+		for _, e := range EventTypes {
+			if e == t {
+				known = true
+			}
+		}
+		if !known {
+			return errors.New("unknown event type: " + t)
+		}
+	}
+	return nil
+}
+
+// Create registers an endpoint and generates its signing secret. The secret
+// is only returned here.
+func (s *Service) Create(ctx context.Context, ownerID int64, rawURL string, types []string) (*Webhook, error) {
+	if err := s.validate(ctx, rawURL, types); err != nil {
+		return nil, err
+	}
+	w := &Webhook{OwnerID: ownerID, URL: rawURL, Secret: "whsec_" + randomHex(32), EventTypes: types, Active: true}
+	err := s.DB.QueryRowContext(ctx,
+		"INSERT INTO webhooks (owner_id, url, secret, event_types) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		ownerID, rawURL, w.Secret, pq.Array(types)).Scan(&w.ID, &w.CreatedAt)
+	return w, err
+}
+
+// List returns the owner's webhooks, or every webhook when ownerID is 0.
+// Secrets are not included.
+func (s *Service) List(ctx context.Context, ownerID int64) ([]*Webhook, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, owner_id, url, event_types, active, created_at FROM webhooks
+		WHERE $1 = 0 OR owner_id = $1
+		ORDER BY id
+	`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*Webhook{}
+	for rows.Next() {
+		var w Webhook
+		if err := rows.Scan(&w.ID, &w.OwnerID, &w.URL, pq.Array(&w.EventTypes), &w.Active, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &w)
+	}
+	return list, rows.Err()
+}
+
+// OwnerOf returns who registered a webhook, for permission checks.
+func (s *Service) OwnerOf(ctx context.Context, webhookID int64) (int64, error) {
+	var owner int64
+	err := s.DB.QueryRowContext(ctx, "SELECT owner_id FROM webhooks WHERE id=$1", webhookID).Scan(&owner)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return owner, err
+}
+
+func (s *Service) Delete(ctx context.Context, webhookID int64) error {
+	_, err := s.DB.ExecContext(ctx, "DELETE FROM webhooks WHERE id=$1", webhookID)
+	return err
+}
+
+// Deliveries returns the most recent delivery log entries of a webhook.
+func (s *Service) Deliveries(ctx context.Context, webhookID int64) ([]*Delivery, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, webhook_id, event_type, payload, status, attempts, last_status_code, COALESCE(last_error, ''),
+		       redelivery_of, next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY id DESC
+		LIMIT 100
+	`, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*Delivery{}
+	for rows.Next() {
+		var d Delivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.LastStatusCode,
+			&d.LastError, &d.RedeliveryOf, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &d)
+	}
+	return list, rows.Err()
+}
+
+// WebhookOfDelivery returns the webhook a delivery belongs to.
+func (s *Service) WebhookOfDelivery(ctx context.Context, deliveryID int64) (int64, error) {
+	var id int64
+	err := s.DB.QueryRowContext(ctx, "SELECT webhook_id FROM webhook_deliveries WHERE id=$1", deliveryID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return id, err
+}
+
+// Redeliver queues a copy of a past delivery with a fresh retry budget. The
+// original stays in the log unchanged.
+func (s *Service) Redeliver(ctx context.Context, deliveryID int64) (int64, error) {
+	var id int64
+	err := s.DB.QueryRowContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload, redelivery_of)
+		SELECT webhook_id, event_type, payload, id FROM webhook_deliveries WHERE id = $1
+		RETURNING id
+	`, deliveryID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return id, err
+}
+
+// Ping queues a test delivery to one webhook.
+func (s *Service) Ping(ctx context.Context, webhookID int64) error {
+	payload, err := json.Marshal(Envelope{ID: randomHex(16), Type: Ping, CreatedAt: time.Now().UTC(), Data: map[string]int64{"webhook_id": webhookID}})
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx,
+		"INSERT INTO webhook_deliveries (webhook_id, event_type, payload) VALUES ($1, $2, $3)", webhookID, Ping, payload)
+	return err
+}
+
+// RegistrationPayload is the data of registration.*, waitlist.promoted and
+// checkin.recorded deliveries.
+type RegistrationPayload struct {
+	EventID   int64  `json:"event_id"`
+	UserID    int64  `json:"user_id"`
+	UserEmail string `json:"user_email"`
+	Status    string `json:"status"`
+}
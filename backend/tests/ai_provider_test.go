@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+)
+
+func fastClient(p ai.Provider) *ai.Client {
+	c := ai.NewClient(p)
+	c.BaseBackoff = time.Millisecond
+	c.MaxBackoff = 5 * time.Millisecond
+	return c
+}
+
+func TestMockProvider_DeterministicWithUsage(t *testing.T) {
+	c := fastClient(&ai.MockProvider{})
+	req := ai.CompletionRequest{Messages: []ai.Message{{Role: "user", Content: "what is on tonight"}}}
+
+	a, err := c.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	b, _ := c.Complete(context.Background(), req)
+	if a.Content == "" || a.Content != b.Content {
+		t.Fatalf("expected identical replies, got %q and %q", a.Content, b.Content)
+	}
+
+	usage, calls := c.Usage()
+	if calls != 2 || usage.PromptTokens != 8 || usage.TotalTokens != usage.PromptTokens+usage.CompletionTokens {
+		t.Fatalf("unexpected usage %+v after %d calls", usage, calls)
+	}
+}
+
+func TestClient_RetriesTransientFailures(t *testing.T) {
+	mock := &ai.MockProvider{Failures: []error{
+		&ai.StatusError{Code: 429},
+		&ai.StatusError{Code: 503},
+	}}
+	comp, err := fastClient(mock).Complete(context.Background(), ai.CompletionRequest{})
+	if err != nil || comp == nil || mock.Calls() != 3 {
+		t.Fatalf("expected success on third attempt, got err=%v calls=%d", err, mock.Calls())
+	}
+
+	mock = &ai.MockProvider{Failures: []error{&ai.StatusError{Code: 400}}}
+	_, err = fastClient(mock).Complete(context.Background(), ai.CompletionRequest{})
+	var se *ai.StatusError
+	if !errors.As(err, &se) || se.Code != 400 || mock.Calls() != 1 {
+		t.Fatalf("expected 400 without retry, got err=%v calls=%d", err, mock.Calls())
+	}
+}
+
+func TestClient_TimeoutAndCancellation(t *testing.T) {
+	mock := &ai.MockProvider{Delay: time.Second}
+	c := fastClient(mock)
+	c.Timeout = 10 * time.Millisecond
+	c.MaxRetries = 1
+
+	_, err := c.Complete(context.Background(), ai.CompletionRequest{})
+	if !errors.Is(err, context.DeadlineExceeded) || mock.Calls() != 2 {
+		t.Fatalf("expected timeout after retry, got err=%v calls=%d", err, mock.Calls())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Complete(ctx, ai.CompletionRequest{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestOpenAIProvider_CompatibleEndpoint(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": "hi from " + body.Model}}},
+			"usage":   map[string]int{"prompt_tokens": 5, "completion_tokens": 3, "total_tokens": 8},
+		})
+	}))
+	defer srv.Close()
+
+	svc := ai.NewServiceWithProvider(&ai.OpenAIProvider{BaseURL: srv.URL + "/v1/", Model: "test-model", APIKey: "sk-test"})
+	svc.Client.BaseBackoff = time.Millisecond
+
+	comp, err := svc.Converse(context.Background(), []ai.Message{{Role: "user", Content: "hello"}}, 0)
+	if err != nil || comp.Content != "hi from test-model" {
+		t.Fatalf("unexpected answer %+v (%v)", comp, err)
+	}
+	if usage, _ := svc.Client.Usage(); usage.TotalTokens != 8 {
+		t.Fatalf("expected provider-reported usage, got %+v", usage)
+	}
+}
+
+func TestOpenAIProvider_Stream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if !body.Stream {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, piece := range []string{"Hello", " there"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", piece)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":4,\"completion_tokens\":2,\"total_tokens\":6}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	svc := ai.NewServiceWithProvider(&ai.OpenAIProvider{BaseURL: srv.URL, Model: "test-model"})
+	var pieces []string
+	comp, err := svc.ConverseStream(context.Background(), []ai.Message{{Role: "user", Content: "hi"}}, 0, func(d string) {
+		pieces = append(pieces, d)
+	})
+	if err != nil || comp.Content != "Hello there" || len(pieces) != 2 {
+		t.Fatalf("unexpected stream %q / %+v (%v)", pieces, comp, err)
+	}
+	if comp.Usage.TotalTokens != 6 {
+		t.Fatalf("expected usage from the final chunk, got %+v", comp.Usage)
+	}
+}
+
+func TestAIService_UnavailableWithoutProvider(t *testing.T) {
+	svc := ai.NewServiceWithProvider(nil)
+	if svc.Available() {
+		t.Fatalf("expected service without provider to be unavailable")
+	}
+	if got := svc.AnalyzeSentiment(context.Background(), "great talk"); got != "NEUTRAL" {
+		t.Fatalf("expected NEUTRAL fallback, got %s", got)
+	}
+}
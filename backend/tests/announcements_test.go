@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/announcements"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/background"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestAnnouncements_AudiencesAndStats(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	svc := announcements.NewService(db, notifications.NewService(db))
+	h := &announcements.Handler{Service: svc, UserRepo: uRepo, EventRepo: eRepo}
+
+	org := seedUser(t, uRepo, "org-ann@x.com", "auth0|org-ann", "Organizer")
+	other := seedUser(t, uRepo, "other-ann@x.com", "auth0|other-ann", "Organizer")
+	reg := seedUser(t, uRepo, "reg-ann@x.com", "auth0|reg-ann", "Member")
+	wait := seedUser(t, uRepo, "wait-ann@x.com", "auth0|wait-ann", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Announce Event", "PRIVATE")
+
+	db.Exec(`INSERT INTO registrations (user_id, event_id, status) VALUES ($1, $2, 'REGISTERED')`, reg.ID, ev.ID)
+	db.Exec(`INSERT INTO waitlist (user_id, event_id) VALUES ($1, $2)`, wait.ID, ev.ID)
+	// reg is also invited, and one invitee has no account yet.
+	db.Exec(`INSERT INTO invitations (event_id, email) VALUES ($1, $2), ($1, 'guest-ann@x.com')`, ev.ID, reg.Email)
+
+	body, _ := json.Marshal(map[string]any{
+		"event_id": ev.ID, "subject": "Room change", "body": "Now in 201.",
+		"audiences": []string{"registered", "invited"},
+	})
+
+	// Other organizers cannot announce to this event.
+	req := injectClaims(httptest.NewRequest(http.MethodPost, "/events/announcements", bytes.NewReader(body)), other.OIDCID)
+	rr := httptest.NewRecorder()
+	h.HandleCreateAnnouncement(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another organizer, got %d", rr.Code)
+	}
+
+	req = injectClaims(httptest.NewRequest(http.MethodPost, "/events/announcements", bytes.NewReader(body)), org.OIDCID)
+	rr = httptest.NewRecorder()
+	h.HandleCreateAnnouncement(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var a announcements.Announcement
+	json.NewDecoder(rr.Body).Decode(&a)
+	if a.Status != announcements.StatusSent {
+		t.Fatalf("expected immediate send, got status %s", a.Status)
+	}
+	// reg once (deduped across audiences) plus the guest; not the waitlisted user.
+	if a.Stats.Recipients != 2 || a.Stats.InApp != 1 || a.Stats.Emailed != 2 {
+		t.Fatalf("unexpected stats %+v", a.Stats)
+	}
+
+	db.Exec(`UPDATE notifications SET is_read = TRUE WHERE user_id=$1`, reg.ID)
+	got, _ := svc.Get(context.Background(), a.ID)
+	if got.Stats.Read != 1 {
+		t.Fatalf("expected 1 read, got %+v", got.Stats)
+	}
+
+	recipients, err := svc.Recipients(context.Background(), a.ID)
+	if err != nil || len(recipients) != 2 {
+		t.Fatalf("expected 2 recipients, got %d (%v)", len(recipients), err)
+	}
+	for _, rc := range recipients {
+		if rc.Email == "guest-ann@x.com" && (rc.UserID != nil || rc.InApp || rc.EmailStatus != "PENDING") {
+			t.Fatalf("guest should get email only, got %+v", rc)
+		}
+		if rc.Email == reg.Email && (rc.Audience != "registered" || !rc.Read) {
+			t.Fatalf("unexpected registered recipient %+v", rc)
+		}
+	}
+}
+
+func TestAnnouncements_ScheduledAndCancelled(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	svc := announcements.NewService(db, notifications.NewService(db))
+	ctx := context.Background()
+
+	org := seedUser(t, uRepo, "org-sched@x.com", "auth0|org-sched", "Organizer")
+	wait := seedUser(t, uRepo, "wait-sched@x.com", "auth0|wait-sched", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Scheduled Event", "PUBLIC")
+	db.Exec(`INSERT INTO waitlist (user_id, event_id) VALUES ($1, $2)`, wait.ID, ev.ID)
+
+	later := time.Now().Add(time.Hour)
+	a, err := svc.Create(ctx, ev.ID, org.ID, "Spots may open", "Stay tuned.", []string{"waitlisted"}, &later)
+	if err != nil || a.Status != announcements.StatusScheduled {
+		t.Fatalf("expected scheduled announcement, got %+v (%v)", a, err)
+	}
+	dropped, _ := svc.Create(ctx, ev.ID, org.ID, "Never mind", "Ignore.", []string{"waitlisted"}, &later)
+	if err := svc.Cancel(ctx, dropped.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	// Make the first one due and run two schedulers, as two instances would.
+	db.Exec(`UPDATE announcements SET send_at = NOW() - INTERVAL '1 minute'`)
+	sched := background.NewAnnouncementScheduler(svc)
+	sched.Test_SendDue()
+	sched.Test_SendDue()
+
+	var notes int
+	db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id=$1`, wait.ID).Scan(&notes)
+	if notes != 1 {
+		t.Fatalf("expected exactly 1 announcement notification, got %d", notes)
+	}
+	got, _ := svc.Get(ctx, dropped.ID)
+	if got.Status != announcements.StatusCancelled || got.Stats.Recipients != 0 {
+		t.Fatalf("cancelled announcement should not be sent, got %+v", got)
+	}
+	if err := svc.Cancel(ctx, a.ID); err != announcements.ErrNotScheduled {
+		t.Fatalf("expected ErrNotScheduled cancelling a sent announcement, got %v", err)
+	}
+}
+
+func TestAnnouncements_FailingAnnouncementDoesNotBlockOthers(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	svc := announcements.NewService(db, notifications.NewService(db))
+	ctx := context.Background()
+
+	org := seedUser(t, uRepo, "org-fail@x.com", "auth0|org-fail", "Organizer")
+	broken := seedUser(t, uRepo, "broken-fail@x.com", "auth0|broken-fail", "Member")
+	fine := seedUser(t, uRepo, "fine-fail@x.com", "auth0|fine-fail", "Member")
+	bad := seedEvent(t, eRepo, org.ID, "Bad Event", "PUBLIC")
+	good := seedEvent(t, eRepo, org.ID, "Good Event", "PUBLIC")
+	db.Exec(`INSERT INTO registrations (user_id, event_id, status) VALUES ($1, $2, 'REGISTERED'), ($3, $4, 'REGISTERED')`, broken.ID, bad.ID, fine.ID, good.ID)
+
+	// A template override that cannot render, used only by one recipient's
+	// locale, makes the first announcement fail every time.
+	db.Exec(`UPDATE users SET locale = 'xx' WHERE id = $1`, broken.ID)
+	db.Exec(`INSERT INTO notification_templates (name, locale, subject, text_body) VALUES ($1, 'xx', '{{.Nope}}', 'x')`, notifications.TmplAnnouncement)
+
+	later := time.Now().Add(time.Hour)
+	first, _ := svc.Create(ctx, bad.ID, org.ID, "First", "Fails.", []string{"registered"}, &later)
+	second, _ := svc.Create(ctx, good.ID, org.ID, "Second", "Works.", []string{"registered"}, &later)
+	db.Exec(`UPDATE announcements SET send_at = NOW() - INTERVAL '2 minutes' WHERE id = $1`, first.ID)
+	db.Exec(`UPDATE announcements SET send_at = NOW() - INTERVAL '1 minute' WHERE id = $1`, second.ID)
+
+	sent, err := svc.SendDue(ctx)
+	if sent != 1 || err == nil {
+		t.Fatalf("expected 1 sent and the failure reported, got %d (%v)", sent, err)
+	}
+	if got, _ := svc.Get(ctx, second.ID); got.Status != announcements.StatusSent {
+		t.Fatalf("expected the later announcement to be sent, got %s", got.Status)
+	}
+	got, _ := svc.Get(ctx, first.ID)
+	if got.Status != announcements.StatusScheduled || got.Attempts != 1 || got.LastError == "" {
+		t.Fatalf("expected a recorded attempt on the failing announcement, got %+v", got)
+	}
+
+	// It waits before the next try, and is given up on after the last one.
+	if sent, err := svc.SendDue(ctx); sent != 0 || err != nil {
+		t.Fatalf("expected no retry before the backoff ends, got %d (%v)", sent, err)
+	}
+	db.Exec(`UPDATE announcements SET attempts = 4, next_attempt_at = NOW() WHERE id = $1`, first.ID)
+	svc.SendDue(ctx)
+	if got, _ := svc.Get(ctx, first.ID); got.Status != announcements.StatusFailed || got.Attempts != 5 {
+		t.Fatalf("expected FAILED after the last attempt, got %+v", got)
+	}
+}
@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+// fakeUsers resolves a token subject to a user without a database.
+type fakeUsers map[string]*store.User
+
+func (f fakeUsers) GetByOIDCID(_ context.Context, oidcID string) (*store.User, error) {
+	if u, ok := f[oidcID]; ok {
+		return u, nil
+	}
+	return nil, errors.New("not found")
+}
+
+// apiRoutes reads the protected routes registered in main.go.
+func apiRoutes(t *testing.T) []string {
+	t.Helper()
+	src, err := os.ReadFile("../cmd/api/main.go")
+	if err != nil {
+		t.Fatalf("read main.go: %v", err)
+	}
+	var routes []string
+	for _, m := range regexp.MustCompile(`apiMux\.Handle(?:Func)?\("([^"]+)"`).FindAllStringSubmatch(string(src), -1) {
+		routes = append(routes, m[1])
+	}
+	if len(routes) == 0 {
+		t.Fatal("found no routes in main.go")
+	}
+	return routes
+}
+
+func TestAuthzPolicy_CoversEveryRoute(t *testing.T) {
+	routes := apiRoutes(t)
+	registered := map[string]bool{}
+	for _, r := range routes {
+		registered[r] = true
+		if _, ok := authz.Policy[r]; !ok {
+			t.Errorf("route %q has no entry in authz.Policy", r)
+		}
+	}
+	for r := range authz.Policy {
+		if !registered[r] {
+			t.Errorf("authz.Policy lists %q, which main.go does not register", r)
+		}
+	}
+}
+
+// lowestRole is the weakest role expected to reach each route. It is
+// written out by hand, so a wrong entry in authz.Policy fails the test.
+var lowestRole = map[string]string{
+	"POST /users/sync":                     authz.RoleMember,
+	"GET /admin/users":                     authz.RoleAdmin,
+	"PATCH /admin/users/role":              authz.RoleAdmin,
+	"PATCH /admin/users/active":            authz.RoleAdmin,
+	"GET /admin/users/sync-log":            authz.RoleAdmin,
+	"GET /leaderboard":                     authz.RoleMember,
+	"GET /users/badges":                    authz.RoleMember,
+	"PATCH /users/settings":                authz.RoleMember,
+	"POST /events":                         authz.RoleMember,
+	"POST /events/draft":                   authz.RoleMember,
+	"POST /events/duplicates/check":        authz.RoleMember,
+	"GET /events/links":                    authz.RoleMember,
+	"POST /events/links":                   authz.RoleMember,
+	"POST /events/merge":                   authz.RoleMember,
+	"PUT /events":                          authz.RoleMember,
+	"POST /events/cancel":                  authz.RoleMember,
+	"POST /events/invite":                  authz.RoleMember,
+	"POST /events/invite/bulk":             authz.RoleMember,
+	"GET /events/attendees":                authz.RoleMember,
+	"GET /events/export":                   authz.RoleMember,
+	"POST /events/feedback":                authz.RoleMember,
+	"GET /events/feedback/report":          authz.RoleMember,
+	"GET /events/certificate":              authz.RoleMember,
+	"POST /events/comments":                authz.RoleMember,
+	"POST /events/photos":                  authz.RoleMember,
+	"POST /events/checkin":                 authz.RoleOrganizer,
+	"POST /moderation/reports":             authz.RoleMember,
+	"GET /moderation/queue":                authz.RoleMember,
+	"POST /moderation/actions":             authz.RoleMember,
+	"POST /moderation/bans":                authz.RoleMember,
+	"DELETE /moderation/bans":              authz.RoleMember,
+	"GET /moderation/audit":                authz.RoleMember,
+	"POST /registrations":                  authz.RoleMember,
+	"DELETE /registrations":                authz.RoleMember,
+	"GET /registrations/me":                authz.RoleMember,
+	"GET /events/applications":             authz.RoleMember,
+	"POST /events/applications/decide":     authz.RoleMember,
+	"GET /notifications":                   authz.RoleMember,
+	"PATCH /notifications":                 authz.RoleMember,
+	"DELETE /notifications":                authz.RoleMember,
+	"GET /notifications/unread-count":      authz.RoleMember,
+	"POST /notifications/read":             authz.RoleMember,
+	"GET /notifications/stream":            authz.RoleMember,
+	"GET /notifications/preferences":       authz.RoleMember,
+	"PUT /notifications/preferences":       authz.RoleMember,
+	"GET /admin/outbox":                    authz.RoleAdmin,
+	"POST /admin/outbox/retry":             authz.RoleAdmin,
+	"GET /admin/templates":                 authz.RoleAdmin,
+	"PUT /admin/templates":                 authz.RoleAdmin,
+	"DELETE /admin/templates":              authz.RoleAdmin,
+	"POST /admin/templates/preview":        authz.RoleAdmin,
+	"POST /events/announcements":           authz.RoleMember,
+	"GET /events/announcements":            authz.RoleMember,
+	"DELETE /events/announcements":         authz.RoleMember,
+	"GET /events/announcements/recipients": authz.RoleMember,
+	"GET /recommendations":                 authz.RoleMember,
+	"GET /webhooks":                        authz.RoleOrganizer,
+	"POST /webhooks":                       authz.RoleOrganizer,
+	"DELETE /webhooks":                     authz.RoleOrganizer,
+	"GET /webhooks/deliveries":             authz.RoleOrganizer,
+	"POST /webhooks/redeliver":             authz.RoleOrganizer,
+	"POST /webhooks/ping":                  authz.RoleOrganizer,
+	"GET /organizations":                   authz.RoleMember,
+	"POST /organizations":                  authz.RoleOrganizer,
+	"GET /organizations/members":           authz.RoleMember,
+	"PUT /organizations/members":           authz.RoleMember,
+	"DELETE /organizations/members":        authz.RoleMember,
+	"POST /organizations/transfer":         authz.RoleMember,
+	"GET /organizations/events":            authz.RoleMember,
+	"GET /organizations/analytics":         authz.RoleMember,
+	"GET /organizations/export":            authz.RoleMember,
+	"GET /follows":                         authz.RoleMember,
+	"POST /follows":                        authz.RoleMember,
+	"DELETE /follows":                      authz.RoleMember,
+	"GET /follows/feed":                    authz.RoleMember,
+	"GET /organizers":                      authz.RoleMember,
+	"GET /friends":                         authz.RoleMember,
+	"DELETE /friends":                      authz.RoleMember,
+	"POST /friends/requests":               authz.RoleMember,
+	"POST /friends/requests/respond":       authz.RoleMember,
+	"GET /events/friends":                  authz.RoleMember,
+	"PATCH /registrations/visibility":      authz.RoleMember,
+	"GET /admin/analytics":                 authz.RoleAdmin,
+	"GET /analytics":                       authz.RoleAdmin,
+	"GET /analytics/export":                authz.RoleAdmin,
+}
+
+func TestAuthzGuard_RoleMatrix(t *testing.T) {
+	users := fakeUsers{
+		"auth0|admin":     {ID: 1, Role: authz.RoleAdmin},
+		"auth0|organizer": {ID: 2, Role: authz.RoleOrganizer},
+		"auth0|member":    {ID: 3, Role: authz.RoleMember},
+	}
+	rank := map[string]int{authz.RoleMember: 0, authz.RoleOrganizer: 1, authz.RoleAdmin: 2}
+	mux := http.NewServeMux()
+	for pattern := range authz.Policy {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	}
+	guard := authz.Guard(mux, users, authz.Policy)
+
+	for pattern := range authz.Policy {
+		if _, ok := lowestRole[pattern]; !ok {
+			t.Errorf("route %q has no expected role in this test", pattern)
+		}
+	}
+	for pattern, lowest := range lowestRole {
+		method, path, _ := strings.Cut(pattern, " ")
+		for sub, user := range users {
+			rr := httptest.NewRecorder()
+			guard.ServeHTTP(rr, injectClaims(httptest.NewRequest(method, path, nil), sub))
+			want := http.StatusForbidden
+			if rank[user.Role] >= rank[lowest] {
+				want = http.StatusOK
+			}
+			if rr.Code != want {
+				t.Errorf("%s as %s: got %d, want %d", pattern, user.Role, rr.Code, want)
+			}
+		}
+	}
+}
+
+func TestAuthzGuard_FailsClosed(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /unlisted", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	mux.HandleFunc("GET /admin/users", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	guard := authz.Guard(mux, fakeUsers{}, authz.Policy)
+
+	rr := httptest.NewRecorder()
+	guard.ServeHTTP(rr, injectClaims(httptest.NewRequest(http.MethodGet, "/unlisted", nil), "auth0|anyone"))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected a route without a policy to be refused, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	guard.ServeHTTP(rr, injectClaims(httptest.NewRequest(http.MethodGet, "/admin/users", nil), "auth0|unknown"))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a token without a user, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	guard.ServeHTTP(rr, injectClaims(httptest.NewRequest(http.MethodGet, "/nowhere", nil), "auth0|anyone"))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown path, got %d", rr.Code)
+	}
+}
+
+func TestAuthzRoles(t *testing.T) {
+	for _, role := range []string{authz.RoleAdmin, authz.RoleOrganizer, authz.RoleMember} {
+		if !authz.ValidRole(role) {
+			t.Errorf("expected %s to be a valid role", role)
+		}
+	}
+	if authz.ValidRole("Superuser") || authz.Can("Superuser", authz.EventCreate) {
+		t.Fatal("expected unknown roles to have no permissions")
+	}
+	if !authz.CanManageEvent(authz.RoleMember, 7, 7) || authz.CanManageEvent(authz.RoleOrganizer, 7, 8) || !authz.CanManageEvent(authz.RoleAdmin, 7, 8) {
+		t.Fatal("expected organizers to manage only their own events and admins all")
+	}
+	if got := authz.RolesWith(authz.EventCreate); len(got) != 2 || got[0] != authz.RoleOrganizer || got[1] != authz.RoleAdmin {
+		t.Fatalf("expected Organizer and Admin to have event.create, got %v", got)
+	}
+}
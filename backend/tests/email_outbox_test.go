@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/background"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/registration"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+type failingTransport struct{}
+
+func (failingTransport) Send(ctx context.Context, msg notifications.Message) error {
+	return errors.New("smtp: connection refused")
+}
+
+func TestBackoff_DoublesUntilCap(t *testing.T) {
+	base, max := 30*time.Second, 5*time.Minute
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, w := range want {
+		if got := background.Backoff(i+1, base, max); got != w {
+			t.Fatalf("attempt %d: expected %v, got %v", i+1, w, got)
+		}
+	}
+}
+
+func TestFileTransport_WritesMaildir(t *testing.T) {
+	dir := t.TempDir()
+	tr := &notifications.FileTransport{Dir: dir, From: "test@campussync.local"}
+
+	if err := tr.Send(context.Background(), notifications.Message{To: "a@x.com", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(dir, "new"))
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 message in maildir, got %d", len(entries))
+	}
+	raw, _ := os.ReadFile(filepath.Join(dir, "new", entries[0].Name()))
+	if !strings.Contains(string(raw), "Subject: Hi") || !strings.Contains(string(raw), "Hello") {
+		t.Fatalf("unexpected message contents: %s", raw)
+	}
+}
+
+func TestSMTPTransport_HungRelayTimesOut(t *testing.T) {
+	// A relay that accepts the connection but never sends its greeting.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	msg := notifications.Message{To: "a@x.com", Subject: "Hi", Body: "Hello"}
+
+	tr := &notifications.SMTPTransport{Host: host, Port: port, From: "test@campussync.local", Timeout: 200 * time.Millisecond}
+	start := time.Now()
+	if err := tr.Send(context.Background(), msg); err == nil {
+		t.Fatal("expected a hung relay to fail the send")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("send took %v, expected it to stop at the timeout", elapsed)
+	}
+
+	tr.Timeout = time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	start = time.Now()
+	if err := tr.Send(ctx, msg); err == nil {
+		t.Fatal("expected a cancelled context to fail the send")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("send took %v after cancel, expected it to stop promptly", elapsed)
+	}
+}
+
+func TestOutbox_RegistrationQueuesAndDispatcherDelivers(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
+
+	org := seedUser(t, uRepo, "org-outbox@x.com", "auth0|org-outbox", "Organizer")
+	u := seedUser(t, uRepo, "outbox@x.com", "auth0|outbox", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Outbox Event", "PUBLIC")
+
+	if _, err := svc.RegisterUserForEvent(ctx, u.ID, ev.ID); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	var queued int
+	db.QueryRow(`SELECT COUNT(*) FROM email_outbox WHERE to_email=$1 AND status='PENDING'`, u.Email).Scan(&queued)
+	if queued != 1 {
+		t.Fatalf("expected 1 queued email, got %d", queued)
+	}
+
+	dir := t.TempDir()
+	d := background.NewEmailDispatcher(db, &notifications.FileTransport{Dir: dir})
+	d.Test_DispatchBatch()
+
+	var status string
+	db.QueryRow(`SELECT status FROM email_outbox WHERE to_email=$1`, u.Email).Scan(&status)
+	if status != "SENT" {
+		t.Fatalf("expected SENT, got %s", status)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "new"))
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 delivered file, got %d", len(entries))
+	}
+}
+
+func TestOutbox_FailuresRetryThenDeadLetter(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	eRepo := store.NewEventRepository(db)
+	nsvc := notifications.NewService(db)
+
+	if err := nsvc.Enqueue(ctx, nil, notifications.Message{To: "dead@x.com", Subject: "S", Body: "B"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	d := background.NewEmailDispatcher(db, failingTransport{})
+	d.MaxAttempts = 2
+
+	d.Test_DispatchBatch()
+	var attempts int
+	var status string
+	db.QueryRow(`SELECT attempts, status FROM email_outbox WHERE to_email='dead@x.com'`).Scan(&attempts, &status)
+	if attempts != 1 || status != "PENDING" {
+		t.Fatalf("expected 1 attempt still PENDING, got %d %s", attempts, status)
+	}
+
+	// Make the retry due immediately instead of waiting out the backoff.
+	db.Exec(`UPDATE email_outbox SET next_attempt_at = NOW() - INTERVAL '1 second'`)
+	d.Test_DispatchBatch()
+
+	dead, err := eRepo.ListOutbox(ctx, "DEAD")
+	if err != nil {
+		t.Fatalf("ListOutbox: %v", err)
+	}
+	if len(dead) != 1 || dead[0].LastError == "" {
+		t.Fatalf("expected 1 dead-lettered message with error, got %+v", dead)
+	}
+
+	if err := eRepo.RequeueOutboxMessage(ctx, dead[0].ID); err != nil {
+		t.Fatalf("RequeueOutboxMessage: %v", err)
+	}
+	db.QueryRow(`SELECT attempts, status FROM email_outbox WHERE id=$1`, dead[0].ID).Scan(&attempts, &status)
+	if attempts != 0 || status != "PENDING" {
+		t.Fatalf("expected requeued message, got %d %s", attempts, status)
+	}
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/events"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+const goodDraft = `{"title": "Intro to Rust", "description": "A hands-on workshop.", "category": "Workshop", "tags": ["Rust", "#programming", "rust"], "custom_fields": [{"label": "Experience level", "type": "text", "required": true}]}`
+
+func TestAIService_DraftEventIsStrict(t *testing.T) {
+	reply := goodDraft
+	svc := ai.NewServiceWithProvider(&ai.MockProvider{Reply: func(ai.CompletionRequest) string { return reply }})
+	ctx := context.Background()
+
+	draft, err := svc.DraftEvent(ctx, []string{"rust workshop"}, events.Categories, events.CustomFieldTypes)
+	if err != nil || draft.Title != "Intro to Rust" || len(draft.CustomFields) != 1 {
+		t.Fatalf("unexpected draft %+v (%v)", draft, err)
+	}
+
+	for _, bad := range []string{
+		"Sure! Here is your event: " + goodDraft,
+		`{"title": "x", "description": "y", "category": "Workshop", "tags": [], "custom_fields": [], "price": 5}`,
+		goodDraft + ` {"title": "again"}`,
+	} {
+		reply = bad
+		if _, err := svc.DraftEvent(ctx, []string{"rust workshop"}, events.Categories, events.CustomFieldTypes); !errors.Is(err, ai.ErrInvalidReply) {
+			t.Fatalf("expected ErrInvalidReply for %q, got %v", bad, err)
+		}
+	}
+}
+
+func TestHandleDraftEvent_ValidatedDraftCanBeSaved(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userRepo := store.NewUserRepository(db)
+	eventRepo := store.NewEventRepository(db)
+	org := seedUser(t, userRepo, "org-draft@x.com", "auth0|org-draft", "Organizer")
+	member := seedUser(t, userRepo, "member-draft@x.com", "auth0|member-draft", "Member")
+
+	reply := goodDraft
+	h := &events.Handler{
+		Repo:     eventRepo,
+		UserRepo: userRepo,
+		AI:       ai.NewServiceWithProvider(&ai.MockProvider{Reply: func(ai.CompletionRequest) string { return reply }}),
+	}
+	draft := func(sub string) *httptest.ResponseRecorder {
+		body := bytes.NewBufferString(`{"notes": ["Rust for beginners", "CS undergrads", "Friday 5pm", "Iribe Center"]}`)
+		rr := httptest.NewRecorder()
+		h.HandleDraftEvent(rr, injectClaims(httptest.NewRequest(http.MethodPost, "/events/draft", body), sub))
+		return rr
+	}
+
+	if rr := draft(member.OIDCID); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected members to be forbidden, got %d", rr.Code)
+	}
+
+	rr := draft(org.OIDCID)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected draft, got %d %s", rr.Code, rr.Body.String())
+	}
+	var got events.EventDraft
+	json.NewDecoder(rr.Body).Decode(&got)
+	if len(got.Tags) != 2 || got.Tags[0] != "rust" || got.Tags[1] != "programming" {
+		t.Fatalf("expected normalized tags, got %v", got.Tags)
+	}
+
+	// The organizer edits the draft and saves it as usual.
+	start := time.Now().Add(48 * time.Hour)
+	create, _ := json.Marshal(events.CreateEventRequest{
+		Title: got.Title, Description: got.Description, Category: got.Category,
+		Tags: got.Tags, CustomFields: got.CustomFields,
+		Location: "Iribe Center", StartTime: start, EndTime: start.Add(time.Hour), Capacity: 30, Visibility: "PUBLIC",
+	})
+	rr = httptest.NewRecorder()
+	h.HandleCreateEvent(rr, injectClaims(httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(create)), org.OIDCID))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected event to be created, got %d %s", rr.Code, rr.Body.String())
+	}
+	var created store.Event
+	json.NewDecoder(rr.Body).Decode(&created)
+	saved, err := eventRepo.GetEventByID(context.Background(), created.ID)
+	if err != nil || len(saved.Tags) != 2 || len(saved.CustomFields) != 1 || !saved.CustomFields[0].Required {
+		t.Fatalf("expected tags and custom fields to be saved, got %+v (%v)", saved, err)
+	}
+
+	// A reply that parses but breaks the rules is rejected, not passed on.
+	reply = `{"title": "Rust", "description": "Workshop", "category": "Hackathon", "tags": [], "custom_fields": []}`
+	if rr := draft(org.OIDCID); rr.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502 for an unknown category, got %d", rr.Code)
+	}
+}
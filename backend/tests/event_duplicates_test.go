@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/events"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestEventDuplicates_CreateLinkAndMerge(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	chess := seedUser(t, uRepo, "dup-chess@x.com", "auth0|dup-chess", "Organizer")
+	music := seedUser(t, uRepo, "dup-music@x.com", "auth0|dup-music", "Organizer")
+	admin := seedUser(t, uRepo, "dup-admin@x.com", "auth0|dup-admin", "Admin")
+	member := seedUser(t, uRepo, "dup-member@x.com", "auth0|dup-member", "Member")
+	h := &events.Handler{Repo: eRepo, UserRepo: uRepo, Notifications: notifications.NewService(db)}
+	ctx := context.Background()
+
+	start := time.Now().Add(72 * time.Hour).Truncate(time.Hour).UTC()
+	create := func(sub string, body map[string]any) *httptest.ResponseRecorder {
+		body["description"] = "Bring a friend."
+		body["capacity"] = 30
+		body["visibility"] = "PUBLIC"
+		body["end_time"] = body["start_time"].(time.Time).Add(2 * time.Hour)
+		b, _ := json.Marshal(body)
+		rr := httptest.NewRecorder()
+		h.HandleCreateEvent(rr, injectClaims(httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(b)), sub))
+		return rr
+	}
+
+	rr := create(music.OIDCID, map[string]any{"title": "Spring Jazz Night", "location": "Student Union", "start_time": start})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected the first event to be created, got %d %s", rr.Code, rr.Body.String())
+	}
+	var original store.Event
+	json.NewDecoder(rr.Body).Decode(&original)
+
+	// A re-post with a typo, half an hour later, in the same building.
+	repost := map[string]any{"title": "Spring jazz nite", "location": "Student Union, Main Hall", "start_time": start.Add(30 * time.Minute)}
+	rr = create(chess.OIDCID, repost)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a probable duplicate, got %d %s", rr.Code, rr.Body.String())
+	}
+	var conflict struct {
+		Duplicates []events.Match `json:"duplicates"`
+	}
+	json.NewDecoder(rr.Body).Decode(&conflict)
+	if len(conflict.Duplicates) != 1 || conflict.Duplicates[0].ID != original.ID || len(conflict.Duplicates[0].Reasons) != 3 {
+		t.Fatalf("expected the original as the only match, got %+v", conflict.Duplicates)
+	}
+
+	// A different event in the same slot and room is not a duplicate.
+	if rr := create(chess.OIDCID, map[string]any{"title": "Chess Tournament", "location": "Student Union", "start_time": start}); rr.Code != http.StatusCreated {
+		t.Fatalf("expected an unrelated event to be created, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	repost["duplicate_action"] = "link"
+	rr = create(chess.OIDCID, repost)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected the linked event to be created, got %d %s", rr.Code, rr.Body.String())
+	}
+	var copied store.Event
+	json.NewDecoder(rr.Body).Decode(&copied)
+
+	rr = httptest.NewRecorder()
+	h.HandleListLinks(rr, injectClaims(httptest.NewRequest(http.MethodGet, "/events/links?event_id="+strconv.FormatInt(original.ID, 10), nil), member.OIDCID))
+	var links []store.LinkedEvent
+	json.NewDecoder(rr.Body).Decode(&links)
+	if len(links) != 1 || links[0].ID != copied.ID || links[0].Kind != store.LinkDuplicate {
+		t.Fatalf("expected the copy to be linked, got %d %+v", rr.Code, links)
+	}
+
+	if _, err := db.ExecContext(ctx, "INSERT INTO registrations (user_id, event_id, status) VALUES ($1, $2, 'REGISTERED')", member.ID, copied.ID); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	merge := func(sub string) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(map[string]int64{"source_id": copied.ID, "target_id": original.ID})
+		rr := httptest.NewRecorder()
+		h.HandleMergeEvents(rr, injectClaims(httptest.NewRequest(http.MethodPost, "/events/merge", bytes.NewReader(b)), sub))
+		return rr
+	}
+	if rr := merge(chess.OIDCID); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 merging into someone else's event, got %d", rr.Code)
+	}
+	if rr := merge(admin.OIDCID); rr.Code != http.StatusOK {
+		t.Fatalf("expected the merge to succeed, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	var status string
+	db.QueryRowContext(ctx, "SELECT status FROM registrations WHERE user_id = $1 AND event_id = $2", member.ID, original.ID).Scan(&status)
+	if status != "REGISTERED" {
+		t.Fatalf("expected the registration to move to the kept event, got %q", status)
+	}
+	if ev, _ := eRepo.GetEventByID(ctx, copied.ID); ev.Status != "CANCELLED" {
+		t.Fatalf("expected the duplicate to be cancelled, got %q", ev.Status)
+	}
+	merged, _ := eRepo.GetLinkedEvents(ctx, copied.ID)
+	if len(merged) != 1 || merged[0].Kind != store.LinkMerged || !merged[0].MergedInto {
+		t.Fatalf("expected a merged link to the kept event, got %+v", merged)
+	}
+	if rr := merge(admin.OIDCID); rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 merging a cancelled event, got %d", rr.Code)
+	}
+}
@@ -22,7 +22,7 @@ func TestHandleListAttendees_ReturnsAllStatuses(t *testing.T) {
 	h := &events.Handler{
 		Repo:          eventRepo,
 		UserRepo:      userRepo,
-		Notifications: notifications.NewService(),
+		Notifications: notifications.NewService(db),
 	}
 
 	org := seedUser(t, userRepo, "org-att@x.com", "auth0|org-att", "Organizer")
@@ -66,6 +66,7 @@ func TestHandleListAttendees_ReturnsAllStatuses(t *testing.T) {
 		"/attendees?event_id="+strconv.FormatInt(ev.ID, 10),
 		nil,
 	)
+	req = injectClaims(req, org.OIDCID)
 	w := httptest.NewRecorder()
 
 	h.HandleListAttendees(w, req)
@@ -93,6 +94,53 @@ func TestHandleListAttendees_ReturnsAllStatuses(t *testing.T) {
 	}
 }
 
+// Covers: HandleCheckIn only for events the caller manages
+func TestHandleCheckIn_OnlyForManagedEvents(t *testing.T) {
+	db := setupTestDB(t)
+
+	userRepo := store.NewUserRepository(db)
+	eventRepo := store.NewEventRepository(db)
+	h := &events.Handler{
+		Repo:          eventRepo,
+		UserRepo:      userRepo,
+		Notifications: notifications.NewService(db),
+	}
+
+	org := seedUser(t, userRepo, "org-chk@x.com", "auth0|org-chk", "Organizer")
+	other := seedUser(t, userRepo, "org-chk2@x.com", "auth0|org-chk2", "Organizer")
+	attendee := seedUser(t, userRepo, "chk@x.com", "auth0|chk", "Member")
+	ev := seedEvent(t, eventRepo, org.ID, "Check-in Event", "PUBLIC")
+
+	if _, err := db.Exec(`INSERT INTO registrations (user_id, event_id, status) VALUES ($1, $2, 'REGISTERED')`, attendee.ID, ev.ID); err != nil {
+		t.Fatalf("seed registration: %v", err)
+	}
+
+	checkIn := func(sub string) int {
+		body, _ := json.Marshal(map[string]int64{"event_id": ev.ID, "user_id": attendee.ID})
+		req := injectClaims(httptest.NewRequest(http.MethodPost, "/events/checkin", bytes.NewReader(body)), sub)
+		w := httptest.NewRecorder()
+		h.HandleCheckIn(w, req)
+		return w.Code
+	}
+
+	if code := checkIn(other.OIDCID); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another organizer, got %d", code)
+	}
+	var status string
+	db.QueryRow(`SELECT status FROM registrations WHERE user_id=$1 AND event_id=$2`, attendee.ID, ev.ID).Scan(&status)
+	if status != "REGISTERED" {
+		t.Fatalf("expected attendance untouched, got %s", status)
+	}
+
+	if code := checkIn(org.OIDCID); code != http.StatusOK {
+		t.Fatalf("expected 200 for the organizer, got %d", code)
+	}
+	db.QueryRow(`SELECT status FROM registrations WHERE user_id=$1 AND event_id=$2`, attendee.ID, ev.ID).Scan(&status)
+	if status != "ATTENDED" {
+		t.Fatalf("expected ATTENDED, got %s", status)
+	}
+}
+
 // Covers: HandleAddFeedback + AddFeedback
 func TestHandleAddFeedback_ValidAndInvalidRating(t *testing.T) {
 	db := setupTestDB(t)
@@ -102,7 +150,7 @@ func TestHandleAddFeedback_ValidAndInvalidRating(t *testing.T) {
 	h := &events.Handler{
 		Repo:          eventRepo,
 		UserRepo:      userRepo,
-		Notifications: notifications.NewService(),
+		Notifications: notifications.NewService(db),
 	}
 
 	member := seedUser(t, userRepo, "fb@x.com", "auth0|fb", "Member")
@@ -166,7 +214,7 @@ func TestHandleGetAnalytics_AdminAndForbidden(t *testing.T) {
 	h := &events.Handler{
 		Repo:          eventRepo,
 		UserRepo:      userRepo,
-		Notifications: notifications.NewService(),
+		Notifications: notifications.NewService(db),
 	}
 
 	admin := seedUser(t, userRepo, "admin@stats.com", "auth0|admin-stats", "Admin")
@@ -59,7 +59,7 @@ func TestHandleInviteUser_RBACAndInsert(t *testing.T) {
 
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	nsvc := notifications.NewService()
+	nsvc := notifications.NewService(db)
 
 	h := &events.Handler{
 		Repo:          eventRepo,
@@ -124,7 +124,7 @@ func TestHandleBulkInvite_CreatesInvitations(t *testing.T) {
 
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	nsvc := notifications.NewService()
+	nsvc := notifications.NewService(db)
 
 	h := &events.Handler{
 		Repo:          eventRepo,
@@ -188,7 +188,7 @@ func TestHandleExportAttendees_ReturnsCSV(t *testing.T) {
 	db := setupTestDB(t)
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	nsvc := notifications.NewService()
+	nsvc := notifications.NewService(db)
 
 	h := &events.Handler{
 		Repo:          eventRepo,
@@ -211,6 +211,7 @@ func TestHandleExportAttendees_ReturnsCSV(t *testing.T) {
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/events/export?event_id="+strconv.FormatInt(ev.ID, 10), nil)
+	req = injectClaims(req, org.OIDCID)
 	w := httptest.NewRecorder()
 
 	h.HandleExportAttendees(w, req)
@@ -17,13 +17,15 @@ func TestHandleUpdateEvent_ForbiddenForNonOrganizers(t *testing.T) {
 	db := setupTestDB(t)
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	h := &events.Handler{Repo: eventRepo, UserRepo: userRepo, Notifications: notifications.NewService()}
+	h := &events.Handler{Repo: eventRepo, UserRepo: userRepo, Notifications: notifications.NewService(db)}
 
-	// seed a normal member
+	// seed a normal member and someone else's event
 	member := seedUser(t, userRepo, "member@x.com", "auth0|member", "Member")
+	org := seedUser(t, userRepo, "org-neg@x.com", "auth0|org-neg", "Organizer")
+	ev := seedEvent(t, eventRepo, org.ID, "Not Yours", "PUBLIC")
 
 	body, _ := json.Marshal(map[string]interface{}{
-		"id":         123,
+		"id":         ev.ID,
 		"title":      "New Title",
 		"location":   "Loc",
 		"start_time": time.Now(),
@@ -48,7 +50,7 @@ func TestHandleUpdateEvent_InvalidJSON(t *testing.T) {
 	db := setupTestDB(t)
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	h := &events.Handler{Repo: eventRepo, UserRepo: userRepo, Notifications: notifications.NewService()}
+	h := &events.Handler{Repo: eventRepo, UserRepo: userRepo, Notifications: notifications.NewService(db)}
 
 	org := seedUser(t, userRepo, "org@x.com", "auth0|org", "Organizer")
 
@@ -67,7 +69,7 @@ func TestHandleUpdateEvent_EndBeforeStart(t *testing.T) {
 	db := setupTestDB(t)
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	h := &events.Handler{Repo: eventRepo, UserRepo: userRepo, Notifications: notifications.NewService()}
+	h := &events.Handler{Repo: eventRepo, UserRepo: userRepo, Notifications: notifications.NewService(db)}
 
 	org := seedUser(t, userRepo, "org2@x.com", "auth0|org2", "Organizer")
 
@@ -97,7 +99,7 @@ func TestHandleUpdateEvent_EventNotFound(t *testing.T) {
 	db := setupTestDB(t)
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	h := &events.Handler{Repo: eventRepo, UserRepo: userRepo, Notifications: notifications.NewService()}
+	h := &events.Handler{Repo: eventRepo, UserRepo: userRepo, Notifications: notifications.NewService(db)}
 
 	org := seedUser(t, userRepo, "org3@x.com", "auth0|org3", "Organizer")
 
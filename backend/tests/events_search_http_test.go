@@ -19,7 +19,7 @@ func TestHandleListEvents_SearchAndFilter(t *testing.T) {
 
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	nsvc := notifications.NewService()
+	nsvc := notifications.NewService(db)
 
 	h := &events.Handler{
 		Repo:          eventRepo,
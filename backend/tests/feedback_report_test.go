@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/feedback"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestFeedbackReport_SummaryIsGroundedAndCached(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	org := seedUser(t, uRepo, "org-fb@x.com", "auth0|org-fb", "Organizer")
+	a := seedUser(t, uRepo, "fb-a@x.com", "auth0|fb-a", "Member")
+	b := seedUser(t, uRepo, "fb-b@x.com", "auth0|fb-b", "Member")
+	c := seedUser(t, uRepo, "fb-c@x.com", "auth0|fb-c", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Robotics Expo", "PUBLIC")
+
+	add := func(userID int64, rating int, comment, sentiment string) {
+		if _, err := db.Exec(`
+			INSERT INTO event_feedback (event_id, user_id, rating, comment, sentiment) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (event_id, user_id) DO UPDATE SET rating = EXCLUDED.rating, comment = EXCLUDED.comment
+		`, ev.ID, userID, rating, comment, sentiment); err != nil {
+			t.Fatalf("seed feedback: %v", err)
+		}
+	}
+	add(a.ID, 5, "The demos were amazing!", "POSITIVE")
+	add(b.ID, 2, "Way too crowded near the stage.", "NEGATIVE")
+
+	mock := &ai.MockProvider{Reply: func(ai.CompletionRequest) string {
+		return `{"overview": "Mixed.", "praise": [{"theme": "Demos", "quotes": ["The demos were amazing!", "Best event ever"]}], "complaints": [{"theme": "Crowding", "quotes": ["too crowded"]}]}`
+	}}
+	svc := feedback.NewService(db, ai.NewServiceWithProvider(mock))
+	ctx := context.Background()
+
+	rep, err := svc.Report(ctx, ev.ID)
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if rep.Count != 2 || rep.AverageRating != 3.5 || rep.Ratings[5] != 1 || rep.Ratings[2] != 1 || rep.Sentiments["NEGATIVE"] != 1 {
+		t.Fatalf("unexpected stats %+v", rep)
+	}
+	if rep.Summary == nil || len(rep.Summary.Praise) != 1 || len(rep.Summary.Praise[0].Quotes) != 1 {
+		t.Fatalf("expected invented quotes to be dropped, got %+v", rep.Summary)
+	}
+	if rep.Summary.Complaints[0].Quotes[0] != "too crowded" {
+		t.Fatalf("expected verbatim excerpt to be kept, got %+v", rep.Summary.Complaints)
+	}
+
+	if _, err := svc.Report(ctx, ev.ID); err != nil || mock.Calls() != 1 {
+		t.Fatalf("expected cached summary, got %d calls (%v)", mock.Calls(), err)
+	}
+
+	add(c.ID, 4, "Good snacks.", "POSITIVE")
+	if _, err := svc.Report(ctx, ev.ID); err != nil || mock.Calls() != 2 {
+		t.Fatalf("expected new feedback to regenerate the summary, got %d calls (%v)", mock.Calls(), err)
+	}
+	add(c.ID, 1, "Actually, the snacks ran out.", "NEGATIVE")
+	if _, err := svc.Report(ctx, ev.ID); err != nil || mock.Calls() != 3 {
+		t.Fatalf("expected edited feedback to regenerate the summary, got %d calls (%v)", mock.Calls(), err)
+	}
+}
+
+func TestFeedbackReport_OwnerOnlyAndPDF(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	org := seedUser(t, uRepo, "org-fb2@x.com", "auth0|org-fb2", "Organizer")
+	other := seedUser(t, uRepo, "org-fb3@x.com", "auth0|org-fb3", "Organizer")
+	ev := seedEvent(t, eRepo, org.ID, "Chess Night", "PUBLIC")
+
+	h := &feedback.Handler{Service: feedback.NewService(db, ai.NewServiceWithProvider(nil)), UserRepo: uRepo, EventRepo: eRepo}
+
+	req := injectClaims(httptest.NewRequest(http.MethodGet, "/events/feedback/report?event_id="+strconv.FormatInt(ev.ID, 10), nil), other.OIDCID)
+	rr := httptest.NewRecorder()
+	h.HandleReport(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another organizer, got %d", rr.Code)
+	}
+
+	req = injectClaims(httptest.NewRequest(http.MethodGet, "/events/feedback/report?format=pdf&event_id="+strconv.FormatInt(ev.ID, 10), nil), org.OIDCID)
+	rr = httptest.NewRecorder()
+	h.HandleReport(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/pdf" || !bytes.HasPrefix(rr.Body.Bytes(), []byte("%PDF")) {
+		t.Fatalf("expected a PDF, got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+}
+
+func TestFeedbackReport_WritePDF(t *testing.T) {
+	rep := &feedback.Report{
+		EventTitle:    "Café Night",
+		Count:         2,
+		AverageRating: 4.5,
+		Ratings:       map[int]int{4: 1, 5: 1},
+		Sentiments:    map[string]int{"POSITIVE": 2},
+		Summary: &feedback.Summary{
+			Overview: "Attendees loved the music.",
+			Praise:   []feedback.Theme{{Theme: "Music", Quotes: []string{"Great band"}}},
+		},
+		SummaryStatus: feedback.SummaryReady,
+	}
+	var buf bytes.Buffer
+	if err := feedback.WritePDF(&buf, rep); err != nil {
+		t.Fatalf("pdf: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "%PDF") {
+		t.Fatalf("expected PDF output")
+	}
+}
+
+func TestFeedbackReport_LongMultibyteCommentsStayQuotable(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	org := seedUser(t, uRepo, "org-fb4@x.com", "auth0|org-fb4", "Organizer")
+	a := seedUser(t, uRepo, "fb-d@x.com", "auth0|fb-d", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Café Social", "PUBLIC")
+
+	// 400 two-byte runes push the quote past the first 500 bytes but keep it
+	// within the first 500 runes.
+	comment := strings.Repeat("ü", 400) + " loved the café au lait"
+	if _, err := db.Exec(`INSERT INTO event_feedback (event_id, user_id, rating, comment, sentiment) VALUES ($1, $2, 5, $3, 'POSITIVE')`, ev.ID, a.ID, comment); err != nil {
+		t.Fatalf("seed feedback: %v", err)
+	}
+
+	mock := &ai.MockProvider{Reply: func(ai.CompletionRequest) string {
+		return `{"overview": "Happy.", "praise": [{"theme": "Coffee", "quotes": ["loved the café au lait"]}], "complaints": []}`
+	}}
+	rep, err := feedback.NewService(db, ai.NewServiceWithProvider(mock)).Report(context.Background(), ev.ID)
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if rep.Summary == nil || len(rep.Summary.Praise) != 1 || len(rep.Summary.Praise[0].Quotes) != 1 {
+		t.Fatalf("expected the quote to survive truncation, got %+v", rep.Summary)
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/follows"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestFollows_FeedProfileAndAlerts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	orgRepo := store.NewOrganizationRepository(db)
+	organizer := seedUser(t, uRepo, "fol-organizer@x.com", "auth0|fol-organizer", "Organizer")
+	fan := seedUser(t, uRepo, "fol-fan@x.com", "auth0|fol-fan", "Member")
+	clubFan := seedUser(t, uRepo, "fol-clubfan@x.com", "auth0|fol-clubfan", "Member")
+	h := &follows.Handler{Repo: store.NewFollowRepository(db, authz.RolesWith(authz.EventCreate)), UserRepo: uRepo}
+	notify := notifications.NewService(db)
+	ctx := context.Background()
+
+	club := &store.Organization{Name: "Robotics Club"}
+	if err := orgRepo.Create(ctx, club, organizer.ID); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	if err := orgRepo.SetMember(ctx, club.ID, fan.ID, store.OrgMember); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+
+	follow := func(sub string, body map[string]int64) int {
+		b, _ := json.Marshal(body)
+		rr := httptest.NewRecorder()
+		h.HandleFollow(rr, injectClaims(httptest.NewRequest(http.MethodPost, "/follows", bytes.NewReader(b)), sub))
+		return rr.Code
+	}
+	if code := follow(fan.OIDCID, map[string]int64{"organizer_id": organizer.ID}); code != http.StatusNoContent {
+		t.Fatalf("expected to follow the organizer, got %d", code)
+	}
+	if code := follow(fan.OIDCID, map[string]int64{"organizer_id": organizer.ID}); code != http.StatusNoContent {
+		t.Fatalf("expected following twice to be harmless, got %d", code)
+	}
+	if code := follow(clubFan.OIDCID, map[string]int64{"organization_id": club.ID}); code != http.StatusNoContent {
+		t.Fatalf("expected to follow the organization, got %d", code)
+	}
+	if code := follow(organizer.OIDCID, map[string]int64{"organizer_id": organizer.ID}); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 following yourself, got %d", code)
+	}
+	if code := follow(fan.OIDCID, map[string]int64{"organizer_id": clubFan.ID}); code != http.StatusNotFound {
+		t.Fatalf("expected 404 following a member who organizes nothing, got %d", code)
+	}
+
+	public := seedEvent(t, eRepo, organizer.ID, "Robot Sumo", "PUBLIC")
+	seedEvent(t, eRepo, organizer.ID, "Board Meeting", "PRIVATE")
+	membersOnly := seedEvent(t, eRepo, organizer.ID, "Build Night", "MEMBERS")
+	if _, err := db.ExecContext(ctx, "UPDATE events SET organization_id = $1 WHERE id = $2", club.ID, membersOnly.ID); err != nil {
+		t.Fatalf("assign organization: %v", err)
+	}
+
+	var feed []store.EventSummary
+	rr := httptest.NewRecorder()
+	h.HandleFeed(rr, injectClaims(httptest.NewRequest(http.MethodGet, "/follows/feed", nil), fan.OIDCID))
+	json.NewDecoder(rr.Body).Decode(&feed)
+	if len(feed) != 2 || feed[0].ID != public.ID || feed[1].ID != membersOnly.ID {
+		t.Fatalf("expected the public and members-only events in the fan's feed, got %+v", feed)
+	}
+	rr = httptest.NewRecorder()
+	h.HandleFeed(rr, injectClaims(httptest.NewRequest(http.MethodGet, "/follows/feed", nil), clubFan.OIDCID))
+	json.NewDecoder(rr.Body).Decode(&feed)
+	if len(feed) != 0 {
+		t.Fatalf("expected a non-member to miss the members-only event, got %+v", feed)
+	}
+
+	// A members-only event reaches only followers who are members.
+	data := notifications.TemplateData{Event: notifications.EventVars{ID: membersOnly.ID, Title: membersOnly.Title}, Note: club.Name}
+	if err := notify.NotifyFollowers(ctx, organizer.ID, club.ID, true, notifications.TmplEventPublished, data); err != nil {
+		t.Fatalf("notify followers: %v", err)
+	}
+	var fanCount, clubFanCount int
+	count := "SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND category = 'following'"
+	db.QueryRowContext(ctx, count, fan.ID).Scan(&fanCount)
+	db.QueryRowContext(ctx, count, clubFan.ID).Scan(&clubFanCount)
+	if fanCount != 1 || clubFanCount != 0 {
+		t.Fatalf("expected only the member to hear about it, got fan=%d clubFan=%d", fanCount, clubFanCount)
+	}
+
+	var profile store.OrganizerProfile
+	rr = httptest.NewRecorder()
+	h.HandleOrganizerProfile(rr, injectClaims(httptest.NewRequest(http.MethodGet, "/organizers?id="+strconv.FormatInt(organizer.ID, 10), nil), fan.OIDCID))
+	json.NewDecoder(rr.Body).Decode(&profile)
+	if profile.FollowerCount != 1 || !profile.Following || len(profile.UpcomingEvents) != 1 {
+		t.Fatalf("expected one follower and one public event, got %+v", profile)
+	}
+
+	// Members have no profile, so their email cannot be looked up by id.
+	rr = httptest.NewRecorder()
+	h.HandleOrganizerProfile(rr, injectClaims(httptest.NewRequest(http.MethodGet, "/organizers?id="+strconv.FormatInt(clubFan.ID, 10), nil), fan.OIDCID))
+	if rr.Code != http.StatusNotFound || strings.Contains(rr.Body.String(), clubFan.Email) {
+		t.Fatalf("expected 404 for a member's profile, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// The following list, like the profile, leaves out the organizer's email.
+	var following []store.Followed
+	rr = httptest.NewRecorder()
+	h.HandleListFollowing(rr, injectClaims(httptest.NewRequest(http.MethodGet, "/follows", nil), fan.OIDCID))
+	json.Unmarshal(rr.Body.Bytes(), &following)
+	if len(following) != 1 || following[0].ID != organizer.ID || strings.Contains(rr.Body.String(), organizer.Email) {
+		t.Fatalf("expected the organizer without their email, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.HandleUnfollow(rr, injectClaims(httptest.NewRequest(http.MethodDelete, "/follows?organizer_id="+strconv.FormatInt(organizer.ID, 10), nil), fan.OIDCID))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected to unfollow, got %d", rr.Code)
+	}
+	if org, _ := orgRepo.Get(ctx, club.ID, clubFan.ID); org.FollowerCount != 1 || !org.Following {
+		t.Fatalf("expected the organization to show its follower, got %+v", org)
+	}
+}
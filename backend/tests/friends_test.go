@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/friends"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestFriends_RequestsAndFriendsGoing(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	organizer := seedUser(t, uRepo, "fr-organizer@x.com", "auth0|fr-organizer", "Organizer")
+	alice := seedUser(t, uRepo, "fr-alice@x.com", "auth0|fr-alice", "Member")
+	bob := seedUser(t, uRepo, "fr-bob@x.com", "auth0|fr-bob", "Member")
+	carol := seedUser(t, uRepo, "fr-carol@x.com", "auth0|fr-carol", "Member")
+	stranger := seedUser(t, uRepo, "fr-stranger@x.com", "auth0|fr-stranger", "Member")
+	h := &friends.Handler{Repo: store.NewFriendRepository(db), UserRepo: uRepo}
+	ctx := context.Background()
+
+	send := func(fn http.HandlerFunc, method, target, sub string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		rr := httptest.NewRecorder()
+		fn(rr, injectClaims(httptest.NewRequest(method, target, bytes.NewReader(b)), sub))
+		return rr
+	}
+
+	// Alice asks Bob by email; Bob accepts.
+	if rr := send(h.HandleRequest, http.MethodPost, "/friends/requests", alice.OIDCID, map[string]string{"email": bob.Email}); rr.Code != http.StatusOK {
+		t.Fatalf("expected the request to be sent, got %d %s", rr.Code, rr.Body.String())
+	}
+	var lists store.FriendLists
+	json.NewDecoder(send(h.HandleListFriends, http.MethodGet, "/friends", bob.OIDCID, nil).Body).Decode(&lists)
+	if len(lists.Incoming) != 1 || lists.Incoming[0].UserID != alice.ID || len(lists.Friends) != 0 {
+		t.Fatalf("expected Bob to see Alice's request, got %+v", lists)
+	}
+	if rr := send(h.HandleRespond, http.MethodPost, "/friends/requests/respond", bob.OIDCID, map[string]any{"user_id": alice.ID, "accept": true}); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected Bob to accept, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Carol asks Alice and Alice asks back, which accepts.
+	send(h.HandleRequest, http.MethodPost, "/friends/requests", carol.OIDCID, map[string]int64{"user_id": alice.ID})
+	var result struct {
+		Status string `json:"status"`
+	}
+	json.NewDecoder(send(h.HandleRequest, http.MethodPost, "/friends/requests", alice.OIDCID, map[string]int64{"user_id": carol.ID}).Body).Decode(&result)
+	if result.Status != "ACCEPTED" {
+		t.Fatalf("expected a mutual request to make friends, got %q", result.Status)
+	}
+
+	event := seedEvent(t, eRepo, organizer.ID, "Open Mic", "PUBLIC")
+	support := seedEvent(t, eRepo, organizer.ID, "Grief Circle", "PUBLIC")
+	if _, err := db.ExecContext(ctx, "UPDATE events SET category = 'Support Group' WHERE id = $1", support.ID); err != nil {
+		t.Fatalf("set category: %v", err)
+	}
+	for _, u := range []*store.User{bob, carol, stranger} {
+		for _, e := range []*store.Event{event, support} {
+			if _, err := db.ExecContext(ctx, "INSERT INTO registrations (user_id, event_id, status) VALUES ($1, $2, 'REGISTERED')", u.ID, e.ID); err != nil {
+				t.Fatalf("register: %v", err)
+			}
+		}
+	}
+	// Carol hides her attendance everywhere.
+	if err := uRepo.UpdateAttendanceVisibility(ctx, carol.ID, store.AttendanceHidden); err != nil {
+		t.Fatalf("hide attendance: %v", err)
+	}
+
+	going := func(e *store.Event) []store.Friend {
+		var list []store.Friend
+		json.NewDecoder(send(h.HandleFriendsGoing, http.MethodGet, "/events/friends?event_id="+strconv.FormatInt(e.ID, 10), alice.OIDCID, nil).Body).Decode(&list)
+		return list
+	}
+	if list := going(event); len(list) != 1 || list[0].UserID != bob.ID {
+		t.Fatalf("expected only Bob among Alice's friends going, got %+v", list)
+	}
+	if list := going(support); len(list) != 0 {
+		t.Fatalf("expected sensitive attendance to be hidden by default, got %+v", list)
+	}
+
+	// Bob chooses to show this one.
+	show := true
+	if rr := send(h.HandleShowAttendance, http.MethodPatch, "/registrations/visibility", bob.OIDCID, map[string]any{"event_id": support.ID, "show_to_friends": show}); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected Bob to show his attendance, got %d", rr.Code)
+	}
+	if list := going(support); len(list) != 1 || list[0].UserID != bob.ID {
+		t.Fatalf("expected Bob to be shown after opting in, got %+v", list)
+	}
+
+	if rr := send(h.HandleRemove, http.MethodDelete, "/friends?user_id="+strconv.FormatInt(bob.ID, 10), alice.OIDCID, nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected to unfriend, got %d", rr.Code)
+	}
+	if list := going(event); len(list) != 0 {
+		t.Fatalf("expected no friends going after unfriending, got %+v", list)
+	}
+}
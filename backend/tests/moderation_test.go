@@ -0,0 +1,261 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/events"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/moderation"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestModerationRules(t *testing.T) {
+	rules := moderation.DefaultRules()
+	cases := []struct {
+		kind, text, want string
+	}{
+		{moderation.TypeComment, "   ", moderation.Reject},
+		{moderation.TypeComment, "See you all there!", moderation.Publish},
+		{moderation.TypeComment, "I will kill you", moderation.Reject},
+		{moderation.TypeComment, "Join the crypto giveaway", moderation.Reject},
+		{moderation.TypeComment, "This is fucking great", moderation.Hold},
+		{moderation.TypeComment, "Slides at https://example.com", moderation.Hold},
+		{moderation.TypeComment, "THIS EVENT IS THE WORST EVER", moderation.Hold},
+		{moderation.TypePhoto, "https://img.example.com/a.jpg", moderation.Publish},
+		{moderation.TypePhoto, "javascript:alert(1)", moderation.Reject},
+	}
+	for _, c := range cases {
+		if got := rules.Check(c.kind, c.text); got.Decision != c.want {
+			t.Errorf("Check(%s, %q) = %+v, want %s", c.kind, c.text, got, c.want)
+		}
+	}
+
+	t.Setenv("MODERATION_BLOCKED_TERMS", "foo bar, baz")
+	if got := moderation.NewRulesFromEnv().Check(moderation.TypeComment, "so much Foo Bar here"); got.Decision != moderation.Reject {
+		t.Errorf("expected configured term to be rejected, got %+v", got)
+	}
+}
+
+func TestModeration_ScreenReportAndQueue(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	org := seedUser(t, uRepo, "org-mod@x.com", "auth0|org-mod", "Organizer")
+	other := seedUser(t, uRepo, "org-mod2@x.com", "auth0|org-mod2", "Organizer")
+	author := seedUser(t, uRepo, "mod-author@x.com", "auth0|mod-author", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Open Mic", "PUBLIC")
+	ctx := context.Background()
+
+	model := &ai.MockProvider{Reply: func(req ai.CompletionRequest) string {
+		if bytes.Contains([]byte(req.Messages[0].Content), []byte("losers")) {
+			return "ABUSIVE"
+		}
+		return "SAFE"
+	}}
+	svc := &moderation.Service{DB: db, Rules: moderation.DefaultRules(), AI: ai.NewServiceWithProvider(model), ReportThreshold: 2}
+	h := &events.Handler{Repo: eRepo, UserRepo: uRepo, Notifications: notifications.NewService(db), Moderation: svc}
+
+	post := func(sub, text string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]any{"event_id": ev.ID, "text": text})
+		rr := httptest.NewRecorder()
+		h.HandleAddComment(rr, injectClaims(httptest.NewRequest(http.MethodPost, "/events/comments", bytes.NewReader(body)), sub))
+		return rr
+	}
+
+	if rr := post(author.OIDCID, ""); rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected empty comment to be rejected, got %d", rr.Code)
+	}
+	if rr := post(author.OIDCID, "Only losers go to this"); rr.Code != http.StatusOK {
+		t.Fatalf("expected held comment to be accepted, got %d", rr.Code)
+	}
+	rr := post(author.OIDCID, "Great line-up tonight")
+	var published store.Comment
+	json.NewDecoder(rr.Body).Decode(&published)
+	if published.Status != moderation.StatusPublished {
+		t.Fatalf("expected published comment, got %+v", published)
+	}
+
+	list, _ := eRepo.GetComments(ctx, ev.ID)
+	if len(list) != 1 || list[0].ID != published.ID {
+		t.Fatalf("expected only the published comment to be public, got %d", len(list))
+	}
+
+	// Two reports take the comment down for review.
+	a := seedUser(t, uRepo, "mod-a@x.com", "auth0|mod-a", "Member")
+	b := seedUser(t, uRepo, "mod-b@x.com", "auth0|mod-b", "Member")
+	for _, u := range []*store.User{a, a, b} {
+		if err := svc.Report(ctx, u.ID, moderation.TypeComment, published.ID, "rude"); err != nil {
+			t.Fatalf("report: %v", err)
+		}
+	}
+	if list, _ := eRepo.GetComments(ctx, ev.ID); len(list) != 0 {
+		t.Fatalf("expected reported comment to be held")
+	}
+
+	orgActor := moderation.Actor{ID: org.ID, Role: org.Role}
+	queue, err := svc.Queue(ctx, orgActor, 0)
+	if err != nil || len(queue) != 2 {
+		t.Fatalf("expected 2 held comments in the queue, got %d (%v)", len(queue), err)
+	}
+	if q, _ := svc.Queue(ctx, moderation.Actor{ID: other.ID, Role: other.Role}, 0); len(q) != 0 {
+		t.Fatalf("expected another organizer to see an empty queue, got %d", len(q))
+	}
+
+	if err := svc.Act(ctx, moderation.Actor{ID: other.ID, Role: other.Role}, moderation.TypeComment, published.ID, moderation.ActionRestore, ""); !errors.Is(err, moderation.ErrForbidden) {
+		t.Fatalf("expected another organizer to be forbidden, got %v", err)
+	}
+	if err := svc.Act(ctx, orgActor, moderation.TypeComment, published.ID, moderation.ActionRestore, "fair criticism"); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if list, _ := eRepo.GetComments(ctx, ev.ID); len(list) != 1 {
+		t.Fatalf("expected restored comment to be public again")
+	}
+	if q, _ := svc.Queue(ctx, orgActor, ev.ID); len(q) != 1 {
+		t.Fatalf("expected restoring to dismiss the reports, got %d items", len(q))
+	}
+
+	// An event ban stops the author posting there.
+	if err := svc.Ban(ctx, orgActor, author.ID, 0, "spam"); !errors.Is(err, moderation.ErrForbidden) {
+		t.Fatalf("expected site-wide bans to be admin-only, got %v", err)
+	}
+	if err := svc.Ban(ctx, orgActor, author.ID, ev.ID, "spam"); err != nil {
+		t.Fatalf("ban: %v", err)
+	}
+	if rr := post(author.OIDCID, "Hello again"); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected banned user to be forbidden, got %d", rr.Code)
+	}
+	if err := svc.Unban(ctx, orgActor, author.ID, ev.ID, ""); err != nil {
+		t.Fatalf("unban: %v", err)
+	}
+
+	trail, err := svc.Audit(ctx, orgActor, ev.ID)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	seen := map[string]int{}
+	for _, a := range trail {
+		seen[a.Action]++
+	}
+	want := map[string]int{"rejected": 1, "held": 2, "reported": 2, "restore": 1, "ban": 1, "unban": 1}
+	for action, n := range want {
+		if seen[action] != n {
+			t.Fatalf("expected %d %q entries, got %v", n, action, seen)
+		}
+	}
+}
+
+func TestModerationHandler_MembersCannotSeeQueue(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	member := seedUser(t, uRepo, "mod-member@x.com", "auth0|mod-member", "Member")
+	h := &moderation.Handler{Service: &moderation.Service{DB: db}, UserRepo: uRepo}
+
+	rr := httptest.NewRecorder()
+	h.HandleQueue(rr, injectClaims(httptest.NewRequest(http.MethodGet, "/moderation/queue", nil), member.OIDCID))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/moderation/bans?user_id="+strconv.FormatInt(member.ID, 10), nil)
+	h.HandleUnban(rr, injectClaims(req, member.OIDCID))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected users to be unable to unban themselves, got %d", rr.Code)
+	}
+}
+
+func TestModeration_HiddenFeedbackStaysHiddenOnResubmit(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	org := seedUser(t, uRepo, "org-fbmod@x.com", "auth0|org-fbmod", "Organizer")
+	author := seedUser(t, uRepo, "fbmod-author@x.com", "auth0|fbmod-author", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Hackathon", "PUBLIC")
+	ctx := context.Background()
+	svc := &moderation.Service{DB: db, Rules: moderation.DefaultRules()}
+
+	fb := &store.Feedback{EventID: ev.ID, UserID: author.ID, Rating: 1, Comment: "first", Status: moderation.StatusPublished}
+	if err := eRepo.AddFeedback(ctx, fb); err != nil {
+		t.Fatalf("add feedback: %v", err)
+	}
+	if err := svc.Act(ctx, moderation.Actor{ID: org.ID, Role: org.Role}, moderation.TypeFeedback, fb.ID, moderation.ActionHide, "off-topic"); err != nil {
+		t.Fatalf("hide: %v", err)
+	}
+
+	again := &store.Feedback{EventID: ev.ID, UserID: author.ID, Rating: 2, Comment: "second", Status: moderation.StatusPublished}
+	if err := eRepo.AddFeedback(ctx, again); err != nil {
+		t.Fatalf("resubmit: %v", err)
+	}
+	var status string
+	if err := db.QueryRowContext(ctx, "SELECT moderation_status FROM event_feedback WHERE id = $1", fb.ID).Scan(&status); err != nil {
+		t.Fatalf("read status: %v", err)
+	}
+	if status != moderation.StatusHidden || again.Status != moderation.StatusHidden {
+		t.Fatalf("expected resubmitted feedback to stay hidden, got %s (%s)", status, again.Status)
+	}
+}
+
+func TestModeration_OrganizationOfficersModerateClubEvents(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	orgRepo := store.NewOrganizationRepository(db)
+	owner := seedUser(t, uRepo, "modorg-owner@x.com", "auth0|modorg-owner", "Organizer")
+	officer := seedUser(t, uRepo, "modorg-officer@x.com", "auth0|modorg-officer", "Member")
+	outsider := seedUser(t, uRepo, "modorg-outsider@x.com", "auth0|modorg-outsider", "Organizer")
+	author := seedUser(t, uRepo, "modorg-author@x.com", "auth0|modorg-author", "Member")
+	ctx := context.Background()
+
+	club := &store.Organization{Name: "Film Society"}
+	if err := orgRepo.Create(ctx, club, owner.ID); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	orgRepo.SetMember(ctx, club.ID, officer.ID, store.OrgOfficer)
+	ev := seedEvent(t, eRepo, owner.ID, "Screening", "PUBLIC")
+	db.Exec(`UPDATE events SET organization_id = $1 WHERE id = $2`, club.ID, ev.ID)
+	var commentID int64
+	db.QueryRow(`INSERT INTO comments (event_id, user_id, text, moderation_status) VALUES ($1, $2, 'spoilers!', 'PENDING') RETURNING id`, ev.ID, author.ID).Scan(&commentID)
+
+	svc := &moderation.Service{DB: db}
+	h := &moderation.Handler{Service: svc, UserRepo: uRepo}
+
+	rr := httptest.NewRecorder()
+	h.HandleQueue(rr, injectClaims(httptest.NewRequest(http.MethodGet, "/moderation/queue", nil), officer.OIDCID))
+	var queue []*moderation.Item
+	json.NewDecoder(rr.Body).Decode(&queue)
+	if rr.Code != http.StatusOK || len(queue) != 1 || queue[0].ContentID != commentID {
+		t.Fatalf("expected the officer to see the held comment, got %d %+v", rr.Code, queue)
+	}
+
+	officerActor := moderation.Actor{ID: officer.ID, Role: officer.Role}
+	if err := svc.Act(ctx, officerActor, moderation.TypeComment, commentID, moderation.ActionHide, "spoilers"); err != nil {
+		t.Fatalf("expected the officer to moderate the club's event: %v", err)
+	}
+	if trail, err := svc.Audit(ctx, officerActor, ev.ID); err != nil || len(trail) != 1 {
+		t.Fatalf("expected the officer to see the audit entry, got %d (%v)", len(trail), err)
+	}
+
+	outsiderActor := moderation.Actor{ID: outsider.ID, Role: outsider.Role}
+	if err := svc.Act(ctx, outsiderActor, moderation.TypeComment, commentID, moderation.ActionRestore, ""); !errors.Is(err, moderation.ErrForbidden) {
+		t.Fatalf("expected an unrelated organizer to be forbidden, got %v", err)
+	}
+	if trail, _ := svc.Audit(ctx, outsiderActor, 0); len(trail) != 0 {
+		t.Fatalf("expected an unrelated organizer to see no audit entries, got %d", len(trail))
+	}
+}
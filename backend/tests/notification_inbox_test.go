@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/background"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/registration"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestParseRetention(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 90 * 24 * time.Hour, false},
+		{" 720h ", 720 * time.Hour, false},
+		{"forever", 0, true},
+		{"0s", 0, true},
+		{"-24h", 0, true},
+	}
+	for _, c := range cases {
+		got, err := background.ParseRetention(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("ParseRetention(%q) = %v, want error", c.in, got)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Errorf("ParseRetention(%q) = %v (%v), want %v", c.in, got, err, c.want)
+		}
+	}
+}
+
+func TestInbox_PaginationAndPerItemActions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	h := &notifications.Handler{Repo: eRepo, UserRepo: uRepo}
+
+	u := seedUser(t, uRepo, "inbox@x.com", "auth0|inbox", "Member")
+	for i := 0; i < 5; i++ {
+		if err := eRepo.CreateNotification(ctx, u.ID, "Message "+strconv.Itoa(i)); err != nil {
+			t.Fatalf("CreateNotification: %v", err)
+		}
+	}
+
+	list := func(query string) ([]*store.Notification, string) {
+		req := httptest.NewRequest(http.MethodGet, "/notifications?"+query, nil)
+		req = injectClaims(req, u.OIDCID)
+		w := httptest.NewRecorder()
+		h.HandleListNotifications(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("list %q: expected 200, got %d", query, w.Code)
+		}
+		var got []*store.Notification
+		json.Unmarshal(w.Body.Bytes(), &got)
+		return got, w.Header().Get("X-Next-Cursor")
+	}
+
+	page1, cursor := list("limit=3")
+	if len(page1) != 3 || cursor == "" || page1[0].Message != "Message 4" {
+		t.Fatalf("unexpected first page: %d items, cursor %q", len(page1), cursor)
+	}
+	page2, cursor := list("limit=3&cursor=" + cursor)
+	if len(page2) != 2 || cursor != "" {
+		t.Fatalf("unexpected second page: %d items, cursor %q", len(page2), cursor)
+	}
+
+	patch := func(body map[string]any) int {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPatch, "/notifications", bytes.NewReader(b))
+		req = injectClaims(req, u.OIDCID)
+		w := httptest.NewRecorder()
+		h.HandleUpdateNotification(w, req)
+		return w.Code
+	}
+
+	if code := patch(map[string]any{"id": page1[0].ID, "read": true}); code != http.StatusOK {
+		t.Fatalf("mark read: expected 200, got %d", code)
+	}
+	if code := patch(map[string]any{"id": page1[1].ID, "archived": true}); code != http.StatusOK {
+		t.Fatalf("archive: expected 200, got %d", code)
+	}
+
+	counts, _ := eRepo.UnreadNotificationCounts(ctx, u.ID)
+	if counts["general"] != 3 {
+		t.Fatalf("expected 3 unread, got %v", counts)
+	}
+	if archived, _ := list("status=archived"); len(archived) != 1 {
+		t.Fatalf("expected 1 archived notification, got %d", len(archived))
+	}
+
+	// Another user's notification cannot be touched.
+	other := seedUser(t, uRepo, "inbox2@x.com", "auth0|inbox2", "Member")
+	eRepo.CreateNotification(ctx, other.ID, "Private")
+	otherNotes, _ := eRepo.GetNotifications(ctx, other.ID)
+	if code := patch(map[string]any{"id": otherNotes[0].ID, "read": true}); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for someone else's notification, got %d", code)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/notifications?id="+strconv.FormatInt(page1[2].ID, 10), nil)
+	req = injectClaims(req, u.OIDCID)
+	w := httptest.NewRecorder()
+	h.HandleDeleteNotification(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+	if remaining, _ := list(""); len(remaining) != 3 {
+		t.Fatalf("expected 3 inbox notifications after archive and delete, got %d", len(remaining))
+	}
+}
+
+func TestInbox_DeepLinksCategoriesAndRetention(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
+
+	org := seedUser(t, uRepo, "org-inbox@x.com", "auth0|org-inbox", "Organizer")
+	u := seedUser(t, uRepo, "link@x.com", "auth0|link", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Linked Event", "PUBLIC")
+
+	if _, err := svc.RegisterUserForEvent(ctx, u.ID, ev.ID); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	notes, _, err := eRepo.ListNotifications(ctx, u.ID, store.NotificationFilter{Category: notifications.CategoryRegistration})
+	if err != nil || len(notes) != 1 {
+		t.Fatalf("expected 1 registration notification, got %d (%v)", len(notes), err)
+	}
+	link := notes[0].Link
+	if link == nil || link.EventID == nil || *link.EventID != ev.ID || link.Action != notifications.ActionViewEvent {
+		t.Fatalf("expected deep link to the event, got %+v", link)
+	}
+
+	// Old read notifications are pruned; old unread and archived ones stay.
+	eRepo.CreateNotification(ctx, u.ID, "old unread")
+	eRepo.CreateNotification(ctx, u.ID, "old archived")
+	db.Exec(`UPDATE notifications SET created_at = NOW() - INTERVAL '100 days' WHERE user_id=$1`, u.ID)
+	db.Exec(`UPDATE notifications SET is_read = TRUE WHERE user_id=$1 AND category=$2`, u.ID, notifications.CategoryRegistration)
+	db.Exec(`UPDATE notifications SET is_read = TRUE, archived_at = NOW() WHERE user_id=$1 AND message='old archived'`, u.ID)
+
+	background.NewNotificationRetention(db, 90*24*time.Hour).Test_Prune()
+
+	var left []string
+	rows, _ := db.Query(`SELECT message FROM notifications WHERE user_id=$1 ORDER BY id`, u.ID)
+	for rows.Next() {
+		var m string
+		rows.Scan(&m)
+		left = append(left, m)
+	}
+	rows.Close()
+	if len(left) != 2 || left[0] != "old unread" || left[1] != "old archived" {
+		t.Fatalf("expected only the unread and archived notifications to remain, got %v", left)
+	}
+
+	// GetNotifications is not paged.
+	for i := 0; i < 60; i++ {
+		eRepo.CreateNotification(ctx, u.ID, "bulk")
+	}
+	if all, err := eRepo.GetNotifications(ctx, u.ID); err != nil || len(all) != 61 {
+		t.Fatalf("expected all 61 inbox notifications, got %d (%v)", len(all), err)
+	}
+}
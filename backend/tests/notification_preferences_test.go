@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/background"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/registration"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestUnsubscribeToken_RoundTripAndTamper(t *testing.T) {
+	t.Setenv("UNSUBSCRIBE_SECRET", "test-unsubscribe-secret")
+	svc := notifications.NewService(nil)
+
+	token := svc.UnsubscribeToken(42, notifications.CategoryReminders)
+	userID, category, err := svc.ParseUnsubscribeToken(token)
+	if err != nil || userID != 42 || category != notifications.CategoryReminders {
+		t.Fatalf("expected (42, reminders), got (%d, %q, %v)", userID, category, err)
+	}
+
+	forged := svc.UnsubscribeToken(43, notifications.CategoryReminders)
+	tampered := strings.SplitN(forged, ".", 2)[0] + "." + strings.SplitN(token, ".", 2)[1]
+	if _, _, err := svc.ParseUnsubscribeToken(tampered); err == nil {
+		t.Fatalf("expected tampered token to be rejected")
+	}
+
+	svc.UnsubscribeTTL = -time.Hour
+	if _, _, err := svc.ParseUnsubscribeToken(svc.UnsubscribeToken(42, "all")); err == nil {
+		t.Fatalf("expected an expired token to be rejected")
+	}
+}
+
+func TestUnsubscribeToken_TurnedOffWithoutSecret(t *testing.T) {
+	t.Setenv("UNSUBSCRIBE_SECRET", "")
+	svc := notifications.NewService(nil)
+
+	if url := svc.UnsubscribeURL(42, "all"); url != "" {
+		t.Fatalf("expected no unsubscribe link without a secret, got %q", url)
+	}
+	// A token signed with an empty key must not be accepted either.
+	if _, _, err := svc.ParseUnsubscribeToken("NDIuYWxsLjk5OTk5OTk5OTk.x"); err == nil {
+		t.Fatalf("expected every token to be rejected without a secret")
+	}
+}
+
+func TestPreferences_RouteAndDigest(t *testing.T) {
+	t.Setenv("UNSUBSCRIBE_SECRET", "test-unsubscribe-secret")
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	nsvc := notifications.NewService(db)
+	svc := &registration.Service{DB: db, Notifications: nsvc}
+
+	org := seedUser(t, uRepo, "org-prefs@x.com", "auth0|org-prefs", "Organizer")
+	u := seedUser(t, uRepo, "prefs@x.com", "auth0|prefs", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Digest Event", "PUBLIC")
+
+	err := nsvc.SavePreferences(ctx, u.ID, []notifications.Preference{
+		{Category: notifications.CategoryRegistration, InApp: false, Email: notifications.EmailDaily},
+	})
+	if err != nil {
+		t.Fatalf("SavePreferences: %v", err)
+	}
+
+	if _, err := svc.RegisterUserForEvent(ctx, u.ID, ev.ID); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	var inApp, emails, pending int
+	db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id=$1`, u.ID).Scan(&inApp)
+	db.QueryRow(`SELECT COUNT(*) FROM email_outbox WHERE to_email=$1`, u.Email).Scan(&emails)
+	db.QueryRow(`SELECT COUNT(*) FROM digest_items WHERE user_id=$1 AND digested_at IS NULL`, u.ID).Scan(&pending)
+	if inApp != 0 || emails != 0 || pending != 1 {
+		t.Fatalf("expected only a digest item, got in-app=%d emails=%d digest=%d", inApp, emails, pending)
+	}
+
+	// Not due yet: the first digest waits a full period after the oldest item.
+	builder := background.NewDigestBuilder(db, nsvc)
+	builder.Test_BuildDigests()
+	db.QueryRow(`SELECT COUNT(*) FROM email_outbox WHERE to_email=$1`, u.Email).Scan(&emails)
+	if emails != 0 {
+		t.Fatalf("expected no digest before the period elapsed, got %d", emails)
+	}
+
+	db.Exec(`UPDATE digest_items SET created_at = NOW() - INTERVAL '25 hours' WHERE user_id=$1`, u.ID)
+	builder.Test_BuildDigests()
+	builder.Test_BuildDigests()
+
+	var body, unsubscribe string
+	db.QueryRow(`SELECT COUNT(*) FROM email_outbox WHERE to_email=$1`, u.Email).Scan(&emails)
+	db.QueryRow(`SELECT body, COALESCE(unsubscribe_url, '') FROM email_outbox WHERE to_email=$1`, u.Email).Scan(&body, &unsubscribe)
+	if emails != 1 || !strings.Contains(body, "Digest Event") || unsubscribe == "" {
+		t.Fatalf("expected one digest mentioning the event with an unsubscribe link, got %d: %q", emails, body)
+	}
+
+	// One-click unsubscribe turns email off for every category.
+	h := &notifications.Handler{Repo: eRepo, UserRepo: uRepo, Service: nsvc}
+	token := nsvc.UnsubscribeToken(u.ID, "all")
+	req := httptest.NewRequest(http.MethodPost, "/api/unsubscribe?token="+token, nil)
+	w := httptest.NewRecorder()
+	h.HandleUnsubscribe(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 unsubscribing, got %d", w.Code)
+	}
+
+	prefs, err := nsvc.GetPreferences(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("GetPreferences: %v", err)
+	}
+	for _, p := range prefs {
+		if p.Email != notifications.EmailOff {
+			t.Fatalf("expected email off for %s, got %s", p.Category, p.Email)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/registration"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestStream_WakeupsAreCoalescedPerUser(t *testing.T) {
+	s := notifications.NewStream(nil, "")
+	wakeups, cancel := s.Subscribe(7)
+	other, cancelOther := s.Subscribe(8)
+	defer cancelOther()
+
+	s.Publish(7)
+	s.Publish(7) // must not block even though nobody is reading
+
+	select {
+	case <-wakeups:
+	default:
+		t.Fatalf("expected a wake-up for user 7")
+	}
+	select {
+	case <-other:
+		t.Fatalf("user 8 should not be woken by user 7's events")
+	default:
+	}
+
+	cancel()
+	s.Publish(7) // no subscribers left; must not panic
+}
+
+func TestStream_ReplaysFromLastEventID(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	nsvc := notifications.NewService(db)
+	svc := &registration.Service{DB: db, Notifications: nsvc}
+	h := &notifications.Handler{Repo: eRepo, UserRepo: uRepo, Service: nsvc, Stream: notifications.NewStream(eRepo, "")}
+
+	org := seedUser(t, uRepo, "org-stream@x.com", "auth0|org-stream", "Organizer")
+	u := seedUser(t, uRepo, "stream@x.com", "auth0|stream", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Stream Event", "PUBLIC")
+
+	if _, err := svc.RegisterUserForEvent(ctx, u.ID, ev.ID); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	events, err := eRepo.GetStreamEventsAfter(ctx, u.ID, 0, 100)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("expected registration and notification events, got %d (%v)", len(events), err)
+	}
+
+	// Reconnect after the first event: only the second is replayed.
+	reqCtx, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/notifications/stream", nil).WithContext(reqCtx)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(events[0].ID, 10))
+	req = injectClaims(req, u.OIDCID)
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		h.HandleStream(w, req)
+		close(done)
+	}()
+	<-done
+
+	body := w.Body.String()
+	if w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected event stream, got %q", w.Header().Get("Content-Type"))
+	}
+	if strings.Contains(body, "id: "+strconv.FormatInt(events[0].ID, 10)+"\n") {
+		t.Fatalf("expected first event to be skipped, got %q", body)
+	}
+	if !strings.Contains(body, "id: "+strconv.FormatInt(events[1].ID, 10)+"\n") || !strings.Contains(body, "Stream Event") {
+		t.Fatalf("expected second event to be replayed, got %q", body)
+	}
+}
+
+func TestStream_DeliversRowsThatCommitLate(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	nsvc := notifications.NewService(db)
+	svc := &registration.Service{DB: db, Notifications: nsvc}
+	stream := notifications.NewStream(eRepo, "")
+	h := &notifications.Handler{Repo: eRepo, UserRepo: uRepo, Service: nsvc, Stream: stream}
+
+	org := seedUser(t, uRepo, "org-late@x.com", "auth0|org-late", "Organizer")
+	u := seedUser(t, uRepo, "late-stream@x.com", "auth0|late-stream", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Late Event", "PUBLIC")
+
+	// A slow transaction takes its id first but commits after later rows.
+	var lateID int64
+	db.QueryRow(`SELECT nextval('stream_events_id_seq')`).Scan(&lateID)
+	if _, err := svc.RegisterUserForEvent(ctx, u.ID, ev.ID); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	latest, _ := eRepo.LatestStreamEventID(ctx, u.ID)
+
+	reqCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/notifications/stream", nil).WithContext(reqCtx)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(latest, 10))
+	req = injectClaims(req, u.OIDCID)
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		h.HandleStream(w, req)
+		close(done)
+	}()
+	time.Sleep(150 * time.Millisecond)
+	if _, err := db.Exec(`INSERT INTO stream_events (id, user_id, type, payload) VALUES ($1, $2, 'notification', '{"late": true}')`, lateID, u.ID); err != nil {
+		t.Fatalf("insert late row: %v", err)
+	}
+	stream.Publish(u.ID)
+	<-done
+
+	body := w.Body.String()
+	if strings.Count(body, `"late": true`) != 1 {
+		t.Fatalf("expected the late row once, got %q", body)
+	}
+	if strings.Contains(body, "Late Event") {
+		t.Fatalf("expected events before Last-Event-ID not to be resent, got %q", body)
+	}
+	if !strings.Contains(body, "id: "+strconv.FormatInt(latest, 10)+"\n") {
+		t.Fatalf("expected the late row to keep the resume point at %d, got %q", latest, body)
+	}
+}
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/registration"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestTemplates_BuiltinsRenderInEveryLocale(t *testing.T) {
+	svc := notifications.NewService(nil)
+
+	templates, err := svc.ListTemplates(context.Background())
+	if err != nil {
+		t.Fatalf("ListTemplates: %v", err)
+	}
+	if len(templates) == 0 {
+		t.Fatalf("expected built-in templates")
+	}
+	for _, tmpl := range templates {
+		if err := notifications.ValidateTemplate(tmpl); err != nil {
+			t.Fatalf("template %s/%s invalid: %v", tmpl.Name, tmpl.Locale, err)
+		}
+	}
+}
+
+func TestTemplates_TimesRenderInRecipientZone(t *testing.T) {
+	tmpl := notifications.Template{
+		Name:    notifications.TmplRegistrationConfirmed,
+		Locale:  "en",
+		Subject: "Hi",
+		Text:    "Starts {{localtime .Event.StartTime}}",
+		HTML:    "<p>{{.Event.Title}}</p>",
+	}
+	data := notifications.SampleData()
+	data.Event.Title = "<script>alert(1)</script>"
+
+	ny, _ := time.LoadLocation("America/New_York")
+	out, err := notifications.RenderTemplate(tmpl, data, ny)
+	if err != nil {
+		t.Fatalf("RenderTemplate: %v", err)
+	}
+	// 18:00 UTC on Mar 14 2030 is 2:00 PM EDT.
+	if !strings.Contains(out.Text, "2:00 PM EDT") {
+		t.Fatalf("expected time in New York zone, got %q", out.Text)
+	}
+	if strings.Contains(out.HTML, "<script>") {
+		t.Fatalf("expected HTML part to be escaped, got %q", out.HTML)
+	}
+}
+
+func TestTemplates_ValidateRejectsUnknownVariables(t *testing.T) {
+	err := notifications.ValidateTemplate(notifications.Template{
+		Name:    notifications.TmplEventUpdated,
+		Locale:  "en",
+		Subject: "Update",
+		Text:    "{{.Event.Organizer}} changed the event",
+	})
+	if err == nil {
+		t.Fatalf("expected error for unknown variable")
+	}
+}
+
+func TestTemplates_OverrideAndLocaleAreUsed(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	nsvc := notifications.NewService(db)
+	svc := &registration.Service{DB: db, Notifications: nsvc}
+	h := &notifications.Handler{Repo: eRepo, UserRepo: uRepo, Service: nsvc}
+
+	admin := seedUser(t, uRepo, "tmpl-admin@x.com", "auth0|tmpl-admin", "Admin")
+	es := seedUser(t, uRepo, "es@x.com", "auth0|es", "Member")
+	if err := uRepo.UpdateSettings(ctx, es.ID, "es", "America/Mexico_City"); err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+	ev := seedEvent(t, eRepo, admin.ID, "Noche de Cine", "PUBLIC")
+
+	body, _ := json.Marshal(map[string]string{
+		"name":    notifications.TmplRegistrationConfirmed,
+		"locale":  "es",
+		"subject": "Confirmado",
+		"text":    "Nos vemos en {{.Event.Title}}",
+	})
+	req := httptest.NewRequest(http.MethodPut, "/admin/templates", bytes.NewReader(body))
+	req = injectClaims(req, admin.OIDCID)
+	w := httptest.NewRecorder()
+	h.HandleSaveTemplate(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 saving template, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if _, err := svc.RegisterUserForEvent(ctx, es.ID, ev.ID); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	var msg string
+	db.QueryRow(`SELECT message FROM notifications WHERE user_id=$1`, es.ID).Scan(&msg)
+	if msg != "Nos vemos en Noche de Cine" {
+		t.Fatalf("expected overridden Spanish message, got %q", msg)
+	}
+
+	// Members cannot edit templates.
+	req = httptest.NewRequest(http.MethodPut, "/admin/templates", bytes.NewReader(body))
+	req = injectClaims(req, es.OIDCID)
+	w = httptest.NewRecorder()
+	h.HandleSaveTemplate(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for member, got %d", w.Code)
+	}
+}
+
+func TestTemplates_AttendeeNoticesSkipInactiveRegistrations(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	nsvc := notifications.NewService(db)
+
+	org := seedUser(t, uRepo, "notice-org@x.com", "auth0|notice-org", "Organizer")
+	ev := seedEvent(t, eRepo, org.ID, "Notice Event", "PUBLIC")
+	told := map[string]bool{
+		"REGISTERED": true,
+		"ATTENDED":   true,
+		"PENDING":    false,
+		"REJECTED":   false,
+		"CANCELLED":  false,
+	}
+	users := map[string]int64{}
+	for status := range told {
+		u := seedUser(t, uRepo, "notice-"+strings.ToLower(status)+"@x.com", "auth0|notice-"+status, "Member")
+		if _, err := db.Exec(`INSERT INTO registrations (user_id, event_id, status) VALUES ($1, $2, $3)`, u.ID, ev.ID, status); err != nil {
+			t.Fatalf("seed %s registration: %v", status, err)
+		}
+		users[status] = u.ID
+	}
+	wait := seedUser(t, uRepo, "notice-wait@x.com", "auth0|notice-wait", "Member")
+	db.Exec(`INSERT INTO waitlist (user_id, event_id) VALUES ($1, $2)`, wait.ID, ev.ID)
+	told["WAITLIST"], users["WAITLIST"] = true, wait.ID
+
+	data := notifications.TemplateData{Event: notifications.EventVars{ID: ev.ID, Title: ev.Title}}
+	if err := nsvc.NotifyAttendees(ctx, ev.ID, notifications.TmplEventCancelled, data); err != nil {
+		t.Fatalf("NotifyAttendees: %v", err)
+	}
+
+	for status, want := range told {
+		var n int
+		db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id=$1`, users[status]).Scan(&n)
+		if got := n > 0; got != want {
+			t.Fatalf("%s: expected notified=%v, got %d notifications", status, want, n)
+		}
+	}
+}
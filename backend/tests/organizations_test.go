@@ -0,0 +1,260 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/announcements"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/authz"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/events"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/feedback"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/organizations"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/registration"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestOrganizations_MembersOnlyEventsAndTransfer(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	orgRepo := store.NewOrganizationRepository(db)
+	owner := seedUser(t, uRepo, "org-owner@x.com", "auth0|org-owner", "Organizer")
+	officer := seedUser(t, uRepo, "org-officer@x.com", "auth0|org-officer", "Organizer")
+	member := seedUser(t, uRepo, "org-member@x.com", "auth0|org-member", "Member")
+	outsider := seedUser(t, uRepo, "org-outsider@x.com", "auth0|org-outsider", "Organizer")
+	admin := seedUser(t, uRepo, "org-admin@x.com", "auth0|org-admin", "Admin")
+	oh := &organizations.Handler{Repo: orgRepo, UserRepo: uRepo}
+	eh := &events.Handler{Repo: eRepo, UserRepo: uRepo, Notifications: notifications.NewService(db), Orgs: orgRepo}
+	ctx := context.Background()
+
+	send := func(fn http.HandlerFunc, method, target, sub string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		rr := httptest.NewRecorder()
+		fn(rr, injectClaims(httptest.NewRequest(method, target, bytes.NewReader(b)), sub))
+		return rr
+	}
+
+	rr := send(oh.HandleCreateOrganization, http.MethodPost, "/organizations", owner.OIDCID, map[string]string{"name": "Chess Club"})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected the organization to be created, got %d %s", rr.Code, rr.Body.String())
+	}
+	var org store.Organization
+	json.NewDecoder(rr.Body).Decode(&org)
+	q := "?organization_id=" + strconv.FormatInt(org.ID, 10)
+
+	for _, m := range []struct {
+		user *store.User
+		role string
+	}{{officer, store.OrgOfficer}, {member, store.OrgMember}} {
+		body := map[string]any{"organization_id": org.ID, "user_id": m.user.ID, "role": m.role}
+		if rr := send(oh.HandleSetMember, http.MethodPut, "/organizations/members", owner.OIDCID, body); rr.Code != http.StatusNoContent {
+			t.Fatalf("expected %s to be added, got %d %s", m.role, rr.Code, rr.Body.String())
+		}
+	}
+	// Officers add members but do not appoint officers.
+	body := map[string]any{"organization_id": org.ID, "user_id": outsider.ID, "role": store.OrgOfficer}
+	if rr := send(oh.HandleSetMember, http.MethodPut, "/organizations/members", officer.OIDCID, body); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for an officer appointing an officer, got %d", rr.Code)
+	}
+
+	start := time.Now().Add(48 * time.Hour).UTC()
+	event := map[string]any{
+		"title": "Members Blitz Night", "description": "Five-minute games.", "location": "Library",
+		"start_time": start, "end_time": start.Add(2 * time.Hour), "capacity": 20,
+		"visibility": "MEMBERS", "organization_id": org.ID, "duplicate_action": "ignore",
+	}
+	if rr := send(eh.HandleCreateEvent, http.MethodPost, "/events", outsider.OIDCID, event); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 posting for someone else's organization, got %d", rr.Code)
+	}
+	rr = send(eh.HandleCreateEvent, http.MethodPost, "/events", officer.OIDCID, event)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected the officer to post for the organization, got %d %s", rr.Code, rr.Body.String())
+	}
+	var created store.Event
+	json.NewDecoder(rr.Body).Decode(&created)
+
+	list, _ := eRepo.Search(ctx, "", "", "")
+	for _, e := range list {
+		if e.ID == created.ID {
+			t.Fatal("expected the members-only event to stay out of the public list")
+		}
+	}
+	var summaries []store.EventSummary
+	json.NewDecoder(send(oh.HandleListEvents, http.MethodGet, "/organizations/events"+q, member.OIDCID, nil).Body).Decode(&summaries)
+	if len(summaries) != 1 || summaries[0].ID != created.ID {
+		t.Fatalf("expected members to see the event, got %+v", summaries)
+	}
+
+	reg := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
+	if _, err := reg.RegisterUserForEvent(ctx, outsider.ID, created.ID); err == nil {
+		t.Fatal("expected a non-member to be refused")
+	}
+	if _, err := reg.RegisterUserForEvent(ctx, member.ID, created.ID); err != nil {
+		t.Fatalf("expected a member to register, got %v", err)
+	}
+
+	if rr := send(oh.HandleAnalytics, http.MethodGet, "/organizations/analytics"+q, member.OIDCID, nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a plain member's analytics, got %d", rr.Code)
+	}
+	var stats store.OrgStats
+	json.NewDecoder(send(oh.HandleAnalytics, http.MethodGet, "/organizations/analytics"+q, officer.OIDCID, nil).Body).Decode(&stats)
+	if stats.TotalEvents != 1 || stats.TotalRegistrations != 1 {
+		t.Fatalf("expected the organization's own numbers, got %+v", stats)
+	}
+
+	transfer := map[string]any{"organization_id": org.ID, "user_id": officer.ID}
+	if rr := send(oh.HandleTransferOwnership, http.MethodPost, "/organizations/transfer", officer.OIDCID, transfer); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for an officer transferring, got %d", rr.Code)
+	}
+	if rr := send(oh.HandleTransferOwnership, http.MethodPost, "/organizations/transfer", admin.OIDCID, transfer); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected the admin to transfer ownership, got %d %s", rr.Code, rr.Body.String())
+	}
+	if role, _ := orgRepo.MemberRole(ctx, org.ID, officer.ID); role != store.OrgOwner {
+		t.Fatalf("expected the officer to own the organization, got %q", role)
+	}
+	if role, _ := orgRepo.MemberRole(ctx, org.ID, owner.ID); role != store.OrgOfficer {
+		t.Fatalf("expected the previous owner to stay on as officer, got %q", role)
+	}
+	if rr := send(oh.HandleRemoveMember, http.MethodDelete, "/organizations/members"+q+"&user_id="+strconv.FormatInt(officer.ID, 10), admin.OIDCID, nil); rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 removing the owner, got %d", rr.Code)
+	}
+}
+
+func TestOrganizations_OfficersManageEventsEverywhere(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	orgRepo := store.NewOrganizationRepository(db)
+	owner := seedUser(t, uRepo, "orgm-owner@x.com", "auth0|orgm-owner", "Organizer")
+	officer := seedUser(t, uRepo, "orgm-officer@x.com", "auth0|orgm-officer", "Organizer")
+	outsider := seedUser(t, uRepo, "orgm-outsider@x.com", "auth0|orgm-outsider", "Organizer")
+	ctx := context.Background()
+
+	club := &store.Organization{Name: "Debate Society"}
+	if err := orgRepo.Create(ctx, club, owner.ID); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	if err := orgRepo.SetMember(ctx, club.ID, officer.ID, store.OrgOfficer); err != nil {
+		t.Fatalf("add officer: %v", err)
+	}
+	event := seedEvent(t, eRepo, owner.ID, "Debate Finals", "PUBLIC")
+	if _, err := db.ExecContext(ctx, "UPDATE events SET organization_id = $1 WHERE id = $2", club.ID, event.ID); err != nil {
+		t.Fatalf("assign organization: %v", err)
+	}
+	q := "?event_id=" + strconv.FormatInt(event.ID, 10)
+
+	fh := &feedback.Handler{Service: feedback.NewService(db, ai.NewServiceWithProvider(nil)), UserRepo: uRepo, EventRepo: eRepo, Orgs: orgRepo}
+	ah := &announcements.Handler{Service: announcements.NewService(db, notifications.NewService(db)), UserRepo: uRepo, EventRepo: eRepo, Orgs: orgRepo}
+	rh := &registration.Handler{Service: &registration.Service{DB: db}, UserRepo: uRepo, EventRepo: eRepo, Orgs: orgRepo}
+
+	for _, c := range []struct {
+		name   string
+		fn     http.HandlerFunc
+		target string
+	}{
+		{"feedback report", fh.HandleReport, "/events/feedback/report" + q},
+		{"announcements", ah.HandleListAnnouncements, "/events/announcements" + q},
+		{"applications", rh.HandleListApplications, "/events/applications" + q},
+	} {
+		rr := httptest.NewRecorder()
+		c.fn(rr, injectClaims(httptest.NewRequest(http.MethodGet, c.target, nil), officer.OIDCID))
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: expected the officer to get 200, got %d %s", c.name, rr.Code, rr.Body.String())
+		}
+		rr = httptest.NewRecorder()
+		c.fn(rr, injectClaims(httptest.NewRequest(http.MethodGet, c.target, nil), outsider.OIDCID))
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403 for an outsider, got %d", c.name, rr.Code)
+		}
+	}
+}
+
+// Club officers are usually plain Members on the site; the route policy
+// must let them through so the handlers can check their organization role.
+func TestOrganizations_MemberOfficerManagesClubEvents(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	orgRepo := store.NewOrganizationRepository(db)
+	owner := seedUser(t, uRepo, "orgmo-owner@x.com", "auth0|orgmo-owner", "Organizer")
+	officer := seedUser(t, uRepo, "orgmo-officer@x.com", "auth0|orgmo-officer", "Member")
+	member := seedUser(t, uRepo, "orgmo-member@x.com", "auth0|orgmo-member", "Member")
+	ctx := context.Background()
+
+	club := &store.Organization{Name: "Chess Club"}
+	if err := orgRepo.Create(ctx, club, owner.ID); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	orgRepo.SetMember(ctx, club.ID, officer.ID, store.OrgOfficer)
+	orgRepo.SetMember(ctx, club.ID, member.ID, store.OrgMember)
+	event := seedEvent(t, eRepo, owner.ID, "Chess Open", "PUBLIC")
+	db.ExecContext(ctx, "UPDATE events SET organization_id = $1 WHERE id = $2", club.ID, event.ID)
+	q := "?event_id=" + strconv.FormatInt(event.ID, 10)
+
+	eh := &events.Handler{Repo: eRepo, UserRepo: uRepo, Notifications: notifications.NewService(db), Orgs: orgRepo}
+	rh := &registration.Handler{Service: &registration.Service{DB: db}, UserRepo: uRepo, EventRepo: eRepo, Orgs: orgRepo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /events", eh.HandleCreateEvent)
+	mux.HandleFunc("PUT /events", eh.HandleUpdateEvent)
+	mux.HandleFunc("GET /events/export", eh.HandleExportAttendees)
+	mux.HandleFunc("GET /events/applications", rh.HandleListApplications)
+	guard := authz.Guard(mux, uRepo, authz.Policy)
+
+	start := time.Now().Add(48 * time.Hour)
+	edit, _ := json.Marshal(map[string]any{
+		"id": event.ID, "title": "Chess Open (Rapid)", "location": "Hall B",
+		"start_time": start, "end_time": start.Add(time.Hour), "capacity": 20, "visibility": "PUBLIC", "category": "Games",
+		"organization_id": club.ID,
+	})
+	post := func(orgID int64) []byte {
+		b, _ := json.Marshal(map[string]any{
+			"title": "Blitz Night", "location": "Hall C", "start_time": start.Add(24 * time.Hour), "end_time": start.Add(25 * time.Hour),
+			"capacity": 20, "visibility": "PUBLIC", "category": "Games", "organization_id": orgID, "duplicate_action": "ignore",
+		})
+		return b
+	}
+
+	for _, c := range []struct {
+		name   string
+		method string
+		target string
+		body   []byte
+		who    *store.User
+		want   int
+	}{
+		{"edit", http.MethodPut, "/events", edit, officer, http.StatusOK},
+		{"export", http.MethodGet, "/events/export" + q, nil, officer, http.StatusOK},
+		{"applications", http.MethodGet, "/events/applications" + q, nil, officer, http.StatusOK},
+		{"post for the club", http.MethodPost, "/events", post(club.ID), officer, http.StatusCreated},
+		{"personal event", http.MethodPost, "/events", post(0), officer, http.StatusForbidden},
+		{"member edit", http.MethodPut, "/events", edit, member, http.StatusForbidden},
+		{"member export", http.MethodGet, "/events/export" + q, nil, member, http.StatusForbidden},
+		{"member applications", http.MethodGet, "/events/applications" + q, nil, member, http.StatusForbidden},
+		{"member post for the club", http.MethodPost, "/events", post(club.ID), member, http.StatusForbidden},
+	} {
+		rr := httptest.NewRecorder()
+		guard.ServeHTTP(rr, injectClaims(httptest.NewRequest(c.method, c.target, bytes.NewReader(c.body)), c.who.OIDCID))
+		if rr.Code != c.want {
+			t.Errorf("%s: got %d, want %d (%s)", c.name, rr.Code, c.want, rr.Body.String())
+		}
+	}
+
+	updated, _ := eRepo.GetEventByID(ctx, event.ID)
+	if updated.Title != "Chess Open (Rapid)" {
+		t.Fatalf("expected the officer's edit to be saved, got %q", updated.Title)
+	}
+}
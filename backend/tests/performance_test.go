@@ -49,6 +49,7 @@ func TestPerformance_Export500AttendeesUnder5s(t *testing.T) {
 	}
 
 	req := httptest.NewRequest("GET", fmt.Sprintf("/export?event_id=%d", ev.ID), nil)
+	req = injectClaims(req, org.OIDCID)
 	w := httptest.NewRecorder()
 
 	start := time.Now()
@@ -7,12 +7,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
 
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/auth"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/events"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/users"
@@ -28,6 +30,17 @@ func injectClaims(req *http.Request, subject string) *http.Request {
 	return req.WithContext(ctx)
 }
 
+// injectTokenClaims is injectClaims with custom claims, such as the email
+// the identity provider put in the token.
+func injectTokenClaims(req *http.Request, subject string, custom map[string]any) *http.Request {
+	claims := &validator.ValidatedClaims{
+		RegisteredClaims: validator.RegisteredClaims{Subject: subject},
+		CustomClaims:     &auth.CustomClaims{All: custom},
+	}
+	ctx := context.WithValue(req.Context(), jwtmiddleware.ContextKey{}, claims)
+	return req.WithContext(ctx)
+}
+
 func TestHandleUpdateRole_AdminCanPromoteMemberToOrganizer(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -185,3 +198,35 @@ func TestHandleCreateEvent_OnlyOrganizerOrAdminAllowed(t *testing.T) {
 		t.Fatalf("expected 201 for Organizer create event, got %d, body=%s", rr2.Code, rr2.Body.String())
 	}
 }
+
+func TestAttendees_OnlyEventManagers(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userRepo := store.NewUserRepository(db)
+	eventRepo := store.NewEventRepository(db)
+	h := &events.Handler{Repo: eventRepo, UserRepo: userRepo, Orgs: store.NewOrganizationRepository(db)}
+
+	owner := seedUser(t, userRepo, "att-owner@x.com", "auth0|att-owner", "Organizer")
+	other := seedUser(t, userRepo, "att-other@x.com", "auth0|att-other", "Organizer")
+	admin := seedUser(t, userRepo, "att-admin@x.com", "auth0|att-admin", "Admin")
+	ev := seedEvent(t, eventRepo, owner.ID, "Private List", "PUBLIC")
+	target := "/events/attendees?event_id=" + strconv.FormatInt(ev.ID, 10)
+
+	for _, fn := range []http.HandlerFunc{h.HandleListAttendees, h.HandleExportAttendees} {
+		for _, c := range []struct {
+			user *store.User
+			want int
+		}{
+			{owner, http.StatusOK},
+			{other, http.StatusForbidden},
+			{admin, http.StatusOK},
+		} {
+			rr := httptest.NewRecorder()
+			fn(rr, injectClaims(httptest.NewRequest(http.MethodGet, target, nil), c.user.OIDCID))
+			if rr.Code != c.want {
+				t.Errorf("%s: got %d, want %d", c.user.Email, rr.Code, c.want)
+			}
+		}
+	}
+}
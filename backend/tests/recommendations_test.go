@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/recommendations"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestRank_SignalsAndReasons(t *testing.T) {
+	now := time.Now()
+	history := []recommendations.PastEvent{
+		{ID: 1, Title: "Go Meetup", Category: "Tech"},
+		{ID: 2, Title: "Rust Night", Category: "Tech"},
+	}
+	candidates := []recommendations.Candidate{
+		{ID: 10, Title: "Poetry Slam", Category: "Arts", StartTime: now.Add(time.Hour), Registered: 2},
+		{ID: 11, Title: "AI Workshop", Category: "Tech", StartTime: now.Add(2 * time.Hour), Registered: 5},
+		{ID: 12, Title: "Chess Club", Category: "Games", StartTime: now.Add(3 * time.Hour), Registered: 50},
+	}
+	co := map[int64][]recommendations.CoAttendance{
+		10: {{SourceID: 2, People: 4}, {SourceID: 1, People: 1}},
+	}
+
+	recs := recommendations.Rank(history, candidates, co, 0)
+	if len(recs) != 3 || recs[0].Event.ID != 11 {
+		t.Fatalf("expected category match first, got %+v", recs)
+	}
+	reasons := map[int64]string{}
+	for _, r := range recs {
+		reasons[r.Event.ID] = r.Reason
+	}
+	if !strings.Contains(reasons[11], "2 Tech events") {
+		t.Fatalf("unexpected category reason %q", reasons[11])
+	}
+	if !strings.Contains(reasons[10], "Rust Night") {
+		t.Fatalf("expected co-attendance reason naming Rust Night, got %q", reasons[10])
+	}
+	if !strings.Contains(reasons[12], "Popular: 50") {
+		t.Fatalf("unexpected popularity reason %q", reasons[12])
+	}
+
+	// Without history only popularity counts.
+	cold := recommendations.Rank(nil, candidates, nil, 1)
+	if len(cold) != 1 || cold[0].Event.ID != 12 {
+		t.Fatalf("expected most popular event for cold start, got %+v", cold)
+	}
+}
+
+func TestReorder_KeepsUnlistedEvents(t *testing.T) {
+	recs := []recommendations.Recommendation{
+		{Event: recommendations.Candidate{ID: 1}},
+		{Event: recommendations.Candidate{ID: 2}},
+		{Event: recommendations.Candidate{ID: 3}},
+	}
+	got := recommendations.Reorder(recs, []int64{3, 99, 3, 1})
+	if len(got) != 3 || got[0].Event.ID != 3 || got[1].Event.ID != 1 || got[2].Event.ID != 2 {
+		t.Fatalf("unexpected order %+v", got)
+	}
+}
+
+func TestRecommendations_ExcludesInaccessibleAndRegistered(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+
+	org := seedUser(t, uRepo, "org-rec@x.com", "auth0|org-rec", "Organizer")
+	u := seedUser(t, uRepo, "rec@x.com", "auth0|rec", "Member")
+	open := seedEvent(t, eRepo, org.ID, "Open Talk", "PUBLIC")
+	joined := seedEvent(t, eRepo, org.ID, "Joined Talk", "PUBLIC")
+	private := seedEvent(t, eRepo, org.ID, "Secret Dinner", "PRIVATE")
+	invited := seedEvent(t, eRepo, org.ID, "Invited Dinner", "PRIVATE")
+	db.Exec(`UPDATE events SET start_time = NOW() + INTERVAL '1 day', end_time = NOW() + INTERVAL '2 days'`)
+	db.Exec(`INSERT INTO registrations (user_id, event_id, status) VALUES ($1, $2, 'REGISTERED')`, u.ID, joined.ID)
+	db.Exec(`INSERT INTO invitations (event_id, email) VALUES ($1, $2)`, invited.ID, u.Email)
+
+	// A model that would put the private event first must not surface it.
+	svc := recommendations.NewService(db, ai.NewServiceWithProvider(&ai.MockProvider{
+		Reply: func(ai.CompletionRequest) string { return fmt.Sprintf("Here you go: [%d, %d]", private.ID, invited.ID) },
+	}))
+	recs, err := svc.Recommend(context.Background(), u.ID, u.Email, 10, true)
+	if err != nil {
+		t.Fatalf("recommend: %v", err)
+	}
+	ids := map[int64]bool{}
+	for _, r := range recs {
+		ids[r.Event.ID] = true
+		if r.Reason == "" {
+			t.Fatalf("expected a reason for %d", r.Event.ID)
+		}
+	}
+	if len(recs) != 2 || !ids[open.ID] || !ids[invited.ID] || recs[0].Event.ID != invited.ID {
+		t.Fatalf("expected re-ranked [invited, open], got %+v", recs)
+	}
+}
+
+func TestRecommendations_ReRankUsesDailyQuota(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	org := seedUser(t, uRepo, "org-recq@x.com", "auth0|org-recq", "Organizer")
+	u := seedUser(t, uRepo, "recq@x.com", "auth0|recq", "Member")
+	first := seedEvent(t, eRepo, org.ID, "First Talk", "PUBLIC")
+	second := seedEvent(t, eRepo, org.ID, "Second Talk", "PUBLIC")
+	db.Exec(`UPDATE events SET start_time = NOW() + INTERVAL '1 day', end_time = NOW() + INTERVAL '2 days'`)
+
+	model := &ai.MockProvider{Reply: func(ai.CompletionRequest) string { return fmt.Sprintf("[%d, %d]", second.ID, first.ID) }}
+	aiService := ai.NewServiceWithProvider(model)
+	aiService.Quota = &ai.Quota{DB: db, Requests: 1}
+	svc := recommendations.NewService(db, aiService)
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.Recommend(context.Background(), u.ID, u.Email, 10, true); err != nil {
+			t.Fatalf("recommend %d: %v", i, err)
+		}
+	}
+	// The second re-rank is over quota: it falls back to the baseline
+	// without calling the model.
+	if model.Calls() != 1 {
+		t.Fatalf("expected one model call within the quota, got %d", model.Calls())
+	}
+	var requests int
+	db.QueryRow(`SELECT requests FROM ai_usage WHERE subject=$1`, ai.UserSubject(u.ID)).Scan(&requests)
+	if requests != 1 {
+		t.Fatalf("expected the re-rank to be charged to the user, got %d requests", requests)
+	}
+}
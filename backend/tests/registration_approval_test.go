@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/registration"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestRegistration_ApprovalModeCreatesPendingApplication(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
+	h := &registration.Handler{Service: svc, UserRepo: uRepo, EventRepo: eRepo}
+
+	org := seedUser(t, uRepo, "org-approve@x.com", "auth0|org-approve", "Organizer")
+	applicant := seedUser(t, uRepo, "applicant@x.com", "auth0|applicant", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Research Symposium", "PUBLIC")
+	if _, err := db.Exec(`UPDATE events SET requires_approval = TRUE WHERE id=$1`, ev.ID); err != nil {
+		t.Fatalf("enable approval: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"form_responses": map[string]string{"Why attend?": "I present a poster"},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/registrations?event_id="+strconv.FormatInt(ev.ID, 10), bytes.NewReader(body))
+	req = injectClaims(req, applicant.OIDCID)
+	w := httptest.NewRecorder()
+	h.HandleRegister(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var res registration.RegisterResult
+	json.Unmarshal(w.Body.Bytes(), &res)
+	if res.Status != "PENDING" {
+		t.Fatalf("expected PENDING, got %s", res.Status)
+	}
+
+	// A second attempt must not create a duplicate application.
+	if _, err := svc.RegisterUserForEvent(ctx, applicant.ID, ev.ID); err == nil {
+		t.Fatalf("expected error for duplicate application")
+	}
+
+	// The organizer sees the applicant and their answers in the queue.
+	req = httptest.NewRequest(http.MethodGet, "/events/applications?event_id="+strconv.FormatInt(ev.ID, 10), nil)
+	req = injectClaims(req, org.OIDCID)
+	w = httptest.NewRecorder()
+	h.HandleListApplications(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 listing applications, got %d: %s", w.Code, w.Body.String())
+	}
+	var apps []*store.Application
+	json.Unmarshal(w.Body.Bytes(), &apps)
+	if len(apps) != 1 || apps[0].FormResponses["Why attend?"] != "I present a poster" {
+		t.Fatalf("unexpected applications: %+v", apps)
+	}
+
+	// Members cannot see the review queue.
+	req = httptest.NewRequest(http.MethodGet, "/events/applications?event_id="+strconv.FormatInt(ev.ID, 10), nil)
+	req = injectClaims(req, applicant.OIDCID)
+	w = httptest.NewRecorder()
+	h.HandleListApplications(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for member, got %d", w.Code)
+	}
+}
+
+func TestRegistration_BulkDecisionsNotifyApplicants(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
+	h := &registration.Handler{Service: svc, UserRepo: uRepo, EventRepo: eRepo}
+
+	org := seedUser(t, uRepo, "org-bulk@x.com", "auth0|org-bulk", "Organizer")
+	a1 := seedUser(t, uRepo, "a1@x.com", "auth0|a1", "Member")
+	a2 := seedUser(t, uRepo, "a2@x.com", "auth0|a2", "Member")
+	a3 := seedUser(t, uRepo, "a3@x.com", "auth0|a3", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Limited Dinner", "PUBLIC")
+	if _, err := db.Exec(`UPDATE events SET requires_approval = TRUE, capacity = 1 WHERE id=$1`, ev.ID); err != nil {
+		t.Fatalf("enable approval: %v", err)
+	}
+
+	for _, u := range []*store.User{a1, a2, a3} {
+		if _, err := svc.RegisterUserForEvent(ctx, u.ID, ev.ID); err != nil {
+			t.Fatalf("apply %s: %v", u.Email, err)
+		}
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"event_id": ev.ID,
+		"user_ids": []int64{a1.ID, a2.ID},
+		"decision": registration.DecisionApprove,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/events/applications/decide", bytes.NewReader(body))
+	req = injectClaims(req, org.OIDCID)
+	w := httptest.NewRecorder()
+	h.HandleDecideApplications(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var results []*registration.DecisionResult
+	json.Unmarshal(w.Body.Bytes(), &results)
+	if len(results) != 2 || results[0].Status != "REGISTERED" || results[1].Status != "SKIPPED" {
+		t.Fatalf("expected first approved and second skipped (full), got %+v", results)
+	}
+
+	if _, err := svc.DecideApplications(ctx, org.ID, ev.ID, []int64{a2.ID}, registration.DecisionWaitlist); err != nil {
+		t.Fatalf("waitlist a2: %v", err)
+	}
+	if _, err := svc.DecideApplications(ctx, org.ID, ev.ID, []int64{a3.ID}, registration.DecisionReject); err != nil {
+		t.Fatalf("reject a3: %v", err)
+	}
+
+	var waitlisted int
+	db.QueryRow(`SELECT COUNT(*) FROM waitlist WHERE event_id=$1 AND user_id=$2`, ev.ID, a2.ID).Scan(&waitlisted)
+	if waitlisted != 1 {
+		t.Fatalf("expected a2 on waitlist")
+	}
+
+	var status string
+	db.QueryRow(`SELECT status FROM registrations WHERE event_id=$1 AND user_id=$2`, ev.ID, a3.ID).Scan(&status)
+	if status != "REJECTED" {
+		t.Fatalf("expected a3 REJECTED, got %s", status)
+	}
+
+	// Every applicant got one notification on applying and one per decision.
+	for _, u := range []*store.User{a1, a2, a3} {
+		var n int
+		db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id=$1`, u.ID).Scan(&n)
+		if n != 2 {
+			t.Fatalf("expected 2 notifications for %s, got %d", u.Email, n)
+		}
+	}
+}
+
+func TestRegistration_WaitlistedApplicantKeepsPlaceAndAnswers(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
+
+	org := seedUser(t, uRepo, "org-wl@x.com", "auth0|org-wl", "Organizer")
+	seated := seedUser(t, uRepo, "seated@x.com", "auth0|seated", "Member")
+	waiting := seedUser(t, uRepo, "waiting@x.com", "auth0|waiting", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Small Workshop", "PUBLIC")
+	if _, err := db.Exec(`UPDATE events SET requires_approval = TRUE, capacity = 1 WHERE id=$1`, ev.ID); err != nil {
+		t.Fatalf("enable approval: %v", err)
+	}
+
+	svc.RegisterUserForEvent(ctx, seated.ID, ev.ID)
+	if _, err := svc.RegisterWithResponses(ctx, waiting.ID, ev.ID, map[string]string{"Laptop?": "yes"}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	svc.DecideApplications(ctx, org.ID, ev.ID, []int64{seated.ID}, registration.DecisionApprove)
+	svc.DecideApplications(ctx, org.ID, ev.ID, []int64{waiting.ID}, registration.DecisionWaitlist)
+
+	// Applying again while waitlisted keeps the waitlist place.
+	res, err := svc.RegisterUserForEvent(ctx, waiting.ID, ev.ID)
+	if err != nil || res.Status != "WAITLISTED" {
+		t.Fatalf("expected the waitlist place to be kept, got %+v (%v)", res, err)
+	}
+	var rows int
+	db.QueryRow(`SELECT COUNT(*) FROM registrations WHERE event_id=$1 AND user_id=$2`, ev.ID, waiting.ID).Scan(&rows)
+	if rows != 0 {
+		t.Fatalf("expected no new application while waitlisted")
+	}
+
+	// A freed seat promotes them with their answers.
+	if err := svc.CancelRegistration(ctx, seated.ID, ev.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	var status, responses string
+	db.QueryRow(`SELECT status, form_responses FROM registrations WHERE event_id=$1 AND user_id=$2`, ev.ID, waiting.ID).Scan(&status, &responses)
+	if status != "REGISTERED" || !strings.Contains(responses, `"Laptop?":"yes"`) {
+		t.Fatalf("expected promotion with answers, got %q %q", status, responses)
+	}
+}
+
+func TestRegistration_PromotionSkipsUsersWithARegistration(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
+
+	org := seedUser(t, uRepo, "org-skip@x.com", "auth0|org-skip", "Organizer")
+	seated := seedUser(t, uRepo, "seated-skip@x.com", "auth0|seated-skip", "Member")
+	stale := seedUser(t, uRepo, "stale-skip@x.com", "auth0|stale-skip", "Member")
+	next := seedUser(t, uRepo, "next-skip@x.com", "auth0|next-skip", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Tiny Talk", "PUBLIC")
+	db.Exec(`UPDATE events SET capacity = 1 WHERE id=$1`, ev.ID)
+
+	svc.RegisterUserForEvent(ctx, seated.ID, ev.ID)
+	// A waitlist row left over next to a pending application.
+	db.Exec(`INSERT INTO waitlist (user_id, event_id, created_at) VALUES ($1, $2, NOW() - INTERVAL '1 hour')`, stale.ID, ev.ID)
+	db.Exec(`INSERT INTO registrations (user_id, event_id, status) VALUES ($1, $2, 'PENDING')`, stale.ID, ev.ID)
+	svc.RegisterUserForEvent(ctx, next.ID, ev.ID)
+
+	if err := svc.CancelRegistration(ctx, seated.ID, ev.ID); err != nil {
+		t.Fatalf("cancel must not fail on a conflicting waitlist entry: %v", err)
+	}
+	var status string
+	db.QueryRow(`SELECT status FROM registrations WHERE event_id=$1 AND user_id=$2`, ev.ID, next.ID).Scan(&status)
+	if status != "REGISTERED" {
+		t.Fatalf("expected the next eligible user promoted, got %q", status)
+	}
+}
@@ -15,7 +15,7 @@ func TestRegistration_CapacityAndWaitlistAndPromotion(t *testing.T) {
 	defer db.Close()
 
 	ctx := context.Background()
-	notifySvc := notifications.NewService()
+	notifySvc := notifications.NewService(db)
 	svc := &registration.Service{
 		DB:            db,
 		Notifications: notifySvc,
@@ -124,7 +124,7 @@ func TestRegistration_PrivateEventRequiresInvitation(t *testing.T) {
 	defer db.Close()
 
 	ctx := context.Background()
-	notifySvc := notifications.NewService()
+	notifySvc := notifications.NewService(db)
 	svc := &registration.Service{
 		DB:            db,
 		Notifications: notifySvc,
@@ -180,7 +180,7 @@ func TestRegistration_PreventDuplicateRegistration(t *testing.T) {
 	defer db.Close()
 
 	ctx := context.Background()
-	notifySvc := notifications.NewService()
+	notifySvc := notifications.NewService(db)
 	svc := &registration.Service{
 		DB:            db,
 		Notifications: notifySvc,
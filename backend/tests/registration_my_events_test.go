@@ -92,7 +92,7 @@ func TestRegister_AlreadyRegistered(t *testing.T) {
 	uRepo := store.NewUserRepository(db)
 	eRepo := store.NewEventRepository(db)
 
-	svc := &registration.Service{DB: db, Notifications: notifications.NewService()}
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
 	h := &registration.Handler{
 		Service:   svc,
 		UserRepo:  uRepo,
@@ -127,7 +127,7 @@ func TestRegister_AlreadyWaitlisted(t *testing.T) {
 	db := setupTestDB(t)
 	uRepo := store.NewUserRepository(db)
 	eRepo := store.NewEventRepository(db)
-	svc := &registration.Service{DB: db, Notifications: notifications.NewService()}
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
 	h := &registration.Handler{Service: svc, UserRepo: uRepo, EventRepo: eRepo}
 
 	user := seedUser(t, uRepo, "wait@dup.com", "auth0|waitdup", "Member")
@@ -159,7 +159,7 @@ func TestRegister_CancelledEvent(t *testing.T) {
 	db := setupTestDB(t)
 	uRepo := store.NewUserRepository(db)
 	eRepo := store.NewEventRepository(db)
-	svc := &registration.Service{DB: db, Notifications: notifications.NewService()}
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
 	h := &registration.Handler{Service: svc, UserRepo: uRepo, EventRepo: eRepo}
 
 	user := seedUser(t, uRepo, "cancel@x.com", "auth0|cx", "Member")
@@ -189,7 +189,7 @@ func TestRegister_PassedEvent(t *testing.T) {
 	db := setupTestDB(t)
 	uRepo := store.NewUserRepository(db)
 	eRepo := store.NewEventRepository(db)
-	svc := &registration.Service{DB: db, Notifications: notifications.NewService()}
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
 	h := &registration.Handler{Service: svc, UserRepo: uRepo, EventRepo: eRepo}
 
 	user := seedUser(t, uRepo, "past@x.com", "auth0|past1", "Member")
@@ -217,7 +217,7 @@ func TestCancelRegistration_NotRegistered(t *testing.T) {
 	db := setupTestDB(t)
 	uRepo := store.NewUserRepository(db)
 	eRepo := store.NewEventRepository(db)
-	svc := &registration.Service{DB: db, Notifications: notifications.NewService()}
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
 	h := &registration.Handler{Service: svc, UserRepo: uRepo, EventRepo: eRepo}
 
 	user := seedUser(t, uRepo, "notreg@x.com", "auth0|nr", "Member")
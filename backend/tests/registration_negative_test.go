@@ -16,7 +16,7 @@ func TestRegister_PrivateEventNotInvited(t *testing.T) {
 
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	svc := &registration.Service{DB: db, Notifications: notifications.NewService()}
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
 
 	handler := &registration.Handler{
 		Service:   svc,
@@ -46,7 +46,7 @@ func TestCancelRegistration_NotFound(t *testing.T) {
 
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	svc := &registration.Service{DB: db, Notifications: notifications.NewService()}
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
 
 	handler := &registration.Handler{
 		Service:   svc,
@@ -74,7 +74,7 @@ func TestRegister_EventNotFound(t *testing.T) {
 
 	userRepo := store.NewUserRepository(db)
 	eventRepo := store.NewEventRepository(db)
-	svc := &registration.Service{DB: db, Notifications: notifications.NewService()}
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db)}
 	handler := &registration.Handler{
 		Service:   svc,
 		UserRepo:  userRepo,
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/background"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestParseOffsets(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    []time.Duration
+		wantErr bool
+	}{
+		{"", []time.Duration{24 * time.Hour, time.Hour}, false},
+		{"  ", []time.Duration{24 * time.Hour, time.Hour}, false},
+		{"48h, 30m", []time.Duration{48 * time.Hour, 30 * time.Minute}, false},
+		{"1h,60m,24h,1h", []time.Duration{time.Hour, 24 * time.Hour}, false},
+		{"tomorrow", nil, true},
+		{"24h,", nil, true},
+		{"0s", nil, true},
+		{"24h,-1h", nil, true},
+		{"30s", nil, true},
+		{"1m,1m30s", nil, true},
+		{"1.5h,90m", []time.Duration{90 * time.Minute}, false},
+	}
+	for _, c := range cases {
+		got, err := background.ParseOffsets(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("ParseOffsets(%q) = %v, want error", c.in, got)
+			}
+			continue
+		}
+		if err != nil || len(got) != len(c.want) {
+			t.Errorf("ParseOffsets(%q) = %v (%v), want %v", c.in, got, err, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("ParseOffsets(%q) = %v, want %v", c.in, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestReminders_SentOnceForClosestWindow(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	nsvc := notifications.NewService(db)
+
+	org := seedUser(t, uRepo, "org-remind@x.com", "auth0|org-remind", "Organizer")
+	u := seedUser(t, uRepo, "remind@x.com", "auth0|remind", "Member")
+	late := seedUser(t, uRepo, "late@x.com", "auth0|late", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Reminder Event", "PUBLIC")
+
+	// Event starts in 30 minutes; u registered two days ago, late just now.
+	if _, err := db.Exec(`UPDATE events SET start_time = NOW() + INTERVAL '30 minutes', end_time = NOW() + INTERVAL '2 hours', reminder_note = 'Bring a laptop' WHERE id=$1`, ev.ID); err != nil {
+		t.Fatalf("update event: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO registrations (user_id, event_id, status, created_at) VALUES ($1, $2, 'REGISTERED', NOW() - INTERVAL '2 days'), ($3, $2, 'REGISTERED', NOW())`, u.ID, ev.ID, late.ID); err != nil {
+		t.Fatalf("seed registrations: %v", err)
+	}
+
+	// Two schedulers stand in for two API instances.
+	a := background.NewReminderScheduler(db, nsvc, []time.Duration{24 * time.Hour, time.Hour})
+	b := background.NewReminderScheduler(db, nsvc, []time.Duration{time.Hour, 24 * time.Hour})
+	a.Test_SendDueReminders()
+	b.Test_SendDueReminders()
+	a.Test_SendDueReminders()
+
+	var sent int
+	db.QueryRow(`SELECT COUNT(*) FROM event_reminders_sent WHERE event_id=$1 AND user_id=$2`, ev.ID, u.ID).Scan(&sent)
+	if sent != 1 {
+		t.Fatalf("expected exactly 1 reminder for u, got %d", sent)
+	}
+
+	var msg string
+	db.QueryRow(`SELECT message FROM notifications WHERE user_id=$1`, u.ID).Scan(&msg)
+	if msg == "" || !strings.Contains(msg, "Bring a laptop") {
+		t.Fatalf("expected reminder with organizer note, got %q", msg)
+	}
+
+	var emails int
+	db.QueryRow(`SELECT COUNT(*) FROM email_outbox WHERE to_email=$1`, u.Email).Scan(&emails)
+	if emails != 1 {
+		t.Fatalf("expected 1 reminder email, got %d", emails)
+	}
+
+	// Registering inside the window does not trigger a reminder for it.
+	db.QueryRow(`SELECT COUNT(*) FROM event_reminders_sent WHERE user_id=$1`, late.ID).Scan(&sent)
+	if sent != 0 {
+		t.Fatalf("expected no reminder for late registration, got %d", sent)
+	}
+}
@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/auth"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/rolemap"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestRoleMapping_ParseAndEvaluate(t *testing.T) {
+	for _, bad := range []string{
+		`[{"claim":"groups","role":"Organizer"}]`,
+		`[{"claim":"groups","value":"staff","email_domain":"x.edu","role":"Organizer"}]`,
+		`[{"email_domain":"x.edu"}]`,
+		`[{"email_domain":"x.edu","role":"Root"}]`,
+		`[{"email_domain":"x.edu","organization":"Chess Club","org_role":"OWNER"}]`,
+	} {
+		if _, err := rolemap.ParseRules([]byte(bad)); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+
+	rules, err := rolemap.ParseRules([]byte(`[
+		{"claim": "https://campussync/groups", "value": "faculty", "role": "Organizer"},
+		{"claim": "permissions", "value": "admin:all", "role": "Admin"},
+		{"claim": "https://campussync/groups", "value": "chess", "organization": "Chess Club"},
+		{"claim": "https://campussync/groups", "value": "chess-board", "organization": "Chess Club", "org_role": "OFFICER"},
+		{"email_domain": "@Staff.Campus.edu", "role": "Organizer"}
+	]`))
+	if err != nil {
+		t.Fatalf("parse rules: %v", err)
+	}
+
+	g := rolemap.Evaluate(rules, map[string]any{
+		"https://campussync/groups": []any{"chess-board", "faculty", "chess"},
+	}, "prof@campus.edu")
+	if g.Role != "Organizer" || g.Orgs["Chess Club"] != store.OrgOfficer {
+		t.Fatalf("expected Organizer and Chess Club officer, got %+v", g)
+	}
+	if g := rolemap.Evaluate(rules, map[string]any{"permissions": []any{"admin:all"}}, "a@x.com"); g.Role != "Admin" {
+		t.Fatalf("expected Admin from permissions, got %+v", g)
+	}
+	if g := rolemap.Evaluate(rules, nil, "head@staff.campus.edu"); g.Role != "Organizer" {
+		t.Fatalf("expected Organizer from the email domain, got %+v", g)
+	}
+	if g := rolemap.Evaluate(rules, map[string]any{"https://campussync/groups": "faculty"}, "student@other.edu"); g.Role != "Organizer" {
+		t.Fatalf("expected a string claim to match, got %+v", g)
+	}
+	if g := rolemap.Evaluate(rules, nil, "student@campus.edu"); g.Role != "" || len(g.Orgs) != 0 {
+		t.Fatalf("expected no grants, got %+v", g)
+	}
+}
+
+func TestRoleMapping_VerifiedEmail(t *testing.T) {
+	cases := []struct {
+		claims map[string]any
+		want   string
+	}{
+		{map[string]any{"email": "a@staff.campus.edu", "email_verified": true}, "a@staff.campus.edu"},
+		{map[string]any{"https://campussync/email": "a@staff.campus.edu", "https://campussync/email_verified": true}, "a@staff.campus.edu"},
+		{map[string]any{"email": "a@staff.campus.edu", "email_verified": false}, ""},
+		{map[string]any{"email": "a@staff.campus.edu"}, ""},
+		{nil, ""},
+	}
+	for _, c := range cases {
+		if got := auth.VerifiedEmail(c.claims); got != c.want {
+			t.Errorf("VerifiedEmail(%v) = %q, want %q", c.claims, got, c.want)
+		}
+	}
+}
+
+func TestRoleMapping_ApplyOnSync(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	oRepo := store.NewOrganizationRepository(db)
+	ctx := context.Background()
+	owner := seedUser(t, uRepo, "rm-owner@x.com", "auth0|rm-owner", "Organizer")
+	user := seedUser(t, uRepo, "rm-user@x.com", "auth0|rm-user", "Member")
+	admin := seedUser(t, uRepo, "rm-admin@x.com", "auth0|rm-admin", "Admin")
+
+	chess := &store.Organization{Name: "Chess Club"}
+	drama := &store.Organization{Name: "Drama Society"}
+	for _, o := range []*store.Organization{chess, drama} {
+		if err := oRepo.Create(ctx, o, owner.ID); err != nil {
+			t.Fatalf("create organization: %v", err)
+		}
+	}
+	// The user joined Drama by hand; role mapping must leave that alone.
+	if err := oRepo.SetMember(ctx, drama.ID, user.ID, store.OrgMember); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+
+	rules, err := rolemap.ParseRules([]byte(`[
+		{"claim": "groups", "value": "faculty", "role": "Organizer"},
+		{"claim": "groups", "value": "chess", "organization": "Chess Club"},
+		{"claim": "groups", "value": "drama", "organization": "Drama Society", "org_role": "OFFICER"},
+		{"claim": "groups", "value": "faculty", "organization": "No Such Club"}
+	]`))
+	if err != nil {
+		t.Fatalf("parse rules: %v", err)
+	}
+	svc := &rolemap.Service{DB: db, Users: uRepo, Rules: rules}
+	groups := func(g ...any) map[string]any { return map[string]any{"groups": g} }
+
+	changes, err := svc.Apply(ctx, user, groups("faculty", "chess", "drama"))
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if user.Role != "Organizer" || len(changes) != 2 {
+		t.Fatalf("expected Organizer and one new membership, got %s %+v", user.Role, changes)
+	}
+	if role, _ := oRepo.MemberRole(ctx, chess.ID, user.ID); role != store.OrgMember {
+		t.Fatalf("expected a Chess Club membership from the claim, got %q", role)
+	}
+	if role, _ := oRepo.MemberRole(ctx, drama.ID, user.ID); role != store.OrgMember {
+		t.Fatalf("expected the manual Drama membership to be kept as is, got %q", role)
+	}
+
+	// Logging in again with the same claims changes nothing.
+	if changes, _ := svc.Apply(ctx, user, groups("faculty", "chess", "drama")); len(changes) != 0 {
+		t.Fatalf("expected no changes on a repeat sync, got %+v", changes)
+	}
+
+	// The claims are gone: back to the manual role, claim membership removed.
+	if _, err := svc.Apply(ctx, user, nil); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	stored, _ := uRepo.GetByID(ctx, user.ID)
+	if stored.Role != "Member" || stored.ManualRole != "Member" || stored.ClaimRole != "" {
+		t.Fatalf("expected to fall back to Member, got %+v", stored)
+	}
+	if role, _ := oRepo.MemberRole(ctx, chess.ID, user.ID); role != "" {
+		t.Fatalf("expected the claim membership to be removed, got %q", role)
+	}
+	if role, _ := oRepo.MemberRole(ctx, drama.ID, user.ID); role != store.OrgMember {
+		t.Fatalf("expected the manual membership to stay, got %q", role)
+	}
+
+	// A claim never lowers a role given by hand.
+	if _, err := svc.Apply(ctx, admin, groups("faculty")); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if admin.Role != "Admin" {
+		t.Fatalf("expected Admin to stay Admin, got %s", admin.Role)
+	}
+
+	entries, err := svc.Log(ctx, user.ID, 10)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Changes[0].To != "Member" {
+		t.Fatalf("expected two logged syncs, the latest back to Member, got %+v", entries)
+	}
+}
+
+func TestRoleMapping_KeepsLastAndProtectedAdmins(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	ctx := context.Background()
+	rules, _ := rolemap.ParseRules([]byte(`[{"claim": "groups", "value": "it-admins", "role": "Admin"}]`))
+	svc := &rolemap.Service{DB: db, Users: uRepo, Rules: rules}
+	admins := map[string]any{"groups": []any{"it-admins"}}
+
+	// The only admin holds the role through a claim alone.
+	only := seedUser(t, uRepo, "rm-only@x.com", "auth0|rm-only", "Member")
+	if _, err := svc.Apply(ctx, only, admins); err != nil || only.Role != "Admin" {
+		t.Fatalf("expected the claim to grant Admin, got %s %v", only.Role, err)
+	}
+	if _, err := svc.Apply(ctx, only, nil); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	stored, _ := uRepo.GetByID(ctx, only.ID)
+	if stored.Role != "Admin" || stored.ClaimRole != "" {
+		t.Fatalf("expected the last admin to keep Admin without the claim, got %+v", stored)
+	}
+
+	// A protected account keeps its role once another admin exists too.
+	seedUser(t, uRepo, "rm-other@x.com", "auth0|rm-other", "Admin")
+	if err := uRepo.SetProtected(ctx, only.OIDCID, true); err != nil {
+		t.Fatalf("protect: %v", err)
+	}
+	if _, err := svc.Apply(ctx, only, nil); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if only.Role != "Admin" {
+		t.Fatalf("expected a protected admin to keep Admin, got %s", only.Role)
+	}
+
+	// Unprotected, the lost claim finally takes effect.
+	if err := uRepo.SetProtected(ctx, only.OIDCID, false); err != nil {
+		t.Fatalf("unprotect: %v", err)
+	}
+	if _, err := svc.Apply(ctx, only, nil); err != nil || only.Role != "Member" {
+		t.Fatalf("expected Member after losing the claim, got %s %v", only.Role, err)
+	}
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/background"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/events"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/search"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+// nextWeekday returns the next given weekday at hour:00 UTC.
+func nextWeekday(day time.Weekday, hour int) time.Time {
+	t := time.Now().UTC().Truncate(24 * time.Hour).Add(time.Duration(hour) * time.Hour)
+	for t.Weekday() != day || t.Before(time.Now()) {
+		t = t.Add(24 * time.Hour)
+	}
+	return t
+}
+
+func TestHashEmbedder_RelatedTextIsCloser(t *testing.T) {
+	idx := &search.Index{Embedder: &search.HashEmbedder{Dims: 256}, Location: time.UTC}
+	ctx := context.Background()
+	texts := []string{
+		"something chill with music on Friday",
+		idx.EventText("Jazz Lounge", "Live acoustic sets and cozy seating.", "Social", "Student Union", nil, nextWeekday(time.Friday, 19)),
+		idx.EventText("Resume Workshop", "Get feedback on your CV from recruiters.", "Career", "Library", nil, nextWeekday(time.Monday, 9)),
+	}
+	vecs, _ := idx.Embedder.Embed(ctx, texts)
+	again, _ := idx.Embedder.Embed(ctx, texts[:1])
+	if search.Cosine(vecs[0], again[0]) < 0.9999 {
+		t.Fatalf("expected the hash embedder to be deterministic")
+	}
+	jazz, resume := search.Cosine(vecs[0], vecs[1]), search.Cosine(vecs[0], vecs[2])
+	if jazz <= resume || jazz < 0.2 {
+		t.Fatalf("expected the jazz night to be closer (%.2f) than the workshop (%.2f)", jazz, resume)
+	}
+}
+
+func TestSemanticSearch_IndexAndHybridFilters(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	org := seedUser(t, uRepo, "org-search@x.com", "auth0|org-search", "Organizer")
+	ctx := context.Background()
+
+	create := func(title, desc, category, location string, start time.Time) *store.Event {
+		ev := &store.Event{
+			Title: title, Description: desc, Category: category, Location: location,
+			StartTime: start, EndTime: start.Add(2 * time.Hour), Capacity: 50,
+			OrganizerID: org.ID, Status: "UPCOMING", Visibility: "PUBLIC",
+		}
+		if err := eRepo.Create(ctx, ev); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+		return ev
+	}
+	jazz := create("Jazz Lounge", "Live acoustic sets and cozy seating.", "Social", "Student Union", nextWeekday(time.Friday, 19))
+	create("Open Mic Night", "Sing or play a song.", "Social", "North Campus", nextWeekday(time.Friday, 20))
+	resume := create("Resume Workshop", "Get feedback on your CV from recruiters.", "Career", "Library", nextWeekday(time.Monday, 9))
+
+	index := &search.Index{DB: db, Embedder: &search.HashEmbedder{Dims: 256}, Location: time.UTC}
+	indexer := background.NewEmbeddingIndexer(index)
+	if n := indexer.Drain(); n != 3 {
+		t.Fatalf("expected 3 events indexed, got %d", n)
+	}
+	if n := indexer.Test_IndexBatch(); n != 0 {
+		t.Fatalf("expected nothing left to index, got %d", n)
+	}
+
+	h := &events.Handler{Repo: eRepo, UserRepo: uRepo, Search: &search.Service{Repo: eRepo, Index: index}}
+	list := func(params url.Values) []search.Result {
+		rr := httptest.NewRecorder()
+		h.HandleListEvents(rr, httptest.NewRequest(http.MethodGet, "/api/events?"+params.Encode(), nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d %s", rr.Code, rr.Body.String())
+		}
+		var got []search.Result
+		json.NewDecoder(rr.Body).Decode(&got)
+		return got
+	}
+
+	got := list(url.Values{"q": {"something chill with music on Friday"}, "mode": {"hybrid"}})
+	if len(got) == 0 || got[0].ID != jazz.ID {
+		t.Fatalf("expected the jazz lounge first, got %+v", got)
+	}
+	for _, r := range got {
+		if r.ID == resume.ID {
+			t.Fatalf("expected the resume workshop not to match")
+		}
+	}
+
+	got = list(url.Values{"q": {"something chill with music on Friday"}, "mode": {"semantic"}, "location": {"North Campus"}})
+	if len(got) != 1 || got[0].Title != "Open Mic Night" {
+		t.Fatalf("expected the location filter to apply, got %+v", got)
+	}
+
+	// Editing an event re-indexes it.
+	jazz.Description = "Live jazz trio."
+	if err := eRepo.Update(ctx, jazz); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if n := indexer.Drain(); n != 1 {
+		t.Fatalf("expected the edited event to be re-indexed, got %d", n)
+	}
+
+	rr := httptest.NewRecorder()
+	h.HandleListEvents(rr, httptest.NewRequest(http.MethodGet, "/api/events?q=jazz&mode=fuzzy", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown mode, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/ai"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/background"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/sentiment"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+)
+
+func TestSentimentLexicon(t *testing.T) {
+	cases := map[string]string{
+		"Great speakers, I loved it!":         sentiment.Positive,
+		"Boring and way too crowded.":         sentiment.Negative,
+		"The talk wasn't good.":               sentiment.Negative,
+		"Not bad at all":                      sentiment.Positive,
+		"It was on Tuesday.":                  sentiment.Neutral,
+		"Good food but the room was terrible": sentiment.Neutral,
+	}
+	for text, want := range cases {
+		if got := sentiment.Lexicon(text); got != want {
+			t.Errorf("Lexicon(%q) = %s, want %s", text, got, want)
+		}
+	}
+}
+
+func TestSentimentScorer_QueueRetryAndBackfill(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	org := seedUser(t, uRepo, "org-sent@x.com", "auth0|org-sent", "Organizer")
+	a := seedUser(t, uRepo, "sent-a@x.com", "auth0|sent-a", "Member")
+	b := seedUser(t, uRepo, "sent-b@x.com", "auth0|sent-b", "Member")
+	ev := seedEvent(t, eRepo, org.ID, "Jazz Evening", "PUBLIC")
+	ctx := context.Background()
+
+	fa := &store.Feedback{EventID: ev.ID, UserID: a.ID, Rating: 5, Comment: "Loved the band"}
+	if err := eRepo.AddFeedback(ctx, fa); err != nil {
+		t.Fatalf("add feedback: %v", err)
+	}
+	fb := &store.Feedback{EventID: ev.ID, UserID: b.ID, Rating: 1, Comment: "Terrible sound", Sentiment: "NEGATIVE"}
+	if err := eRepo.AddFeedback(ctx, fb); err != nil {
+		t.Fatalf("add feedback: %v", err)
+	}
+
+	read := func(id int64) (label, status, source string) {
+		var src *string
+		db.QueryRow(`SELECT sentiment, sentiment_status, sentiment_source FROM event_feedback WHERE id=$1`, id).Scan(&label, &status, &src)
+		if src != nil {
+			source = *src
+		}
+		return
+	}
+	if label, status, _ := read(fa.ID); label != "NEUTRAL" || status != "PENDING" {
+		t.Fatalf("expected queued feedback, got %s/%s", label, status)
+	}
+	if label, status, _ := read(fb.ID); label != "NEGATIVE" || status != "DONE" {
+		t.Fatalf("expected a provided sentiment to be saved, got %s/%s", label, status)
+	}
+
+	// The model fails: the row is retried, then labelled by the lexicon.
+	failing := &ai.MockProvider{Failures: []error{&ai.StatusError{Code: 400}, &ai.StatusError{Code: 400}}}
+	scorer := background.NewSentimentScorer(db, &sentiment.Scorer{AI: ai.NewServiceWithProvider(failing)})
+	scorer.MaxAttempts = 2
+	scorer.Test_ScoreBatch()
+	if _, status, _ := read(fa.ID); status != "PENDING" {
+		t.Fatalf("expected a retry after the first failure, got %s", status)
+	}
+	db.Exec(`UPDATE event_feedback SET sentiment_next_attempt_at = NOW() WHERE id=$1`, fa.ID)
+	scorer.Test_ScoreBatch()
+	if label, status, source := read(fa.ID); label != "POSITIVE" || status != "DONE" || source != sentiment.SourceLexicon {
+		t.Fatalf("expected lexicon fallback, got %s/%s/%s", label, status, source)
+	}
+
+	// Once a model works, the backfill re-scores only what it did not label.
+	model := &ai.MockProvider{Reply: func(ai.CompletionRequest) string { return "NEGATIVE" }}
+	scorer.Scorer = &sentiment.Scorer{AI: ai.NewServiceWithProvider(model)}
+	if n, err := sentiment.Requeue(ctx, db, ev.ID, false); err != nil || n != 2 {
+		t.Fatalf("expected 2 rows requeued, got %d (%v)", n, err)
+	}
+	if n := scorer.Drain(); n != 2 {
+		t.Fatalf("expected 2 rows scored, got %d", n)
+	}
+	if label, _, source := read(fa.ID); label != "NEGATIVE" || source != sentiment.SourceAI {
+		t.Fatalf("expected model label, got %s/%s", label, source)
+	}
+	if n, _ := sentiment.Requeue(ctx, db, ev.ID, false); n != 0 {
+		t.Fatalf("expected model labels to be kept, got %d requeued", n)
+	}
+	if n, _ := sentiment.Requeue(ctx, db, ev.ID, true); n != 2 {
+		t.Fatalf("expected -all to requeue everything, got %d", n)
+	}
+}
@@ -92,6 +92,35 @@ func truncateAll(t *testing.T, db *sql.DB) error {
 
 	// Drop everything that migrations create (idempotent)
 	drops := []string{
+		"DROP TABLE IF EXISTS identity_sync_log CASCADE",
+		"DROP TABLE IF EXISTS friendships CASCADE",
+		"DROP TABLE IF EXISTS organization_follows CASCADE",
+		"DROP TABLE IF EXISTS organizer_follows CASCADE",
+		"DROP TABLE IF EXISTS organization_transfers CASCADE",
+		"DROP TABLE IF EXISTS organization_members CASCADE",
+		"DROP TABLE IF EXISTS organizations CASCADE",
+		"DROP TABLE IF EXISTS event_links CASCADE",
+		"DROP TABLE IF EXISTS event_embeddings CASCADE",
+		"DROP TABLE IF EXISTS moderation_actions CASCADE",
+		"DROP TABLE IF EXISTS moderation_bans CASCADE",
+		"DROP TABLE IF EXISTS content_reports CASCADE",
+		"DROP TABLE IF EXISTS event_photos CASCADE",
+		"DROP TABLE IF EXISTS comments CASCADE",
+		"DROP TABLE IF EXISTS feedback_summaries CASCADE",
+		"DROP TABLE IF EXISTS ai_usage CASCADE",
+		"DROP TABLE IF EXISTS chat_messages CASCADE",
+		"DROP TABLE IF EXISTS chat_sessions CASCADE",
+		"DROP TABLE IF EXISTS announcement_recipients CASCADE",
+		"DROP TABLE IF EXISTS announcements CASCADE",
+		"DROP TABLE IF EXISTS webhook_deliveries CASCADE",
+		"DROP TABLE IF EXISTS webhooks CASCADE",
+		"DROP TABLE IF EXISTS stream_events CASCADE",
+		"DROP TABLE IF EXISTS digest_runs CASCADE",
+		"DROP TABLE IF EXISTS digest_items CASCADE",
+		"DROP TABLE IF EXISTS notification_preferences CASCADE",
+		"DROP TABLE IF EXISTS event_reminders_sent CASCADE",
+		"DROP TABLE IF EXISTS notification_templates CASCADE",
+		"DROP TABLE IF EXISTS email_outbox CASCADE",
 		"DROP TABLE IF EXISTS notifications CASCADE",
 		"DROP TABLE IF EXISTS event_feedback CASCADE",
 		"DROP TABLE IF EXISTS invitations CASCADE",
@@ -2,7 +2,10 @@ package tests
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,13 +14,15 @@ import (
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/users"
 )
 
-func TestHandleUpdateRole_CannotModifySuperAdmin(t *testing.T) {
+func TestHandleUpdateRole_CannotModifyProtectedAccount(t *testing.T) {
 	db := setupTestDB(t)
 	r := store.NewUserRepository(db)
-	h := &users.Handler{Repo: r}
+	h := &users.Handler{Repo: r, ProtectedSubjects: []string{"auth0|super"}}
 
 	admin := seedUser(t, r, "admin@x.com", "auth0|admin", "Admin")
-	super := seedUser(t, r, "devanshukejriwal24@gmail.com", "auth0|super", "Member")
+	super := seedUser(t, r, "owner@x.com", "auth0|super", "Member")
+	// Only the subject protects an account; a matching email does not.
+	lookalike := seedUser(t, r, "auth0|super@x.com", "auth0|lookalike", "Member")
 
 	body, _ := json.Marshal(map[string]interface{}{
 		"user_id": super.ID,
@@ -33,15 +38,25 @@ func TestHandleUpdateRole_CannotModifySuperAdmin(t *testing.T) {
 	if w.Code != http.StatusForbidden {
 		t.Fatalf("expected 403, got %d", w.Code)
 	}
+
+	body, _ = json.Marshal(map[string]interface{}{"user_id": lookalike.ID, "role": "Organizer"})
+	w = httptest.NewRecorder()
+	h.HandleUpdateRole(w, injectClaims(httptest.NewRequest("POST", "/update-role", bytes.NewBuffer(body)), admin.OIDCID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected an unprotected account to be changed, got %d %s", w.Code, w.Body.String())
+	}
 }
 
-func TestHandleToggleActive_CannotModifySuperAdmin(t *testing.T) {
+func TestHandleToggleActive_CannotModifyProtectedAccount(t *testing.T) {
 	db := setupTestDB(t)
 	r := store.NewUserRepository(db)
 	h := &users.Handler{Repo: r}
 
 	admin := seedUser(t, r, "admin@x.com", "auth0|admin", "Admin")
-	super := seedUser(t, r, "devanshukejriwal24@gmail.com", "auth0|super", "Member")
+	super := seedUser(t, r, "owner@x.com", "auth0|super", "Member")
+	if err := r.SetProtected(context.Background(), super.OIDCID, true); err != nil {
+		t.Fatalf("protect: %v", err)
+	}
 
 	body, _ := json.Marshal(map[string]interface{}{
 		"user_id":   super.ID,
@@ -58,3 +73,104 @@ func TestHandleToggleActive_CannotModifySuperAdmin(t *testing.T) {
 		t.Fatalf("expected 403, got %d", w.Code)
 	}
 }
+
+func TestAdminProtections_LastAdminAndSelfDemotion(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	r := store.NewUserRepository(db)
+	h := &users.Handler{Repo: r}
+	ctx := context.Background()
+
+	admin := seedUser(t, r, "admin@x.com", "auth0|admin", "Admin")
+	other := seedUser(t, r, "other-admin@x.com", "auth0|other-admin", "Admin")
+
+	send := func(fn http.HandlerFunc, sub string, body map[string]any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		fn(w, injectClaims(httptest.NewRequest(http.MethodPatch, "/admin/users", bytes.NewReader(b)), sub))
+		return w
+	}
+
+	// Demoting yourself needs confirmation.
+	w := send(h.HandleUpdateRole, admin.OIDCID, map[string]any{"user_id": admin.ID, "role": "Member"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected self-demotion to need confirmation, got %d", w.Code)
+	}
+	if u, _ := r.GetByID(ctx, admin.ID); u.Role != "Admin" {
+		t.Fatalf("expected the role to be unchanged, got %s", u.Role)
+	}
+
+	// Deactivating the other admin leaves one, which is allowed.
+	if w := send(h.HandleToggleActive, admin.OIDCID, map[string]any{"user_id": other.ID, "is_active": false}); w.Code != http.StatusOK {
+		t.Fatalf("expected to deactivate the other admin, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Now the caller is the last active admin: confirming does not help.
+	w = send(h.HandleUpdateRole, admin.OIDCID, map[string]any{"user_id": admin.ID, "role": "Member", "confirm": true})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected the last admin not to be demoted, got %d", w.Code)
+	}
+	if err := r.ToggleActive(ctx, admin.ID, false); !errors.Is(err, store.ErrLastAdmin) {
+		t.Fatalf("expected ErrLastAdmin when deactivating the last admin, got %v", err)
+	}
+
+	// With the other admin back, a confirmed self-demotion goes through.
+	if err := r.ToggleActive(ctx, other.ID, true); err != nil {
+		t.Fatalf("reactivate: %v", err)
+	}
+	if w := send(h.HandleUpdateRole, admin.OIDCID, map[string]any{"user_id": admin.ID, "role": "Organizer", "confirm": true}); w.Code != http.StatusOK {
+		t.Fatalf("expected a confirmed self-demotion, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUserRepository_RestoreAdmin(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	r := store.NewUserRepository(db)
+	ctx := context.Background()
+
+	owner := seedUser(t, r, "owner@x.com", "auth0|owner", "Member")
+	if err := r.ToggleActive(ctx, owner.ID, false); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+
+	if _, err := r.RestoreAdmin(ctx, owner.OIDCID); err != nil {
+		t.Fatalf("RestoreAdmin(): %v", err)
+	}
+	u, _ := r.GetByID(ctx, owner.ID)
+	if u.Role != "Admin" || u.ManualRole != "Admin" || !u.IsActive || !u.IsProtected {
+		t.Fatalf("expected an active, protected admin, got %+v", u)
+	}
+	if _, err := r.RestoreAdmin(ctx, "owner@x.com"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected ErrNoRows for an email instead of a subject, got %v", err)
+	}
+}
+
+func TestProtectedSubjects_StoredAtStartupAndOnSync(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	r := store.NewUserRepository(db)
+	ctx := context.Background()
+
+	early := seedUser(t, r, "early@x.com", "auth0|early", "Admin")
+	late := seedUser(t, r, "late@x.com", "auth0|late", "Admin")
+
+	// At startup, accounts that already exist are flagged.
+	if n, err := r.ProtectSubjects(ctx, []string{early.OIDCID, "auth0|not-yet"}); err != nil || n != 1 {
+		t.Fatalf("expected one account protected, got %d %v", n, err)
+	}
+	if u, _ := r.GetByID(ctx, early.ID); !u.IsProtected {
+		t.Fatalf("expected %s to be protected", u.Email)
+	}
+
+	// Accounts configured later are flagged on their next sync.
+	h := &users.Handler{Repo: r, ProtectedSubjects: []string{late.OIDCID}}
+	w := httptest.NewRecorder()
+	h.HandleSyncUser(w, injectClaims(httptest.NewRequest(http.MethodPost, "/users/sync", nil), late.OIDCID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("sync: %d %s", w.Code, w.Body.String())
+	}
+	if u, _ := r.GetByID(ctx, late.ID); !u.IsProtected {
+		t.Fatalf("expected %s to be protected after sync", u.Email)
+	}
+}
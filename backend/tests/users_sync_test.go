@@ -2,6 +2,7 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -11,40 +12,38 @@ import (
 	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/users"
 )
 
-func TestHandleSyncUser_InvalidJSON(t *testing.T) {
+func TestHandleSyncUser_IgnoresBodyEmail(t *testing.T) {
 	db := setupTestDB(t)
 	repo := store.NewUserRepository(db)
 	h := &users.Handler{Repo: repo}
 
-	req := httptest.NewRequest("POST", "/sync", bytes.NewBuffer([]byte("{bad json")))
+	body, _ := json.Marshal(map[string]interface{}{"email": "dean@campus.edu"})
+	req := httptest.NewRequest("POST", "/sync", bytes.NewBuffer(body))
 	req = injectClaims(req, "auth0|abc")
 	w := httptest.NewRecorder()
 
 	h.HandleSyncUser(w, req)
-	if w.Code != http.StatusBadRequest {
-		t.Fatalf("expected 400, got %d", w.Code)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without an email in the token, got %d", w.Code)
+	}
+	if _, err := repo.GetByEmail(context.Background(), "dean@campus.edu"); err == nil {
+		t.Fatalf("expected no user to be created from the body email")
 	}
 }
 
-func TestHandleSyncUser_MissingFields(t *testing.T) {
+func TestHandleSyncUser_RequiresVerifiedEmail(t *testing.T) {
 	db := setupTestDB(t)
 	repo := store.NewUserRepository(db)
 	h := &users.Handler{Repo: repo}
 
-	body, _ := json.Marshal(map[string]interface{}{
-		"name": "Only Name",
-		// missing: email, oidc_id
-	})
-
-	req := httptest.NewRequest("POST", "/sync", bytes.NewBuffer(body))
-	req = injectClaims(req, "auth0|abc")
+	req := httptest.NewRequest("POST", "/sync", nil)
+	req = injectTokenClaims(req, "auth0|abc", map[string]any{"email": "dean@campus.edu", "email_verified": false})
 	w := httptest.NewRecorder()
 
 	h.HandleSyncUser(w, req)
 
-	// TODO : Should this be a 400?
-	if w.Code != http.StatusOK {
-		t.Fatalf("expected 200 (backend does not enforce required fields), got %d", w.Code)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for an unverified email, got %d", w.Code)
 	}
 }
 
@@ -53,14 +52,11 @@ func TestHandleSyncUser_CreatesNewUser(t *testing.T) {
 	repo := store.NewUserRepository(db)
 	h := &users.Handler{Repo: repo}
 
-	body, _ := json.Marshal(map[string]interface{}{
-		"email":   "jane@x.com",
-		"oidc_id": "auth0|jane1",
-		"role":    "Member",
+	req := httptest.NewRequest("POST", "/sync", nil)
+	req = injectTokenClaims(req, "auth0|jane1", map[string]any{
+		"https://campussync/email":          "jane@x.com",
+		"https://campussync/email_verified": true,
 	})
-
-	req := httptest.NewRequest("POST", "/sync", bytes.NewBuffer(body))
-	req = injectClaims(req, "auth0|jane1")
 	w := httptest.NewRecorder()
 
 	h.HandleSyncUser(w, req)
@@ -76,6 +72,9 @@ func TestHandleSyncUser_CreatesNewUser(t *testing.T) {
 	if err != nil {
 		t.Fatalf("user not created: %v", err)
 	}
+	if u.Email != "jane@x.com" {
+		t.Fatalf("expected the email from the token, got %s", u.Email)
+	}
 
 	if u.Role != "Member" {
 		t.Fatalf("expected role Member, got %s", u.Role)
@@ -0,0 +1,242 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/background"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/notifications"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/registration"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/store"
+	"github.com/DEVANSHUKEJRIWAL/CampusSync/internal/webhooks"
+)
+
+// receiver is a local endpoint that verifies signatures and records what it got.
+type receiver struct {
+	mu     sync.Mutex
+	secret string
+	fail   bool
+	got    []webhooks.Envelope
+	errs   []error
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	if err := webhooks.Verify(rc.secret, r.Header.Get(webhooks.SignatureHeader), body, 5*time.Minute); err != nil {
+		rc.errs = append(rc.errs, err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if rc.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var env webhooks.Envelope
+	json.Unmarshal(body, &env)
+	rc.got = append(rc.got, env)
+}
+
+func TestWebhookSignature_Verify(t *testing.T) {
+	body := []byte(`{"type":"ping"}`)
+	now := time.Now().Unix()
+	header := webhooks.Sign("whsec_test", now, body)
+
+	if err := webhooks.Verify("whsec_test", header, body, time.Minute); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	if err := webhooks.Verify("whsec_other", header, body, time.Minute); err == nil {
+		t.Fatalf("expected wrong secret to fail")
+	}
+	if err := webhooks.Verify("whsec_test", header, []byte(`{"type":"pong"}`), time.Minute); err == nil {
+		t.Fatalf("expected modified body to fail")
+	}
+	old := webhooks.Sign("whsec_test", now-3600, body)
+	if err := webhooks.Verify("whsec_test", old, body, time.Minute); err == nil {
+		t.Fatalf("expected stale timestamp to fail")
+	}
+}
+
+func TestWebhookDeliver_LocalReceiver(t *testing.T) {
+	rc := &receiver{secret: "whsec_local"}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	code, err := webhooks.Deliver(context.Background(), srv.Client(), srv.URL, "whsec_local", 1, webhooks.Ping, []byte(`{"id":"abc","type":"ping"}`))
+	if err != nil || code != http.StatusOK || len(rc.got) != 1 {
+		t.Fatalf("expected successful delivery, got code=%d err=%v received=%d", code, err, len(rc.got))
+	}
+
+	rc.fail = true
+	code, err = webhooks.Deliver(context.Background(), srv.Client(), srv.URL, "whsec_local", 2, webhooks.Ping, []byte(`{}`))
+	if err == nil || code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 to be reported as failure, got code=%d err=%v", code, err)
+	}
+}
+
+func TestWebhooks_RejectLocalEndpoints(t *testing.T) {
+	hooks := &webhooks.Service{}
+	for _, u := range []string{
+		"http://example.com/hook",
+		"https://127.0.0.1/hook",
+		"https://localhost:8443/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://10.0.0.5/hook",
+		"https://192.168.1.20/hook",
+		"https://[::1]/hook",
+		"https://0.0.0.0/hook",
+	} {
+		if _, err := hooks.Create(context.Background(), 1, u, []string{webhooks.EventCreated}); err == nil {
+			t.Errorf("expected %s to be rejected", u)
+		}
+	}
+
+	// The delivery client re-checks the resolved address when it dials.
+	srv := httptest.NewServer(&receiver{})
+	defer srv.Close()
+	_, err := webhooks.Deliver(context.Background(), webhooks.NewHTTPClient(false), srv.URL, "whsec_local", 1, webhooks.Ping, []byte(`{}`))
+	if !errors.Is(err, webhooks.ErrLocalAddress) {
+		t.Fatalf("expected the dispatcher client to refuse a loopback endpoint, got %v", err)
+	}
+}
+
+func TestWebhooks_EndToEnd(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	hooks := webhooks.NewService(db)
+	hooks.AllowLocal = true // the receiver runs on loopback
+	svc := &registration.Service{DB: db, Notifications: notifications.NewService(db), Webhooks: hooks}
+	h := &webhooks.Handler{Service: hooks, UserRepo: uRepo}
+
+	org := seedUser(t, uRepo, "org-hook@x.com", "auth0|org-hook", "Organizer")
+	otherOrg := seedUser(t, uRepo, "org-hook2@x.com", "auth0|org-hook2", "Organizer")
+	member := seedUser(t, uRepo, "hook-member@x.com", "auth0|hook-member", "Member")
+	mine := seedEvent(t, eRepo, org.ID, "Hooked Event", "PUBLIC")
+	theirs := seedEvent(t, eRepo, otherOrg.ID, "Other Event", "PUBLIC")
+
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	body, _ := json.Marshal(map[string]any{"url": srv.URL, "event_types": []string{webhooks.RegistrationCreated}})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+	req = injectClaims(req, org.OIDCID)
+	w := httptest.NewRecorder()
+	h.HandleCreateWebhook(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var hook webhooks.Webhook
+	json.Unmarshal(w.Body.Bytes(), &hook)
+	rc.secret = hook.Secret
+
+	// Members cannot register webhooks.
+	req = httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+	req = injectClaims(req, member.OIDCID)
+	w = httptest.NewRecorder()
+	h.HandleCreateWebhook(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for member, got %d", w.Code)
+	}
+
+	if _, err := svc.RegisterUserForEvent(ctx, member.ID, mine.ID); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := svc.RegisterUserForEvent(ctx, member.ID, theirs.ID); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	dispatcher := background.NewWebhookDispatcher(db)
+	dispatcher.Client = srv.Client()
+	dispatcher.Test_DispatchBatch()
+
+	if len(rc.errs) != 0 {
+		t.Fatalf("receiver rejected signatures: %v", rc.errs)
+	}
+	if len(rc.got) != 1 || rc.got[0].Type != webhooks.RegistrationCreated {
+		t.Fatalf("expected exactly one registration.created for the organizer's own event, got %+v", rc.got)
+	}
+	data, _ := json.Marshal(rc.got[0].Data)
+	var payload webhooks.RegistrationPayload
+	json.Unmarshal(data, &payload)
+	if payload.EventID != mine.ID || payload.UserEmail != member.Email {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+
+	// A failing endpoint is retried later, and can be redelivered by hand.
+	deliveries, _ := hooks.Deliveries(ctx, hook.ID)
+	if len(deliveries) != 1 || deliveries[0].Status != "DELIVERED" {
+		t.Fatalf("expected one delivered entry in the log, got %+v", deliveries)
+	}
+
+	rc.fail = true
+	newID, err := hooks.Redeliver(ctx, deliveries[0].ID)
+	if err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	dispatcher.Test_DispatchBatch()
+
+	var status string
+	var attempts, code int
+	db.QueryRow(`SELECT status, attempts, last_status_code FROM webhook_deliveries WHERE id=$1`, newID).Scan(&status, &attempts, &code)
+	if status != "PENDING" || attempts != 1 || code != http.StatusInternalServerError {
+		t.Fatalf("expected a scheduled retry after a 500, got status=%s attempts=%d code=%d", status, attempts, code)
+	}
+}
+
+func TestWebhooks_EmitReachesOrganizationOfficersAndAdmins(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	uRepo := store.NewUserRepository(db)
+	eRepo := store.NewEventRepository(db)
+	orgRepo := store.NewOrganizationRepository(db)
+	hooks := webhooks.NewService(db)
+	hooks.AllowLocal = true
+
+	owner := seedUser(t, uRepo, "hookorg-owner@x.com", "auth0|hookorg-owner", "Organizer")
+	officer := seedUser(t, uRepo, "hookorg-officer@x.com", "auth0|hookorg-officer", "Organizer")
+	outsider := seedUser(t, uRepo, "hookorg-outsider@x.com", "auth0|hookorg-outsider", "Organizer")
+	admin := seedUser(t, uRepo, "hookorg-admin@x.com", "auth0|hookorg-admin", "Admin")
+	club := &store.Organization{Name: "Robotics Club"}
+	if err := orgRepo.Create(ctx, club, owner.ID); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	orgRepo.SetMember(ctx, club.ID, officer.ID, store.OrgOfficer)
+	ev := seedEvent(t, eRepo, owner.ID, "Robot Wars", "PUBLIC")
+	db.Exec(`UPDATE events SET organization_id = $1 WHERE id = $2`, club.ID, ev.ID)
+
+	ids := map[int64]*webhooks.Webhook{}
+	for _, u := range []*store.User{officer, outsider, admin} {
+		hook, err := hooks.Create(ctx, u.ID, "http://127.0.0.1:9/hook", []string{webhooks.EventUpdated})
+		if err != nil {
+			t.Fatalf("create webhook: %v", err)
+		}
+		ids[u.ID] = hook
+	}
+
+	if err := hooks.Emit(ctx, nil, webhooks.EventUpdated, ev.ID, map[string]any{"id": ev.ID}); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+	for u, want := range map[*store.User]int{officer: 1, outsider: 0, admin: 1} {
+		var n int
+		db.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id=$1`, ids[u.ID].ID).Scan(&n)
+		if n != want {
+			t.Errorf("%s: expected %d deliveries, got %d", u.Email, want, n)
+		}
+	}
+}
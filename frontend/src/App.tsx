@@ -23,8 +23,7 @@ function App() {
             const t = await getAccessTokenSilently();
             const response = await fetch(`${API_URL}/api/users/sync`, {
                 method: "POST",
-                headers: { "Content-Type": "application/json", "Authorization": `Bearer ${t}` },
-                body: JSON.stringify({ email: user?.email })
+                headers: { "Authorization": `Bearer ${t}` }
             });
             if (!response.ok) throw new Error("Failed to sync");
             const data = await response.json();
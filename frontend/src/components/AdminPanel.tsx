@@ -10,6 +10,7 @@ interface User {
     role: string;
     created_at: string;
     is_active: boolean;
+    is_protected: boolean;
 }
 
 interface EventAnalytics {
@@ -30,7 +31,6 @@ export default function AdminPanel() {
     const [currentUserRole, setCurrentUserRole] = useState("");
     const [confirmModal, setConfirmModal] = useState<{id: number, active: boolean} | null>(null);
 
-    const SUPER_ADMIN_EMAIL = "devanshukejriwal24@gmail.com";
     const API_URL = import.meta.env.VITE_API_URL || "http://localhost:8080";
 
     useEffect(() => {
@@ -68,14 +68,21 @@ export default function AdminPanel() {
         } catch (e) { console.error(e); }
     };
 
-    const updateRole = async (userId: number, newRole: string) => {
+    const updateRole = async (userId: number, newRole: string, confirmed = false) => {
         try {
             const token = await getAccessTokenSilently();
             const res = await fetch(`${API_URL}/api/admin/users/role`, {
                 method: "PATCH",
                 headers: { Authorization: `Bearer ${token}`, "Content-Type": "application/json" },
-                body: JSON.stringify({ user_id: userId, role: newRole })
+                body: JSON.stringify({ user_id: userId, role: newRole, confirm: confirmed })
             });
+            if (res.status === 409) {
+                const data = await res.json();
+                if (data.confirm_required && confirm(data.message)) return updateRole(userId, newRole, true);
+                if (!data.confirm_required) showToast(data.message, "error");
+                fetchUsers();
+                return;
+            }
             if (!res.ok) throw new Error("Failed");
             showToast("Role Updated!", "success");
             fetchUsers();
@@ -95,6 +102,11 @@ export default function AdminPanel() {
                 headers: { Authorization: `Bearer ${token}`, "Content-Type": "application/json" },
                 body: JSON.stringify({ user_id: confirmModal.id, is_active: confirmModal.active })
             });
+            if (res.status === 409) {
+                const data = await res.json();
+                showToast(data.message, "error");
+                return;
+            }
             if (!res.ok) throw new Error("Failed");
             showToast(`User ${confirmModal.active ? 'Activated' : 'Deactivated'}!`, "success");
             fetchUsers();
@@ -169,7 +181,7 @@ export default function AdminPanel() {
                                     <select
                                         value={u.role}
                                         onChange={(e) => updateRole(u.id, e.target.value)}
-                                        disabled={!canEdit || u.email === SUPER_ADMIN_EMAIL}
+                                        disabled={!canEdit || u.is_protected}
                                         style={{ padding: '5px', borderRadius: '4px', border: '1px solid #ccc' }}
                                     >
                                         <option value="Member">Member</option>
@@ -179,7 +191,7 @@ export default function AdminPanel() {
 
                                     <button
                                         onClick={() => initiateToggle(u.id, !u.is_active)}
-                                        disabled={!canEdit || u.email === SUPER_ADMIN_EMAIL}
+                                        disabled={!canEdit || u.is_protected}
                                         className={`btn btn-sm ${u.is_active ? 'btn-danger' : 'btn-success'}`}
                                     >
                                         {u.is_active ? 'Disable' : 'Enable'}
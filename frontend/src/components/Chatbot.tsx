@@ -1,4 +1,5 @@
 import { useState } from 'react';
+import { useAuth0 } from '@auth0/auth0-react';
 import {
     MessageCircle as ChatBubbleLeftRightIcon,
     X as XMarkIcon,
@@ -7,13 +8,51 @@ import {
 
 const API_URL = import.meta.env.VITE_API_URL || "http://localhost:8080";
 
+type PendingAction = { type: 'register' | 'cancel_registration', event_id: number, event_title: string };
+
 export default function ChatBot() {
+    const { getAccessTokenSilently, isAuthenticated } = useAuth0();
     const [isOpen, setIsOpen] = useState(false);
-    const [messages, setMessages] = useState<{role: 'user' | 'bot', text: string}[]>([
+    const [messages, setMessages] = useState<{role: 'user' | 'bot', text: string, citations?: {event_id: number, title: string}[]}[]>([
         {role: 'bot', text: 'Hi! I am CampusBot. Ask me about upcoming events!'}
     ]);
     const [input, setInput] = useState("");
     const [loading, setLoading] = useState(false);
+    const [sessionId, setSessionId] = useState("");
+    const [pending, setPending] = useState<PendingAction | null>(null);
+
+    // Anonymous chat only sees public events; signed-in users also get their private ones.
+    const headers = async () => {
+        const h: Record<string, string> = {'Content-Type': 'application/json'};
+        if (isAuthenticated) {
+            try {
+                h['Authorization'] = `Bearer ${await getAccessTokenSilently()}`;
+            } catch { /* fall back to anonymous */ }
+        }
+        return h;
+    };
+
+    const handleConfirm = async (confirm: boolean) => {
+        setPending(null);
+        setLoading(true);
+        try {
+            const res = await fetch(`${API_URL}/api/ai/chat/confirm`, {
+                method: 'POST',
+                headers: await headers(),
+                body: JSON.stringify({ session_id: sessionId, confirm })
+            });
+            const data = await res.json();
+            setMessages(prev => [...prev, {role: 'bot', text: data.message}]);
+        } catch (err) {
+            setMessages(prev => [...prev, {role: 'bot', text: "Sorry, I couldn't do that."}]);
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    // Replaces the text of the bot message being streamed (always the last one).
+    const updateLast = (patch: {text: string, citations?: {event_id: number, title: string}[]}) =>
+        setMessages(prev => [...prev.slice(0, -1), {role: 'bot', ...patch}]);
 
     const handleSend = async (e: React.FormEvent) => {
         e.preventDefault();
@@ -27,11 +66,46 @@ export default function ChatBot() {
         try {
             const res = await fetch(`${API_URL}/api/ai/chat`, {
                 method: 'POST',
-                headers: {'Content-Type': 'application/json'},
-                body: JSON.stringify({ question: userMsg })
+                headers: {...await headers(), 'Accept': 'text/event-stream'},
+                body: JSON.stringify({ question: userMsg, session_id: sessionId })
             });
-            const data = await res.json();
-            setMessages(prev => [...prev, {role: 'bot', text: data.answer}]);
+            if (!res.ok || !res.body) {
+                const data = await res.json().catch(() => ({}));
+                if (res.status === 429) {
+                    setMessages(prev => [...prev, {role: 'bot', text: data.message}]);
+                    return;
+                }
+                // The session may have expired or belong to another login; start over.
+                setSessionId("");
+                throw new Error(data.message);
+            }
+
+            // Answer text arrives as "delta" events; "done" carries the final reply.
+            setMessages(prev => [...prev, {role: 'bot', text: ''}]);
+            const reader = res.body.pipeThrough(new TextDecoderStream()).getReader();
+            let buffer = "", text = "";
+            for (;;) {
+                const { value, done } = await reader.read();
+                if (done) break;
+                buffer += value;
+                let sep;
+                while ((sep = buffer.indexOf("\n\n")) >= 0) {
+                    const frame = buffer.slice(0, sep);
+                    buffer = buffer.slice(sep + 2);
+                    const event = frame.match(/^event: (.*)$/m)?.[1];
+                    const data = JSON.parse(frame.match(/^data: (.*)$/m)?.[1] ?? "null");
+                    if (event === 'delta') {
+                        text += data.text;
+                        updateLast({text});
+                    } else if (event === 'done') {
+                        setSessionId(data.session_id);
+                        setPending(data.pending_action);
+                        updateLast({text: data.answer, citations: data.citations});
+                    } else if (event === 'error') {
+                        throw new Error(data.message);
+                    }
+                }
+            }
         } catch (err) {
             setMessages(prev => [...prev, {role: 'bot', text: "Sorry, I'm having trouble connecting to the campus brain."}]);
         } finally {
@@ -79,8 +153,23 @@ export default function ChatBot() {
                                 maxWidth: '80%'
                             }}>
                                 {m.text}
+                                {m.citations && m.citations.length > 0 && (
+                                    <div style={{fontSize: '11px', color: '#6b7280', marginTop: '4px'}}>
+                                        Sources: {m.citations.map(c => `#${c.event_id} ${c.title}`).join(', ')}
+                                    </div>
+                                )}
                             </div>
                         ))}
+                        {pending && (
+                            <div style={{display: 'flex', gap: '8px'}}>
+                                <button onClick={() => handleConfirm(true)} style={{background: '#4f46e5', color: 'white', border: 'none', borderRadius: '8px', padding: '6px 12px', cursor: 'pointer'}}>
+                                    {pending.type === 'register' ? 'Register' : 'Cancel registration'} for {pending.event_title}
+                                </button>
+                                <button onClick={() => handleConfirm(false)} style={{background: '#f3f4f6', border: 'none', borderRadius: '8px', padding: '6px 12px', cursor: 'pointer'}}>
+                                    No thanks
+                                </button>
+                            </div>
+                        )}
                         {loading && <div style={{color: '#9ca3af', fontSize: '12px'}}>Thinking...</div>}
                     </div>
 
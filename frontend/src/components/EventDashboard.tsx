@@ -57,10 +57,13 @@ interface Event {
     visibility: string;
     category: string;
     registered_count: number;
+    organizer_id?: number;
+    organization_id?: number;
     // New fields
     is_recurring?: boolean;
     custom_fields?: CustomField[];
     ticket_types?: TicketType[];
+    tags?: string[];
 }
 
 interface Notification {
@@ -70,7 +73,7 @@ interface Notification {
     created_at: string;
 }
 
-const CATEGORIES = ["General", "Workshop", "Seminar", "Club Meeting", "Social", "Sports"];
+const CATEGORIES = ["General", "Workshop", "Seminar", "Club Meeting", "Social", "Sports", "Health & Wellness", "Support Group"];
 const LOCATIONS = [
     "Iribe Center",
     "Hornbake Library",
@@ -90,9 +93,13 @@ export default function EventDashboard() {
     const [loading, setLoading] = useState(true);
     const [myEvents, setMyEvents] = useState<any[]>([]);
     const [userRole, setUserRole] = useState("Loading...");
+    const [myUserId, setMyUserId] = useState(0);
+    const [followedOrganizers, setFollowedOrganizers] = useState<number[]>([]);
+    const [friendsGoing, setFriendsGoing] = useState<Record<number, string[]>>({});
     const [notifications, setNotifications] = useState<Notification[]>([]);
     const [leaderboard, setLeaderboard] = useState<any[]>([]);
     const [myBadges, setMyBadges] = useState<any[]>([]);
+    const [myOrgs, setMyOrgs] = useState<any[]>([]); // organizations I can post events for
     const [attendees, setAttendees] = useState<any[]>([]);
 
     // --- COMMUNITY FEATURE STATE ---
@@ -109,6 +116,7 @@ export default function EventDashboard() {
     const [searchQuery, setSearchQuery] = useState("");
     const [searchLocation, setSearchLocation] = useState("");
     const [searchCategory, setSearchCategory] = useState("All");
+    const [smartSearch, setSmartSearch] = useState(false);
 
     // Modal States
     const [selectedEventId, setSelectedEventId] = useState<number | null>(null);
@@ -135,14 +143,19 @@ export default function EventDashboard() {
     const [editingEventId, setEditingEventId] = useState<number | null>(null);
     const [formError, setFormError] = useState("");
     const [formSuccess, setFormSuccess] = useState("");
+    const [duplicates, setDuplicates] = useState<any[]>([]);
     const [formData, setFormData] = useState({
         title: "", description: "", location: "",
         start_time: "", end_time: "",
         capacity: 0, visibility: "PUBLIC", category: "General",
+        organization_id: 0,
         is_recurring: false,           // New
         custom_fields: [] as CustomField[], // New
-        ticket_types: [] as TicketType[]    // New
+        ticket_types: [] as TicketType[],   // New
+        tags: [] as string[]
     });
+    const [draftNotes, setDraftNotes] = useState("");
+    const [drafting, setDrafting] = useState(false);
 
     const canManage = userRole === 'Admin' || userRole === 'Organizer';
 
@@ -156,12 +169,12 @@ export default function EventDashboard() {
             // This endpoint is responsible for Creating the User & assigning "Welcome Badge" in DB
             const res = await fetch(`${API_URL}/api/users/sync`, {
                 method: "POST",
-                headers: {"Content-Type": "application/json", Authorization: `Bearer ${token}`},
-                body: JSON.stringify({email: user.email, name: user.name, picture: user.picture}),
+                headers: {Authorization: `Bearer ${token}`},
             });
             if (res.ok) {
                 const data = await res.json();
                 setUserRole(data.role);
+                setMyUserId(data.id);
                 return true; // ✅ Sync success
             }
         } catch (error) { console.error("Sync failed", error); }
@@ -184,6 +197,58 @@ export default function EventDashboard() {
         } catch (e) { console.error(e); }
     };
 
+    const fetchOrganizations = async () => {
+        try {
+            const token = await getAccessTokenSilently();
+            const res = await fetch(`${API_URL}/api/organizations`, { headers: { Authorization: `Bearer ${token}` } });
+            if (res.ok) {
+                const orgs = await res.json() || [];
+                setMyOrgs(orgs.filter((o: any) => o.my_role === "OWNER" || o.my_role === "OFFICER"));
+            }
+        } catch (e) { console.error(e); }
+    };
+
+    const fetchFollowing = async () => {
+        try {
+            const token = await getAccessTokenSilently();
+            const res = await fetch(`${API_URL}/api/follows`, { headers: { Authorization: `Bearer ${token}` } });
+            if (res.ok) {
+                const list = await res.json() || [];
+                setFollowedOrganizers(list.filter((f: any) => f.kind === "organizer").map((f: any) => f.id));
+            }
+        } catch (e) { console.error(e); }
+    };
+
+    // Followers hear about the organizer's new events.
+    const toggleFollow = async (organizerId: number) => {
+        const following = followedOrganizers.includes(organizerId);
+        try {
+            const token = await getAccessTokenSilently();
+            const res = following
+                ? await fetch(`${API_URL}/api/follows?organizer_id=${organizerId}`, { method: "DELETE", headers: { Authorization: `Bearer ${token}` } })
+                : await fetch(`${API_URL}/api/follows`, {
+                    method: "POST",
+                    headers: {"Content-Type": "application/json", Authorization: `Bearer ${token}`},
+                    body: JSON.stringify({organizer_id: organizerId}),
+                });
+            if (!res.ok) { showToast("Could not update follow.", "error"); return; }
+            setFollowedOrganizers(following ? followedOrganizers.filter(id => id !== organizerId) : [...followedOrganizers, organizerId]);
+            showToast(following ? "Unfollowed organizer." : "Following! You'll hear about their new events.", "success");
+        } catch (e) { showToast("Error.", "error"); }
+    };
+
+    // Friends who registered and let friends see it.
+    const fetchFriendsGoing = async (eventId: number) => {
+        try {
+            const token = await getAccessTokenSilently();
+            const res = await fetch(`${API_URL}/api/events/friends?event_id=${eventId}`, { headers: { Authorization: `Bearer ${token}` } });
+            if (res.ok) {
+                const list = await res.json() || [];
+                setFriendsGoing({...friendsGoing, [eventId]: list.map((f: any) => f.email)});
+            }
+        } catch (e) { console.error(e); }
+    };
+
     const fetchNotifications = async () => {
         try {
             const token = await getAccessTokenSilently();
@@ -208,6 +273,7 @@ export default function EventDashboard() {
             if (query) params.append("q", query);
             if (loc) params.append("location", loc);
             if (cat && cat !== "All") params.append("category", cat);
+            if (query && smartSearch) params.append("mode", "hybrid");
             const res = await fetch(`${API_URL}/api/events?${params.toString()}`, {headers: {Authorization: `Bearer ${token}`}});
             if (res.ok) setEvents(await res.json() || []);
         } catch (error) { console.error(error); } finally { setLoading(false); }
@@ -242,22 +308,38 @@ export default function EventDashboard() {
     const submitComment = async () => {
         if(!communityModalEvent || !newComment) return;
         const token = await getAccessTokenSilently();
-        await fetch(`${API_URL}/api/events/comments`, {
+        const res = await fetch(`${API_URL}/api/events/comments`, {
             method: 'POST',
             headers: {'Content-Type': 'application/json', Authorization: `Bearer ${token}`},
             body: JSON.stringify({event_id: communityModalEvent.id, text: newComment})
         });
+        const data = await res.json().catch(() => ({}));
+        if (!res.ok) { showToast(`⚠️ ${data.message || "Could not post comment"}`, "error"); return; }
+        if (data.status === "PENDING") showToast("Your comment will appear once a moderator approves it.", "success");
         setNewComment("");
         fetchComments(communityModalEvent.id);
     };
+    const reportContent = async (contentType: string, contentId: number) => {
+        const reason = window.prompt("Why are you reporting this?");
+        if (reason === null) return;
+        const token = await getAccessTokenSilently();
+        const res = await fetch(`${API_URL}/api/moderation/reports`, {
+            method: 'POST',
+            headers: {'Content-Type': 'application/json', Authorization: `Bearer ${token}`},
+            body: JSON.stringify({content_type: contentType, content_id: contentId, reason})
+        });
+        showToast(res.ok ? "Thanks, a moderator will review it." : "Could not send report", res.ok ? "success" : "error");
+    };
     const submitPhoto = async () => {
         if(!communityModalEvent || !newPhotoUrl) return;
         const token = await getAccessTokenSilently();
-        await fetch(`${API_URL}/api/events/photos`, {
+        const res = await fetch(`${API_URL}/api/events/photos`, {
             method: 'POST',
             headers: {'Content-Type': 'application/json', Authorization: `Bearer ${token}`},
             body: JSON.stringify({event_id: communityModalEvent.id, url: newPhotoUrl})
         });
+        const data = await res.json().catch(() => ({}));
+        if (!res.ok) { showToast(`⚠️ ${data.message || "Could not add photo"}`, "error"); return; }
         setNewPhotoUrl("");
         fetchPhotos(communityModalEvent.id);
     };
@@ -273,7 +355,9 @@ export default function EventDashboard() {
                 fetchMyEvents(),
                 fetchNotifications(),
                 fetchBadges(),      // Now finds the Welcome Badge
-                fetchLeaderboard()  // Now finds the User in the list
+                fetchLeaderboard(), // Now finds the User in the list
+                fetchOrganizations(),
+                fetchFollowing()
             ]);
         }
     };
@@ -535,9 +619,11 @@ export default function EventDashboard() {
             capacity: evt.capacity,
             visibility: evt.visibility,
             category: evt.category || "General",
+            organization_id: evt.organization_id || 0,
             is_recurring: evt.is_recurring || false,
             custom_fields: evt.custom_fields || [],
-            ticket_types: evt.ticket_types || []
+            ticket_types: evt.ticket_types || [],
+            tags: evt.tags || []
         });
         window.scrollTo({top: 0, behavior: 'smooth'});
     };
@@ -546,13 +632,50 @@ export default function EventDashboard() {
         setEditingEventId(null);
         setFormData({
             title: "", description: "", location: "", start_time: "", end_time: "",
-            capacity: 0, visibility: "PUBLIC", category: "General",
-            is_recurring: false, custom_fields: [], ticket_types: []
+            capacity: 0, visibility: "PUBLIC", category: "General", organization_id: 0,
+            is_recurring: false, custom_fields: [], ticket_types: [], tags: []
         });
     };
 
+    // Fills the form from a few bullet points; the organizer reviews it before saving.
+    const handleDraft = async () => {
+        const notes = draftNotes.split("\n").map(n => n.replace(/^[-*•]\s*/, "").trim()).filter(Boolean);
+        if (notes.length === 0) return;
+        setDrafting(true);
+        setFormError("");
+        try {
+            const token = await getAccessTokenSilently();
+            const res = await fetch(`${API_URL}/api/events/draft`, {
+                method: "POST",
+                headers: {"Content-Type": "application/json", Authorization: `Bearer ${token}`},
+                body: JSON.stringify({notes}),
+            });
+            const data = await res.json();
+            if (!res.ok) throw new Error(data.message || "Draft failed");
+            setFormData(prev => ({
+                ...prev,
+                title: data.title,
+                description: data.description,
+                category: data.category,
+                tags: data.tags,
+                custom_fields: data.custom_fields,
+            }));
+        } catch (error: any) {
+            setFormError(error.message);
+        } finally {
+            setDrafting(false);
+        }
+    };
+
     const handleSubmit = async (e: React.FormEvent) => {
         e.preventDefault();
+        await submitEvent("");
+    };
+
+    // duplicateAction is "" on the first try; the server answers 409 with
+    // probable duplicates until the organizer picks "link" or "ignore".
+    const submitEvent = async (duplicateAction: string) => {
+        setDuplicates([]);
         setFormError("");
         setFormSuccess("");
 
@@ -561,6 +684,7 @@ export default function EventDashboard() {
         if (!formData.start_time || !formData.end_time) { setFormError("Dates are required."); return; }
         if (end <= start) { setFormError("End time must be after start time."); return; }
         if (formData.capacity <= 0) { setFormError("Capacity must be positive."); return; }
+        if (formData.visibility === "MEMBERS" && !formData.organization_id) { setFormError("Members-only events need an organization."); return; }
 
         try {
             const token = await getAccessTokenSilently();
@@ -569,7 +693,8 @@ export default function EventDashboard() {
                 capacity: Number(formData.capacity),
                 start_time: new Date(formData.start_time).toISOString(),
                 end_time: new Date(formData.end_time).toISOString(),
-                id: editingEventId
+                id: editingEventId,
+                duplicate_action: duplicateAction
             };
             const method = editingEventId ? "PUT" : "POST";
             const res = await fetch(`${API_URL}/api/events`, {
@@ -578,6 +703,11 @@ export default function EventDashboard() {
                 body: JSON.stringify(payload),
             });
 
+            if (res.status === 409 && !editingEventId) {
+                const data = await res.json();
+                if (data.duplicates) { setDuplicates(data.duplicates); return; }
+                throw new Error(data.message || "Failed");
+            }
             if (!res.ok) {
                 const data = await res.json();
                 throw new Error(data.message || "Failed");
@@ -702,6 +832,31 @@ export default function EventDashboard() {
                     <div className="form-body">
                         {formError && <div className="alert-box alert-error">{formError}</div>}
                         {formSuccess && <div className="alert-box alert-success">{formSuccess}</div>}
+                        {duplicates.length > 0 && (
+                            <div className="alert-box alert-error">
+                                <strong>⚠️ This looks like an event that already exists:</strong>
+                                <ul>
+                                    {duplicates.map((d: any) => (
+                                        <li key={d.id}>
+                                            {d.title} · {d.location} · {new Date(d.start_time).toLocaleString()} ({d.reasons.join(", ")}){" "}
+                                            <button type="button" className="btn btn-secondary" onClick={() => { setSearchQuery(d.title); fetchEvents(d.title, "", "All"); }}>View</button>
+                                        </li>
+                                    ))}
+                                </ul>
+                                <button type="button" className="btn btn-primary" onClick={() => submitEvent("link")}>Create and link</button>{" "}
+                                <button type="button" className="btn btn-secondary" onClick={() => submitEvent("ignore")}>Create anyway</button>
+                            </div>
+                        )}
+
+                        {!editingEventId && (
+                            <div className="form-section">
+                                <div className="section-title">✨ Draft with AI</div>
+                                <textarea className="input-light" placeholder={"- Topic\n- Audience\n- Time\n- Place"} value={draftNotes} onChange={e => setDraftNotes(e.target.value)}/>
+                                <button type="button" className="btn btn-secondary" onClick={handleDraft} disabled={drafting} style={{marginTop: '8px'}}>
+                                    {drafting ? "Drafting..." : "Suggest details"}
+                                </button>
+                            </div>
+                        )}
 
                         <div className="form-section">
                             <div className="section-title"><PencilSquareIcon style={{width: '20px', color: '#4f46e5'}}/> Basic Details</div>
@@ -720,6 +875,9 @@ export default function EventDashboard() {
                                 <div className="grid-full">
                                     <textarea className="input-light" placeholder="Description..." value={formData.description} onChange={e => setFormData({...formData, description: e.target.value})}/>
                                 </div>
+                                <div className="grid-full">
+                                    <input className="input-light" placeholder="Tags (comma separated)" value={formData.tags.join(", ")} onChange={e => setFormData({...formData, tags: e.target.value.split(",").map(t => t.trim())})}/>
+                                </div>
                             </div>
                         </div>
 
@@ -759,9 +917,20 @@ export default function EventDashboard() {
                                     <select className="select-light" value={formData.visibility} onChange={e => setFormData({...formData, visibility: e.target.value})}>
                                         <option value="PUBLIC">Public</option>
                                         <option value="PRIVATE">Private</option>
+                                        {myOrgs.length > 0 && <option value="MEMBERS">Members only</option>}
                                     </select>
                                     <ChevronDownIcon className="select-arrow"/>
                                 </div>
+                                {myOrgs.length > 0 && (
+                                    <div className="select-wrapper">
+                                        <UsersIcon className="input-icon"/>
+                                        <select className="select-light" value={formData.organization_id} onChange={e => setFormData({...formData, organization_id: Number(e.target.value)})}>
+                                            <option value={0}>Posting as myself</option>
+                                            {myOrgs.map(o => <option key={o.id} value={o.id}>{o.name}</option>)}
+                                        </select>
+                                        <ChevronDownIcon className="select-arrow"/>
+                                    </div>
+                                )}
                                 <div style={{display:'flex', alignItems:'center', gap:'10px', marginTop:'10px'}}>
                                     <input type="checkbox" id="recurring" checked={formData.is_recurring} onChange={e => setFormData({...formData, is_recurring: e.target.checked})} style={{width:'20px', height:'20px'}} />
                                     <label htmlFor="recurring" style={{fontSize:'14px', fontWeight:'600', color:'#374151'}}>Repeat Weekly</label>
@@ -935,6 +1104,9 @@ export default function EventDashboard() {
                         </select>
                         <ChevronDownIcon className="select-arrow"/>
                     </div>
+                    <label title="Search by meaning, e.g. “something chill with music on Friday”" style={{display:'flex', alignItems:'center', gap:'4px', fontSize:'13px', color:'#64748b', whiteSpace:'nowrap'}}>
+                        <input type="checkbox" checked={smartSearch} onChange={e => setSmartSearch(e.target.checked)}/> ✨ Smart
+                    </label>
                     <button onClick={() => fetchEvents(searchQuery, searchLocation, searchCategory)} className="btn btn-primary">Search</button>
                     <button onClick={() => { setSearchQuery(""); setSearchLocation(""); setSearchCategory("All"); fetchEvents("", "", "All") }} className="btn btn-text">Clear</button>
                 </div>
@@ -970,9 +1142,14 @@ export default function EventDashboard() {
                                         <div style={{fontSize: '14px', fontWeight: 'bold', marginTop: '5px', color: (isFull || isPast) ? '#dc2626' : '#16a34a'}}>
                                             👥 {evt.registered_count} / {evt.capacity} Spots Filled
                                         </div>
+                                        {friendsGoing[evt.id] && (
+                                            <div style={{fontSize: '13px', color: '#4338ca', marginTop: '5px'}}>
+                                                {friendsGoing[evt.id].length ? `👫 ${friendsGoing[evt.id].join(", ")}` : "No friends going yet"}
+                                            </div>
+                                        )}
                                         <div className="event-badges">
                                             <span className="badge badge-status">{evt.status}</span>
-                                            <span className={`badge ${evt.visibility === "PRIVATE" ? "badge-private" : "badge-public"}`}>{evt.visibility === "PRIVATE" ? "Private" : "Public"}</span>
+                                            <span className={`badge ${evt.visibility === "PRIVATE" ? "badge-private" : "badge-public"}`}>{evt.visibility === "PRIVATE" ? "Private" : evt.visibility === "MEMBERS" ? "Members" : "Public"}</span>
                                             {isPast && <span className="badge" style={{background: '#dc2626', color: 'white'}}>ENDED</span>}
                                         </div>
 
@@ -989,6 +1166,12 @@ export default function EventDashboard() {
                                                     {status === 'WAITLISTED' && <button onClick={() => handleCancelClick(evt.id)} className="btn btn-warning">Leave Waitlist</button>}
                                                 </>
                                             )}
+                                            {isAuthenticated && evt.organizer_id && evt.organizer_id !== myUserId && (
+                                                <button onClick={() => toggleFollow(evt.organizer_id!)} className="btn btn-secondary">
+                                                    {followedOrganizers.includes(evt.organizer_id) ? "Following" : "Follow"}
+                                                </button>
+                                            )}
+                                            {isAuthenticated && <button onClick={() => fetchFriendsGoing(evt.id)} className="btn btn-secondary">Friends going</button>}
 
                                             {/* --- Admin Only Actions --- */}
                                             {canManage && (
@@ -1186,7 +1369,10 @@ export default function EventDashboard() {
                                     <div style={{flex:1, overflowY:'auto', marginBottom:'10px'}}>
                                         {comments.length === 0 ? <p style={{color:'#999'}}>No comments yet.</p> : comments.map(c => (
                                             <div key={c.id} style={{background:'#f8fafc', padding:'10px', borderRadius:'8px', marginBottom:'8px'}}>
-                                                <div style={{fontSize:'12px', color:'#6b7280', fontWeight:'bold'}}>{c.user_email.split('@')[0]}</div>
+                                                <div style={{display:'flex', justifyContent:'space-between', fontSize:'12px', color:'#6b7280', fontWeight:'bold'}}>
+                                                    <span>{c.user_email.split('@')[0]}</span>
+                                                    <button onClick={() => reportContent("comment", c.id)} style={{background:'none', border:'none', color:'#94a3b8', cursor:'pointer', fontSize:'12px'}}>Report</button>
+                                                </div>
                                                 <div>{c.text}</div>
                                             </div>
                                         ))}